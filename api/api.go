@@ -0,0 +1,76 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/ONSdigital/dp-interactives-importer/config"
+	"github.com/ONSdigital/dp-interactives-importer/importer"
+	"github.com/ONSdigital/log.go/v2/log"
+	"github.com/gorilla/mux"
+)
+
+//go:generate moq -out mocks/importer.go -pkg mocks_api . Importer
+//go:generate moq -out mocks/consumer.go -pkg mocks_api . Consumer
+
+// Importer runs the same import pipeline as the kafka consumer
+type Importer interface {
+	NewJob(ctx context.Context, event *importer.InteractivesUploaded) *importer.Job
+	Import(ctx context.Context, job *importer.Job, event *importer.InteractivesUploaded, archive string, logData log.Data) error
+}
+
+// Consumer controls consumption of import events, so downstream services can be taken down for maintenance
+type Consumer interface {
+	PauseConsumer(ctx context.Context) error
+	ResumeConsumer(ctx context.Context) error
+	DrainConsumer(ctx context.Context) error
+	ConsumerStatus() ConsumerStatus
+}
+
+// API provides the import and consumer admin endpoints
+type API struct {
+	Router   *mux.Router
+	cfg      *config.Config
+	registry *importer.Registry
+	importer Importer
+	consumer Consumer
+}
+
+// Setup registers the import and consumer endpoints on the router, anything that creates an import or
+// changes the consumer requires the service auth token
+func Setup(ctx context.Context, cfg *config.Config, r *mux.Router, registry *importer.Registry, imp Importer, consumer Consumer) *API {
+	api := &API{
+		Router:   r,
+		cfg:      cfg,
+		registry: registry,
+		importer: imp,
+		consumer: consumer,
+	}
+
+	r.HandleFunc("/imports", api.ListImportsHandler).Methods(http.MethodGet)
+	r.HandleFunc("/imports", requireServiceToken(cfg.ServiceAuthToken, api.CreateImportHandler)).Methods(http.MethodPost)
+	r.HandleFunc("/imports/{id}", api.GetImportHandler).Methods(http.MethodGet)
+	r.HandleFunc("/consumer", api.GetConsumerHandler).Methods(http.MethodGet)
+	r.HandleFunc("/consumer/pause", requireServiceToken(cfg.ServiceAuthToken, api.PauseConsumerHandler)).Methods(http.MethodPost)
+	r.HandleFunc("/consumer/resume", requireServiceToken(cfg.ServiceAuthToken, api.ResumeConsumerHandler)).Methods(http.MethodPost)
+	r.HandleFunc("/consumer/drain", requireServiceToken(cfg.ServiceAuthToken, api.DrainConsumerHandler)).Methods(http.MethodPost)
+
+	log.Info(ctx, "import endpoints registered")
+	return api
+}
+
+func writeJSON(ctx context.Context, w http.ResponseWriter, status int, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		log.Error(ctx, "failed to marshal response", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if _, err = w.Write(b); err != nil {
+		log.Error(ctx, "failed to write response", err)
+	}
+}
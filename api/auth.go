@@ -0,0 +1,23 @@
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	dprequest "github.com/ONSdigital/dp-net/request"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// requireServiceToken only lets requests through that carry the service auth token as a bearer token
+func requireServiceToken(serviceAuthToken string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		token := strings.TrimPrefix(req.Header.Get(dprequest.AuthHeaderKey), dprequest.BearerPrefix)
+		if serviceAuthToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(serviceAuthToken)) != 1 {
+			log.Warn(req.Context(), "unauthorised request", log.Data{"method": req.Method, "path": req.URL.Path})
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		h(w, req)
+	}
+}
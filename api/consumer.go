@@ -0,0 +1,74 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+const defaultDrainTimeout = time.Minute
+
+// ConsumerStatus is the state of the kafka consumer of import events
+type ConsumerStatus struct {
+	State    string     `json:"state"`
+	Paused   bool       `json:"paused"`
+	PausedAt *time.Time `json:"paused_at,omitempty"`
+	InFlight int        `json:"in_flight"`
+}
+
+// PauseConsumerHandler stops consuming new import events, imports already in flight carry on
+func (api *API) PauseConsumerHandler(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	if err := api.consumer.PauseConsumer(ctx); err != nil {
+		log.Error(ctx, "failed to pause kafka consumer", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(ctx, w, http.StatusOK, api.consumer.ConsumerStatus())
+}
+
+// ResumeConsumerHandler starts consuming import events again
+func (api *API) ResumeConsumerHandler(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	if err := api.consumer.ResumeConsumer(ctx); err != nil {
+		log.Error(ctx, "failed to resume kafka consumer", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(ctx, w, http.StatusOK, api.consumer.ConsumerStatus())
+}
+
+// DrainConsumerHandler pauses the consumer and waits, up to the timeout query parameter, for in-flight
+// imports to finish. It returns 200 once drained, or 202 if imports are still running at the timeout.
+func (api *API) DrainConsumerHandler(w http.ResponseWriter, req *http.Request) {
+	timeout := defaultDrainTimeout
+	if t := req.URL.Query().Get("timeout"); t != "" {
+		var err error
+		if timeout, err = time.ParseDuration(t); err != nil || timeout <= 0 {
+			http.Error(w, "timeout must be a positive duration", http.StatusBadRequest)
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
+	defer cancel()
+
+	err := api.consumer.DrainConsumer(ctx)
+	switch {
+	case err == nil:
+		writeJSON(req.Context(), w, http.StatusOK, api.consumer.ConsumerStatus())
+	case errors.Is(err, context.DeadlineExceeded):
+		writeJSON(req.Context(), w, http.StatusAccepted, api.consumer.ConsumerStatus())
+	default:
+		log.Error(req.Context(), "failed to drain kafka consumer", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// GetConsumerHandler returns the state of the consumer
+func (api *API) GetConsumerHandler(w http.ResponseWriter, req *http.Request) {
+	writeJSON(req.Context(), w, http.StatusOK, api.consumer.ConsumerStatus())
+}
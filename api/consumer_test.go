@@ -0,0 +1,142 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ONSdigital/dp-interactives-importer/api"
+	mocks_api "github.com/ONSdigital/dp-interactives-importer/api/mocks"
+	"github.com/ONSdigital/dp-interactives-importer/config"
+	"github.com/ONSdigital/dp-interactives-importer/importer"
+	"github.com/gorilla/mux"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestConsumerEndpoints(t *testing.T) {
+
+	Convey("Given a consuming kafka consumer", t, func() {
+		status := api.ConsumerStatus{State: "Consuming"}
+		mockConsumer := &mocks_api.ConsumerMock{
+			PauseConsumerFunc: func(context.Context) error {
+				status = api.ConsumerStatus{State: "Stopped", Paused: true, InFlight: 1}
+				return nil
+			},
+			ResumeConsumerFunc: func(context.Context) error {
+				status = api.ConsumerStatus{State: "Consuming"}
+				return nil
+			},
+			DrainConsumerFunc: func(context.Context) error {
+				status = api.ConsumerStatus{State: "Stopped", Paused: true}
+				return nil
+			},
+			ConsumerStatusFunc: func() api.ConsumerStatus {
+				return status
+			},
+		}
+		r := mux.NewRouter()
+		api.Setup(context.TODO(), &config.Config{ServiceAuthToken: serviceAuthToken}, r, importer.NewRegistry(10), &mocks_api.ImporterMock{}, mockConsumer)
+
+		Convey("When its status is requested", func() {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/consumer", nil))
+
+			Convey("Then the state should be returned", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				var resp api.ConsumerStatus
+				So(json.Unmarshal(w.Body.Bytes(), &resp), ShouldBeNil)
+				So(resp.State, ShouldEqual, "Consuming")
+				So(resp.Paused, ShouldBeFalse)
+			})
+		})
+
+		Convey("When it is paused without the service auth token", func() {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/consumer/pause", nil))
+
+			Convey("Then it should be unauthorised", func() {
+				So(w.Code, ShouldEqual, http.StatusUnauthorized)
+				So(mockConsumer.PauseConsumerCalls(), ShouldBeEmpty)
+			})
+		})
+
+		Convey("When it is paused", func() {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, authorised(httptest.NewRequest(http.MethodPost, "/consumer/pause", nil)))
+
+			Convey("Then the paused state should be returned", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(mockConsumer.PauseConsumerCalls(), ShouldHaveLength, 1)
+				var resp api.ConsumerStatus
+				So(json.Unmarshal(w.Body.Bytes(), &resp), ShouldBeNil)
+				So(resp.Paused, ShouldBeTrue)
+				So(resp.InFlight, ShouldEqual, 1)
+			})
+
+			Convey("And then resumed", func() {
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, authorised(httptest.NewRequest(http.MethodPost, "/consumer/resume", nil)))
+
+				Convey("Then it should be consuming again", func() {
+					So(w.Code, ShouldEqual, http.StatusOK)
+					So(mockConsumer.ResumeConsumerCalls(), ShouldHaveLength, 1)
+					var resp api.ConsumerStatus
+					So(json.Unmarshal(w.Body.Bytes(), &resp), ShouldBeNil)
+					So(resp.State, ShouldEqual, "Consuming")
+				})
+			})
+		})
+
+		Convey("When pausing fails", func() {
+			mockConsumer.PauseConsumerFunc = func(context.Context) error {
+				return errors.New("consumer closing")
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, authorised(httptest.NewRequest(http.MethodPost, "/consumer/pause", nil)))
+
+			Convey("Then it should be an internal error", func() {
+				So(w.Code, ShouldEqual, http.StatusInternalServerError)
+			})
+		})
+
+		Convey("When it is drained", func() {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, authorised(httptest.NewRequest(http.MethodPost, "/consumer/drain?timeout=5s", nil)))
+
+			Convey("Then it should report once nothing is in flight", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(mockConsumer.DrainConsumerCalls(), ShouldHaveLength, 1)
+				deadline, ok := mockConsumer.DrainConsumerCalls()[0].Ctx.Deadline()
+				So(ok, ShouldBeTrue)
+				So(deadline, ShouldNotBeZeroValue)
+			})
+		})
+
+		Convey("When draining times out", func() {
+			mockConsumer.DrainConsumerFunc = func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, authorised(httptest.NewRequest(http.MethodPost, "/consumer/drain?timeout=1ms", nil)))
+
+			Convey("Then it should be accepted with imports still in flight", func() {
+				So(w.Code, ShouldEqual, http.StatusAccepted)
+			})
+		})
+
+		Convey("When it is drained with an invalid timeout", func() {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, authorised(httptest.NewRequest(http.MethodPost, "/consumer/drain?timeout=soon", nil)))
+
+			Convey("Then it should be a bad request", func() {
+				So(w.Code, ShouldEqual, http.StatusBadRequest)
+				So(mockConsumer.DrainConsumerCalls(), ShouldBeEmpty)
+			})
+		})
+	})
+}
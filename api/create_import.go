@@ -0,0 +1,230 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/ONSdigital/dp-interactives-importer/importer"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+const (
+	archiveFormField = "file"
+	maxFieldBytes    = 64 << 10
+)
+
+// errLengthRequired refuses an upload sent without a length, as the free space for the archive is reserved up front
+var errLengthRequired = errors.New("an uploaded archive needs a Content-Length")
+
+// ImportRequest takes the same fields as an interactives uploaded event
+type ImportRequest struct {
+	ID           string `json:"id"`
+	Path         string `json:"path"`
+	Title        string `json:"title"`
+	CollectionID string `json:"collection_id"`
+	Version      string `json:"version"`
+}
+
+// ImportResponse is returned when an import is accepted to run in the background
+type ImportResponse struct {
+	ID   string `json:"id"`
+	Href string `json:"href"`
+}
+
+// CreateImportHandler runs an import without going through kafka. The archive is either downloaded from S3
+// using the path, or uploaded as a multipart form file. The import runs in the background unless ?wait=true.
+func (api *API) CreateImportHandler(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+
+	wait, _ := strconv.ParseBool(req.URL.Query().Get("wait"))
+
+	if api.cfg.ImportMaxUploadBytes > 0 {
+		req.Body = http.MaxBytesReader(w, req.Body, api.cfg.ImportMaxUploadBytes)
+	}
+	event, archive, err := api.parseImportRequest(req)
+	if err != nil {
+		log.Error(ctx, "invalid import request", err)
+		http.Error(w, err.Error(), requestStatus(err))
+		return
+	}
+	logData := log.Data{"source": "http", "wait": wait}
+
+	// the import must not stop half way when the caller goes away, even when the caller waits for it
+	importCtx := context.Background()
+	if wait {
+		if archive != "" {
+			defer os.Remove(archive)
+		}
+		job := api.importer.NewJob(importCtx, event)
+		if err = api.importer.Import(importCtx, job, event, archive, logData); err != nil {
+			writeJSON(ctx, w, importStatus(err), job.Status())
+			return
+		}
+		writeJSON(ctx, w, http.StatusOK, job.Status())
+		return
+	}
+
+	job := api.importer.NewJob(importCtx, event)
+	go func() {
+		if archive != "" {
+			defer os.Remove(archive)
+		}
+		_ = api.importer.Import(importCtx, job, event, archive, logData)
+	}()
+
+	writeJSON(ctx, w, http.StatusAccepted, ImportResponse{
+		ID:   job.ID(),
+		Href: "/imports/" + job.ID(),
+	})
+}
+
+// parseImportRequest reads a JSON body, or a multipart form whose archive is saved to a temporary file
+func (api *API) parseImportRequest(req *http.Request) (_ *importer.InteractivesUploaded, archive string, err error) {
+	mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	defer func() {
+		if err != nil && archive != "" {
+			os.Remove(archive)
+		}
+	}()
+
+	var r ImportRequest
+	switch mediaType {
+	case "multipart/form-data":
+		if req.ContentLength < 0 {
+			return nil, "", errLengthRequired
+		}
+		var filename string
+		if archive, filename, err = api.readForm(req, &r); err != nil {
+			return nil, archive, err
+		}
+		if archive == "" {
+			return nil, "", fmt.Errorf("form file %q is required", archiveFormField)
+		}
+		if r.Path == "" {
+			r.Path = filepath.Base(filename)
+		}
+	default:
+		if err = json.NewDecoder(req.Body).Decode(&r); err != nil {
+			return nil, "", fmt.Errorf("cannot decode request body: %w", err)
+		}
+		if r.Path == "" {
+			return nil, "", errors.New("path is required")
+		}
+	}
+
+	if r.ID == "" {
+		return nil, archive, errors.New("id is required")
+	}
+
+	return &importer.InteractivesUploaded{
+		ID:           r.ID,
+		Path:         r.Path,
+		Title:        r.Title,
+		CollectionID: r.CollectionID,
+		Version:      r.Version,
+	}, archive, nil
+}
+
+// readForm streams the parts of a multipart form, so the archive is written straight to the temp directory
+// rather than buffered wherever the form parser puts large files
+func (api *API) readForm(req *http.Request, r *ImportRequest) (archive, filename string, err error) {
+	mr, err := req.MultipartReader()
+	if err != nil {
+		return "", "", err
+	}
+	fields := map[string]*string{
+		"id":            &r.ID,
+		"path":          &r.Path,
+		"title":         &r.Title,
+		"collection_id": &r.CollectionID,
+		"version":       &r.Version,
+	}
+
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return archive, filename, nil
+		}
+		if err != nil {
+			return archive, filename, err
+		}
+
+		switch name := part.FormName(); {
+		case name == archiveFormField && archive == "":
+			filename = part.FileName()
+			archive, err = api.saveArchive(part, req.ContentLength)
+		case fields[name] != nil:
+			var value []byte
+			if value, err = io.ReadAll(io.LimitReader(part, maxFieldBytes+1)); err == nil && len(value) > maxFieldBytes {
+				err = fmt.Errorf("form field %q is longer than %d bytes", name, maxFieldBytes)
+			}
+			*fields[name] = string(value)
+		}
+		part.Close()
+		if err != nil {
+			return archive, filename, err
+		}
+	}
+}
+
+// saveArchive writes an uploaded archive to the temp directory. The size of the archive is not known until it
+// has been read, so the size of the request, which is always known, is reserved instead.
+func (api *API) saveArchive(r io.Reader, size int64) (string, error) {
+	tmpZip, release, err := importer.CreateTempArchive(api.cfg.TempDir, size, api.cfg.TempDirMinFreeBytes)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	defer tmpZip.Close()
+
+	if _, err = io.Copy(tmpZip, r); err != nil {
+		os.Remove(tmpZip.Name())
+		return "", err
+	}
+	return tmpZip.Name(), nil
+}
+
+// requestStatus is the response status of a request that could not be read
+func requestStatus(err error) int {
+	var maxBytesErr *http.MaxBytesError
+	var pathErr *fs.PathError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, errLengthRequired):
+		return http.StatusLengthRequired
+	case errors.Is(err, importer.ErrInsufficientDiskSpace):
+		return http.StatusInsufficientStorage
+	case errors.As(err, &pathErr):
+		return http.StatusInternalServerError
+	}
+	return http.StatusBadRequest
+}
+
+// importStatus is the response status of a failed import that was waited for, which tells the caller whether
+// to fix the archive or try again later
+func importStatus(err error) int {
+	switch importer.CategoryOf(err) {
+	case importer.CategoryCorruptArchive, importer.CategoryPolicyViolation:
+		return http.StatusUnprocessableEntity
+	case importer.CategoryStorageUnavailable, importer.CategoryInterrupted:
+		return http.StatusServiceUnavailable
+	case importer.CategoryTimeout:
+		return http.StatusGatewayTimeout
+	case importer.CategoryUnauthorized:
+		return http.StatusBadGateway
+	}
+	if errors.Is(err, importer.ErrNotReported) {
+		return http.StatusBadGateway
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,251 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/dp-interactives-importer/api"
+	mocks_api "github.com/ONSdigital/dp-interactives-importer/api/mocks"
+	"github.com/ONSdigital/dp-interactives-importer/config"
+	"github.com/ONSdigital/dp-interactives-importer/importer"
+	mocks_importer "github.com/ONSdigital/dp-interactives-importer/importer/mocks"
+	"github.com/ONSdigital/log.go/v2/log"
+	"github.com/gorilla/mux"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const serviceAuthToken = "token"
+
+func TestCreateImportHandler(t *testing.T) {
+
+	Convey("Given an importer", t, func() {
+		registry := importer.NewRegistry(10)
+		done := make(chan string, 1)
+		mockImporter := &mocks_api.ImporterMock{
+			NewJobFunc: func(ctx context.Context, event *importer.InteractivesUploaded) *importer.Job {
+				job := importer.NewJob(ctx, &config.Config{}, &mocks_importer.InteractivesAPIClientMock{})
+				job.Start(event)
+				registry.Add(job)
+				return job
+			},
+			ImportFunc: func(_ context.Context, _ *importer.Job, _ *importer.InteractivesUploaded, archive string, _ log.Data) error {
+				done <- archive
+				return nil
+			},
+		}
+		tempDir := t.TempDir()
+		cfg := &config.Config{ServiceAuthToken: serviceAuthToken, TempDir: tempDir, ImportMaxUploadBytes: 1024}
+		r := mux.NewRouter()
+		api.Setup(context.TODO(), cfg, r, registry, mockImporter, &mocks_api.ConsumerMock{})
+
+		Convey("When an import is requested without the service auth token", func() {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/imports", strings.NewReader(`{"id":"1","path":"a.zip"}`)))
+
+			Convey("Then it should be unauthorised", func() {
+				So(w.Code, ShouldEqual, http.StatusUnauthorized)
+				So(mockImporter.NewJobCalls(), ShouldBeEmpty)
+			})
+		})
+
+		Convey("When an import is requested without an id", func() {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, authorised(httptest.NewRequest(http.MethodPost, "/imports", strings.NewReader(`{"path":"a.zip"}`))))
+
+			Convey("Then it should be a bad request", func() {
+				So(w.Code, ShouldEqual, http.StatusBadRequest)
+				So(mockImporter.NewJobCalls(), ShouldBeEmpty)
+			})
+		})
+
+		Convey("When an import of an S3 archive is requested", func() {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, authorised(httptest.NewRequest(http.MethodPost, "/imports", strings.NewReader(`{"id":"1","path":"abc/a.zip","title":"t"}`))))
+
+			Convey("Then it should be accepted with a job id to poll", func() {
+				So(w.Code, ShouldEqual, http.StatusAccepted)
+				var resp api.ImportResponse
+				So(json.Unmarshal(w.Body.Bytes(), &resp), ShouldBeNil)
+				So(resp.ID, ShouldNotBeEmpty)
+				So(resp.Href, ShouldEqual, "/imports/"+resp.ID)
+				So(<-done, ShouldBeEmpty)
+
+				event := mockImporter.NewJobCalls()[0].Event
+				So(event.ID, ShouldEqual, "1")
+				So(event.Path, ShouldEqual, "abc/a.zip")
+				So(event.Title, ShouldEqual, "t")
+			})
+		})
+
+		Convey("When an import is requested and waited for", func() {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, authorised(httptest.NewRequest(http.MethodPost, "/imports?wait=true", strings.NewReader(`{"id":"1","path":"abc/a.zip"}`))))
+
+			Convey("Then the result should be returned", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				var status importer.Status
+				So(json.Unmarshal(w.Body.Bytes(), &status), ShouldBeNil)
+				So(status.InteractiveID, ShouldEqual, "1")
+			})
+		})
+
+		Convey("When the caller waiting for an import goes away", func() {
+			var importCtx context.Context
+			mockImporter.ImportFunc = func(ctx context.Context, _ *importer.Job, _ *importer.InteractivesUploaded, _ string, _ log.Data) error {
+				importCtx = ctx
+				return nil
+			}
+			reqCtx, cancel := context.WithCancel(context.Background())
+			cancel()
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, authorised(httptest.NewRequest(http.MethodPost, "/imports?wait=true", strings.NewReader(`{"id":"1","path":"abc/a.zip"}`)).WithContext(reqCtx)))
+
+			Convey("Then the import should not be cancelled", func() {
+				So(importCtx, ShouldNotBeNil)
+				So(importCtx.Err(), ShouldBeNil)
+			})
+		})
+
+		Convey("When a failing import is requested and waited for", func() {
+			statuses := map[importer.Category]int{
+				importer.CategoryCorruptArchive:     http.StatusUnprocessableEntity,
+				importer.CategoryPolicyViolation:    http.StatusUnprocessableEntity,
+				importer.CategoryStorageUnavailable: http.StatusServiceUnavailable,
+				importer.CategoryTimeout:            http.StatusGatewayTimeout,
+				importer.CategoryUnauthorized:       http.StatusBadGateway,
+				importer.CategoryUnknown:            http.StatusInternalServerError,
+			}
+
+			Convey("Then the status should depend on the category of the failure", func() {
+				for category, status := range statuses {
+					importErr := &importer.ImportError{Category: category, Err: errors.New("import failed")}
+					mockImporter.ImportFunc = func(context.Context, *importer.Job, *importer.InteractivesUploaded, string, log.Data) error {
+						return importErr
+					}
+					w := httptest.NewRecorder()
+					r.ServeHTTP(w, authorised(httptest.NewRequest(http.MethodPost, "/imports?wait=true", strings.NewReader(`{"id":"1","path":"abc/a.zip"}`))))
+					So(w.Code, ShouldEqual, status)
+				}
+			})
+
+			Convey("Then an uncategorised failure should be an internal error", func() {
+				mockImporter.ImportFunc = func(context.Context, *importer.Job, *importer.InteractivesUploaded, string, log.Data) error {
+					return errors.New("invalid archive")
+				}
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, authorised(httptest.NewRequest(http.MethodPost, "/imports?wait=true", strings.NewReader(`{"id":"1","path":"abc/a.zip"}`))))
+				So(w.Code, ShouldEqual, http.StatusInternalServerError)
+			})
+		})
+
+		Convey("When a zip is uploaded directly", func() {
+			req := uploadRequest(map[string]string{"id": "1"}, "local.zip", []byte("zip content"))
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, authorised(req))
+
+			Convey("Then the uploaded archive should be imported from a local copy in the temp directory", func() {
+				So(w.Code, ShouldEqual, http.StatusAccepted)
+				archive := <-done
+				So(filepath.Dir(archive), ShouldEqual, tempDir)
+				So(filepath.Base(archive), ShouldStartWith, "s3-zip_")
+				So(mockImporter.NewJobCalls()[0].Event.Path, ShouldEqual, "local.zip")
+			})
+		})
+
+		Convey("When a zip is uploaded directly with the fields after it", func() {
+			body := &bytes.Buffer{}
+			form := multipart.NewWriter(body)
+			fw, err := form.CreateFormFile("file", "local.zip")
+			So(err, ShouldBeNil)
+			_, err = fw.Write([]byte("zip content"))
+			So(err, ShouldBeNil)
+			So(form.WriteField("id", "1"), ShouldBeNil)
+			So(form.WriteField("path", "abc/a.zip"), ShouldBeNil)
+			So(form.Close(), ShouldBeNil)
+			req := httptest.NewRequest(http.MethodPost, "/imports", body)
+			req.Header.Set("Content-Type", form.FormDataContentType())
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, authorised(req))
+
+			Convey("Then the fields should still be read", func() {
+				So(w.Code, ShouldEqual, http.StatusAccepted)
+				So(<-done, ShouldNotBeEmpty)
+				So(mockImporter.NewJobCalls()[0].Event.ID, ShouldEqual, "1")
+				So(mockImporter.NewJobCalls()[0].Event.Path, ShouldEqual, "abc/a.zip")
+			})
+		})
+
+		Convey("When an uploaded zip is larger than the maximum", func() {
+			req := uploadRequest(map[string]string{"id": "1"}, "local.zip", bytes.Repeat([]byte("z"), 2048))
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, authorised(req))
+
+			Convey("Then it should be too large and nothing should be left in the temp directory", func() {
+				So(w.Code, ShouldEqual, http.StatusRequestEntityTooLarge)
+				So(mockImporter.NewJobCalls(), ShouldBeEmpty)
+				entries, err := os.ReadDir(tempDir)
+				So(err, ShouldBeNil)
+				So(entries, ShouldBeEmpty)
+			})
+		})
+
+		Convey("When a zip is uploaded without a length", func() {
+			req := uploadRequest(map[string]string{"id": "1"}, "local.zip", []byte("zip content"))
+			req.ContentLength = -1
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, authorised(req))
+
+			Convey("Then it should be refused before anything is written", func() {
+				So(w.Code, ShouldEqual, http.StatusLengthRequired)
+				entries, err := os.ReadDir(tempDir)
+				So(err, ShouldBeNil)
+				So(entries, ShouldBeEmpty)
+			})
+		})
+
+		Convey("When a zip is uploaded without an id", func() {
+			req := uploadRequest(map[string]string{}, "local.zip", []byte("zip content"))
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, authorised(req))
+
+			Convey("Then it should be a bad request and the archive should be removed", func() {
+				So(w.Code, ShouldEqual, http.StatusBadRequest)
+				entries, err := os.ReadDir(tempDir)
+				So(err, ShouldBeNil)
+				So(entries, ShouldBeEmpty)
+			})
+		})
+	})
+}
+
+func uploadRequest(fields map[string]string, filename string, content []byte) *http.Request {
+	body := &bytes.Buffer{}
+	form := multipart.NewWriter(body)
+	for k, v := range fields {
+		So(form.WriteField(k, v), ShouldBeNil)
+	}
+	fw, err := form.CreateFormFile("file", filename)
+	So(err, ShouldBeNil)
+	_, err = fw.Write(content)
+	So(err, ShouldBeNil)
+	So(form.Close(), ShouldBeNil)
+
+	req := httptest.NewRequest(http.MethodPost, "/imports", body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	return req
+}
+
+func authorised(req *http.Request) *http.Request {
+	req.Header.Set("Authorization", "Bearer "+serviceAuthToken)
+	return req
+}
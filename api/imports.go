@@ -0,0 +1,34 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/ONSdigital/dp-interactives-importer/importer"
+	"github.com/gorilla/mux"
+)
+
+// ImportsResponse lists recent and in-flight imports, newest first
+type ImportsResponse struct {
+	Count int               `json:"count"`
+	Items []importer.Status `json:"items"`
+}
+
+// ListImportsHandler returns every import held by the registry
+func (api *API) ListImportsHandler(w http.ResponseWriter, req *http.Request) {
+	items := api.registry.List()
+	writeJSON(req.Context(), w, http.StatusOK, ImportsResponse{
+		Count: len(items),
+		Items: items,
+	})
+}
+
+// GetImportHandler returns the progress of a single import
+func (api *API) GetImportHandler(w http.ResponseWriter, req *http.Request) {
+	id := mux.Vars(req)["id"]
+	status, ok := api.registry.Get(id)
+	if !ok {
+		http.Error(w, "import not found", http.StatusNotFound)
+		return
+	}
+	writeJSON(req.Context(), w, http.StatusOK, status)
+}
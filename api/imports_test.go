@@ -0,0 +1,73 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ONSdigital/dp-interactives-importer/api"
+	mocks_api "github.com/ONSdigital/dp-interactives-importer/api/mocks"
+	"github.com/ONSdigital/dp-interactives-importer/config"
+	"github.com/ONSdigital/dp-interactives-importer/importer"
+	mocks_importer "github.com/ONSdigital/dp-interactives-importer/importer/mocks"
+	"github.com/gorilla/mux"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestImportsEndpoints(t *testing.T) {
+
+	Convey("Given a registry with one in-flight import", t, func() {
+		registry := importer.NewRegistry(10)
+		job := importer.NewJob(context.TODO(), &config.Config{}, &mocks_importer.InteractivesAPIClientMock{})
+		job.Start(&importer.InteractivesUploaded{ID: "interactive-1", Path: "abc/test.zip"})
+		job.SetStage(importer.StageUpload)
+		job.SetFilesTotal(10)
+		job.FileProcessed(100)
+		registry.Add(job)
+
+		r := mux.NewRouter()
+		api.Setup(context.TODO(), &config.Config{ServiceAuthToken: "token"}, r, registry, &mocks_api.ImporterMock{}, &mocks_api.ConsumerMock{})
+
+		Convey("When the imports are listed", func() {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/imports", nil))
+
+			Convey("Then the in-flight import should be returned", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				var resp api.ImportsResponse
+				So(json.Unmarshal(w.Body.Bytes(), &resp), ShouldBeNil)
+				So(resp.Count, ShouldEqual, 1)
+				So(resp.Items[0].ID, ShouldEqual, job.ID())
+				So(resp.Items[0].InteractiveID, ShouldEqual, "interactive-1")
+				So(resp.Items[0].InFlight, ShouldBeTrue)
+			})
+		})
+
+		Convey("When the import is requested by id", func() {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/imports/"+job.ID(), nil))
+
+			Convey("Then its progress should be returned", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				var status importer.Status
+				So(json.Unmarshal(w.Body.Bytes(), &status), ShouldBeNil)
+				So(status.Stage, ShouldEqual, importer.StageUpload)
+				So(status.FilesProcessed, ShouldEqual, 1)
+				So(status.FilesTotal, ShouldEqual, 10)
+				So(status.BytesUploaded, ShouldEqual, 100)
+			})
+		})
+
+		Convey("When an unknown import is requested", func() {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/imports/unknown", nil))
+
+			Convey("Then it should not be found", func() {
+				So(w.Code, ShouldEqual, http.StatusNotFound)
+			})
+		})
+	})
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/ONSdigital/dp-interactives-importer/importer"
+	"github.com/ONSdigital/dp-interactives-importer/schema"
+)
+
+// csvColumns are the columns a bulk CSV file may have, in any order, named by its header row
+var csvColumns = []string{"id", "path", "title", "collection_id", "version"}
+
+// eventRecord is an event as written in a bulk JSON file
+type eventRecord struct {
+	ID           string `json:"id"`
+	Path         string `json:"path"`
+	Title        string `json:"title"`
+	CollectionID string `json:"collection_id"`
+	Version      string `json:"version"`
+}
+
+// readEvents reads the events of a bulk file, as CSV or JSON depending on its extension
+func readEvents(name string, r io.Reader) ([]importer.InteractivesUploaded, error) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv":
+		return readCSV(r)
+	case ".json":
+		return readJSON(r)
+	default:
+		return nil, fmt.Errorf("cannot read events from %s, expected a .csv or .json file", name)
+	}
+}
+
+func readCSV(r io.Reader) ([]importer.InteractivesUploaded, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, errors.New("no header row")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]int)
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(column))
+		if !contains(csvColumns, column) {
+			return nil, fmt.Errorf("unknown column %q, expected %s", column, strings.Join(csvColumns, ", "))
+		}
+		index[column] = i
+	}
+	for _, column := range []string{"id", "path"} {
+		if _, ok := index[column]; !ok {
+			return nil, fmt.Errorf("missing column %q", column)
+		}
+	}
+	value := func(row []string, column string) string {
+		if i, ok := index[column]; ok {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	var events []importer.InteractivesUploaded
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			return events, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, importer.InteractivesUploaded{
+			ID:           value(row, "id"),
+			Path:         value(row, "path"),
+			Title:        value(row, "title"),
+			CollectionID: value(row, "collection_id"),
+			Version:      value(row, "version"),
+		})
+	}
+}
+
+func readJSON(r io.Reader) ([]importer.InteractivesUploaded, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	var records []eventRecord
+	if err := dec.Decode(&records); err != nil {
+		return nil, err
+	}
+	events := make([]importer.InteractivesUploaded, len(records))
+	for i, rec := range records {
+		events[i] = importer.InteractivesUploaded(rec)
+	}
+	return events, nil
+}
+
+// marshal validates an event and encodes it with the schema the importer consumes, checking it decodes back unchanged
+func marshal(event importer.InteractivesUploaded) ([]byte, error) {
+	if event.ID == "" {
+		return nil, errors.New("missing id")
+	}
+	if event.Path == "" {
+		return nil, errors.New("missing path")
+	}
+	b, err := schema.InteractivesUploadedEvent.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	var decoded importer.InteractivesUploaded
+	if err = schema.InteractivesUploadedEvent.Unmarshal(b, &decoded); err != nil {
+		return nil, err
+	}
+	if decoded != event {
+		return nil, fmt.Errorf("event does not match the schema, decoded as %+v", decoded)
+	}
+	return b, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/dp-interactives-importer/config"
+	"github.com/ONSdigital/dp-interactives-importer/importer"
+	"github.com/ONSdigital/dp-interactives-importer/schema"
+	"github.com/ONSdigital/dp-interactives-importer/storage"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestReadEvents(t *testing.T) {
+
+	Convey("Given a CSV file of events with its columns in any order", t, func() {
+		csv := "path, id, collection_id, title, version\n" +
+			"abc/one.zip, 1, collection-1, \"Population, 2021\", 3\n" +
+			"abc/two.zip, 2, , , \n"
+
+		Convey("Then every row should be read as an event", func() {
+			events, err := readEvents("events.CSV", strings.NewReader(csv))
+			So(err, ShouldBeNil)
+			So(events, ShouldResemble, []importer.InteractivesUploaded{
+				{ID: "1", Path: "abc/one.zip", Title: "Population, 2021", CollectionID: "collection-1", Version: "3"},
+				{ID: "2", Path: "abc/two.zip"},
+			})
+		})
+	})
+
+	Convey("Given CSV files with bad headers", t, func() {
+
+		Convey("Then unknown or missing columns should be refused", func() {
+			_, err := readEvents("events.csv", strings.NewReader("id,path,name\n1,a.zip,x\n"))
+			So(err, ShouldNotBeNil)
+			_, err = readEvents("events.csv", strings.NewReader("id,title\n1,x\n"))
+			So(err, ShouldNotBeNil)
+			_, err = readEvents("events.csv", strings.NewReader(""))
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("Given a JSON file of events", t, func() {
+		json := `[{"id": "1", "path": "abc/one.zip", "title": "One", "collection_id": "collection-1", "version": "3"}, {"id": "2", "path": "abc/two.zip"}]`
+
+		Convey("Then every object should be read as an event", func() {
+			events, err := readEvents("events.json", strings.NewReader(json))
+			So(err, ShouldBeNil)
+			So(events, ShouldResemble, []importer.InteractivesUploaded{
+				{ID: "1", Path: "abc/one.zip", Title: "One", CollectionID: "collection-1", Version: "3"},
+				{ID: "2", Path: "abc/two.zip"},
+			})
+		})
+
+		Convey("Then unknown fields should be refused", func() {
+			_, err := readEvents("events.json", strings.NewReader(`[{"id": "1", "pth": "abc/one.zip"}]`))
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("Then any other kind of file should be refused", t, func() {
+		_, err := readEvents("events.txt", strings.NewReader(""))
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestMarshal(t *testing.T) {
+
+	Convey("Given a complete event", t, func() {
+		event := importer.InteractivesUploaded{ID: "1", Path: "abc/one.zip", Title: "One", CollectionID: "collection-1", Version: "3"}
+
+		Convey("Then it should be encoded as the importer expects", func() {
+			b, err := marshal(event)
+			So(err, ShouldBeNil)
+			var decoded importer.InteractivesUploaded
+			So(schema.InteractivesUploadedEvent.Unmarshal(b, &decoded), ShouldBeNil)
+			So(decoded, ShouldResemble, event)
+		})
+
+		Convey("Then it should be refused without an id or path", func() {
+			_, err := marshal(importer.InteractivesUploaded{Path: "abc/one.zip"})
+			So(err, ShouldNotBeNil)
+			_, err = marshal(importer.InteractivesUploaded{ID: "1"})
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestUploadArchive(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Given the filesystem download backend and a local zip", t, func() {
+		cfg := &config.Config{DownloadBackend: storage.BackendFilesystem, DownloadDir: t.TempDir()}
+		file := filepath.Join(t.TempDir(), "single-interactive.zip")
+		So(os.WriteFile(file, []byte("archive"), 0o644), ShouldBeNil)
+		key := archiveKey("1", file)
+
+		Convey("When it is uploaded", func() {
+			So(uploadArchive(ctx, cfg, file, key), ShouldBeNil)
+
+			Convey("Then the importer should find it at the event path", func() {
+				So(key, ShouldEqual, "1/single-interactive.zip")
+				bucket, err := storage.NewFileBucket(cfg.DownloadDir)
+				So(err, ShouldBeNil)
+				r, _, err := bucket.Get(key)
+				So(err, ShouldBeNil)
+				defer r.Close()
+				b, err := io.ReadAll(r)
+				So(err, ShouldBeNil)
+				So(string(b), ShouldEqual, "archive")
+			})
+		})
+
+		Convey("Then an unknown backend should be refused", func() {
+			cfg.DownloadBackend = "ftp"
+			So(uploadArchive(ctx, cfg, file, key), ShouldNotBeNil)
+		})
+	})
+}
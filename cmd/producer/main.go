@@ -2,20 +2,45 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/ONSdigital/dp-interactives-importer/config"
 	"github.com/ONSdigital/dp-interactives-importer/importer"
-	"github.com/ONSdigital/dp-interactives-importer/schema"
 	kafka "github.com/ONSdigital/dp-kafka/v3"
 	"github.com/ONSdigital/log.go/v2/log"
 )
 
+const usage = `Usage: producer <command> [flags]
+
+Sends interactives uploaded events for the importer to consume.
+
+Commands:
+  send    send one event
+  bulk    send the events of a CSV or JSON file
+  upload  upload a local zip to the download bucket, then send its event
+
+Run producer <command> -h for the flags of a command.
+`
+
+// options are the flags shared by every command
+type options struct {
+	brokers string
+	topic   string
+	timeout time.Duration
+}
+
 func main() {
 	ctx := context.Background()
-	var brokers []string
-	brokers = append(brokers, "localhost:9092")
+
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
 
 	cfg, err := config.Get()
 	if err != nil {
@@ -23,38 +48,165 @@ func main() {
 		os.Exit(1)
 	}
 
-	pConfig := &kafka.ProducerConfig{
-		BrokerAddrs:     cfg.Brokers,               // compulsory
-		Topic:           cfg.InteractivesReadTopic, // compulsory
-		KafkaVersion:    &cfg.KafkaVersion,
-		MaxMessageBytes: &cfg.KafkaMaxBytes,
+	command, args := os.Args[1], os.Args[2:]
+	switch command {
+	case "send":
+		err = send(ctx, cfg, args)
+	case "bulk":
+		err = bulk(ctx, cfg, args)
+	case "upload":
+		err = upload(ctx, cfg, args)
+	case "-h", "-help", "--help", "help":
+		fmt.Fprint(os.Stdout, usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", command, usage)
+		os.Exit(2)
+	}
+	if errors.Is(err, flag.ErrHelp) {
+		return
 	}
-	producer, err := kafka.NewProducer(ctx, pConfig)
 	if err != nil {
-		log.Fatal(ctx, "failed to create kafka producer", err)
+		log.Fatal(ctx, "failed to "+command, err)
 		os.Exit(1)
 	}
+}
 
-	//aws --endpoint-url=http://localhost:4566 s3 cp ~/Desktop/ovpn_configs.zip s3://testing/
-	//browser at to see ls -> http://localhost:4566/testing
+// newFlagSet returns the flags of a command, with the shared options defaulting to the config
+func newFlagSet(name string, cfg *config.Config) (*flag.FlagSet, *options) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	opts := &options{}
+	fs.StringVar(&opts.brokers, "brokers", strings.Join(cfg.Brokers, ","), "comma separated kafka brokers")
+	fs.StringVar(&opts.topic, "topic", cfg.InteractivesReadTopic, "topic to send to")
+	fs.DurationVar(&opts.timeout, "timeout", 30*time.Second, "how long to wait for kafka")
+	return fs, opts
+}
 
-	//https://docs.aws.amazon.com/AmazonS3/latest/userguide/VirtualHosting.html
-	//https://bucket-name.s3.Region.amazonaws.com/key-name
+// eventFlags adds the flags describing a single event
+func eventFlags(fs *flag.FlagSet) *importer.InteractivesUploaded {
+	event := &importer.InteractivesUploaded{}
+	fs.StringVar(&event.ID, "id", "", "id of the interactive (required)")
+	fs.StringVar(&event.Path, "path", "", "key of the archive in the download bucket")
+	fs.StringVar(&event.Title, "title", "", "title of the interactive")
+	fs.StringVar(&event.CollectionID, "collection", "", "id of the collection holding the interactive")
+	fs.StringVar(&event.Version, "version", "", "version of the archive, which changes whenever a new archive is uploaded to the path")
+	return event
+}
 
-	event1 := importer.InteractivesUploaded{
-		ID:   "52bd5e13-8dda-4593-bfe3-d4999bf3cd51", //manually created via Postman
-		Path: "f5XNzqLK76cMwldF835lkCuKO34=/single-interactive.zip",
+func send(ctx context.Context, cfg *config.Config, args []string) error {
+	fs, opts := newFlagSet("send", cfg)
+	event := eventFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
 	}
-	sendEvent(producer, event1)
+	return produce(ctx, cfg, opts, []importer.InteractivesUploaded{*event})
+}
 
-	time.Sleep(5 * time.Second)
-	producer.Close(context.TODO())
+func bulk(ctx context.Context, cfg *config.Config, args []string) error {
+	fs, opts := newFlagSet("bulk", cfg)
+	file := fs.String("file", "", "CSV or JSON file of events (required)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return errors.New("-file is required")
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	events, err := readEvents(*file, f)
+	if err != nil {
+		return fmt.Errorf("cannot read %s: %w", *file, err)
+	}
+	return produce(ctx, cfg, opts, events)
 }
 
-func sendEvent(producer *kafka.Producer, v importer.InteractivesUploaded) {
-	bytes, err := schema.InteractivesUploadedEvent.Marshal(v)
+func upload(ctx context.Context, cfg *config.Config, args []string) error {
+	fs, opts := newFlagSet("upload", cfg)
+	event := eventFlags(fs)
+	file := fs.String("file", "", "zip file to upload (required)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return errors.New("-file is required")
+	}
+	if event.Path == "" {
+		event.Path = archiveKey(event.ID, *file)
+	}
+	// validate before uploading, so a bad event leaves nothing behind
+	if _, err := marshal(*event); err != nil {
+		return err
+	}
+
+	logData := log.Data{"file": *file, "path": event.Path, "backend": cfg.DownloadBackend}
+	log.Info(ctx, "uploading archive", logData)
+	if err := uploadArchive(ctx, cfg, *file, event.Path); err != nil {
+		return err
+	}
+	log.Info(ctx, "uploaded archive", logData)
+	return produce(ctx, cfg, opts, []importer.InteractivesUploaded{*event})
+}
+
+// produce sends the events once every one of them is valid
+func produce(ctx context.Context, cfg *config.Config, opts *options, events []importer.InteractivesUploaded) error {
+	if len(events) == 0 {
+		return errors.New("no events to send")
+	}
+	messages := make([][]byte, len(events))
+	for i, event := range events {
+		b, err := marshal(event)
+		if err != nil {
+			return fmt.Errorf("invalid event %d (id %q): %w", i+1, event.ID, err)
+		}
+		messages[i] = b
+	}
+
+	minBrokersHealthy := 1 // a single local broker is enough to send a few events
+	pConfig := &kafka.ProducerConfig{
+		BrokerAddrs:       strings.Split(opts.brokers, ","), // compulsory
+		Topic:             opts.topic,                       // compulsory
+		KafkaVersion:      &cfg.KafkaVersion,
+		MaxMessageBytes:   &cfg.KafkaMaxBytes,
+		MinBrokersHealthy: &minBrokersHealthy,
+	}
+	if cfg.KafkaSecProtocol == "TLS" {
+		pConfig.SecurityConfig = kafka.GetSecurityConfig(
+			cfg.KafkaSecCACerts,
+			cfg.KafkaSecClientCert,
+			cfg.KafkaSecClientKey,
+			cfg.KafkaSecSkipVerify,
+		)
+	}
+	producer, err := kafka.NewProducer(ctx, pConfig)
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("failed to create kafka producer: %w", err)
+	}
+	producer.LogErrors(ctx)
+
+	select {
+	case <-producer.Channels().Initialised:
+	case <-time.After(opts.timeout):
+		closeProducer(ctx, producer, opts.timeout)
+		return fmt.Errorf("kafka producer not initialised after %s", opts.timeout)
+	}
+
+	for i, b := range messages {
+		if err = kafka.SafeSendBytes(producer.Channels().Output, b); err != nil {
+			closeProducer(ctx, producer, opts.timeout)
+			return err
+		}
+		log.Info(ctx, "sent event", log.Data{"id": events[i].ID, "path": events[i].Path, "topic": opts.topic})
 	}
-	producer.Channels().Output <- bytes
+	// closing flushes the messages still buffered by the producer
+	return closeProducer(ctx, producer, opts.timeout)
+}
+
+func closeProducer(ctx context.Context, producer *kafka.Producer, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return producer.Close(ctx)
 }
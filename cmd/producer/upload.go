@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/ONSdigital/dp-interactives-importer/config"
+	"github.com/ONSdigital/dp-interactives-importer/internal/awssession"
+	"github.com/ONSdigital/dp-interactives-importer/storage"
+	dps3 "github.com/ONSdigital/dp-s3"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+)
+
+// archiveKey is where an archive goes when no path is given: under the interactive id, by its file name
+func archiveKey(id, file string) string {
+	return path.Join(id, filepath.Base(file))
+}
+
+// uploadArchive copies a local archive to the bucket the importer downloads from, as DOWNLOAD_BACKEND chooses
+func uploadArchive(ctx context.Context, cfg *config.Config, file, key string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	switch cfg.DownloadBackend {
+	case storage.BackendFilesystem:
+		bucket, err := storage.NewFileBucket(cfg.DownloadDir)
+		if err != nil {
+			return err
+		}
+		return bucket.Put(key, f)
+	case storage.BackendS3, "":
+		s, err := awssession.New(cfg)
+		if err != nil {
+			return err
+		}
+		uploader := dps3.NewUploaderWithSession(cfg.DownloadBucketName, s)
+		_, err = uploader.UploadWithContext(ctx, &s3manager.UploadInput{
+			Bucket:      aws.String(cfg.DownloadBucketName),
+			Key:         aws.String(key),
+			Body:        f,
+			ContentType: aws.String("application/zip"),
+		})
+		return err
+	default:
+		return fmt.Errorf("unknown download backend: %s", cfg.DownloadBackend)
+	}
+}
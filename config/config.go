@@ -1,6 +1,8 @@
 package config
 
 import (
+	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/kelseyhightower/envconfig"
@@ -9,11 +11,17 @@ import (
 type Config struct {
 	BindAddr                   string        `envconfig:"BIND_ADDR"`
 	UploadAPIURL               string        `envconfig:"UPLOAD_API_URL"`
+	UploadBackend              string        `envconfig:"UPLOAD_BACKEND"`
+	UploadDir                  string        `envconfig:"UPLOAD_DIR"`
+	UploadBucketName           string        `envconfig:"UPLOAD_BUCKET_NAME"`
+	UploadPartSize             int64         `envconfig:"UPLOAD_PART_SIZE"`
 	InteractivesAPIURL         string        `envconfig:"INTERACTIVES_API_URL"`
 	ServiceAuthToken           string        `envconfig:"SERVICE_AUTH_TOKEN" json:"-"`
 	AwsEndpoint                string        `envconfig:"AWS_ENDPOINT"`
 	AwsRegion                  string        `envconfig:"AWS_REGION"`
 	DownloadBucketName         string        `envconfig:"DOWNLOAD_BUCKET_NAME"`
+	DownloadBackend            string        `envconfig:"DOWNLOAD_BACKEND"`
+	DownloadDir                string        `envconfig:"DOWNLOAD_DIR"`
 	Brokers                    []string      `envconfig:"KAFKA_ADDR"`
 	KafkaMaxBytes              int           `envconfig:"KAFKA_MAX_BYTES"`
 	KafkaVersion               string        `envconfig:"KAFKA_VERSION"`
@@ -24,11 +32,62 @@ type Config struct {
 	KafkaSecSkipVerify         bool          `envconfig:"KAFKA_SEC_SKIP_VERIFY"`
 	InteractivesReadTopic      string        `envconfig:"INTERACTIVES_READ_TOPIC"`
 	InteractivesGroup          string        `envconfig:"INTERACTIVES_GROUP"`
+	ProgressEnabled            bool          `envconfig:"PROGRESS_ENABLED"`
+	ProgressTopic              string        `envconfig:"PROGRESS_TOPIC"`
+	ProgressInterval           time.Duration `envconfig:"PROGRESS_INTERVAL"`
 	KafkaConsumerWorkers       int           `envconfig:"KAFKA_CONSUMER_WORKERS"`
 	GracefulShutdownTimeout    time.Duration `envconfig:"GRACEFUL_SHUTDOWN_TIMEOUT"`
+	ShutdownDrainTimeout       time.Duration `envconfig:"SHUTDOWN_DRAIN_TIMEOUT"`
+	ShutdownInterruptTimeout   time.Duration `envconfig:"SHUTDOWN_INTERRUPT_TIMEOUT"`
 	HealthCheckInterval        time.Duration `envconfig:"HEALTHCHECK_INTERVAL"`
 	HealthCheckCriticalTimeout time.Duration `envconfig:"HEALTHCHECK_CRITICAL_TIMEOUT"`
 	BatchSize                  int           `envconfig:"BATCH_SIZE"`
+	UploadConcurrency          int           `envconfig:"UPLOAD_CONCURRENCY"`
+	UploadConcurrencyAdaptive  bool          `envconfig:"UPLOAD_CONCURRENCY_ADAPTIVE"`
+	UploadConcurrencyMin       int           `envconfig:"UPLOAD_CONCURRENCY_MIN"`
+	UploadLatencyTarget        time.Duration `envconfig:"UPLOAD_LATENCY_TARGET"`
+	CircuitBreakerFailures     int           `envconfig:"CIRCUIT_BREAKER_FAILURES"`
+	CircuitBreakerOpenTimeout  time.Duration `envconfig:"CIRCUIT_BREAKER_OPEN_TIMEOUT"`
+	CircuitBreakerMaxWait      time.Duration `envconfig:"CIRCUIT_BREAKER_MAX_WAIT"`
+	ImportTimeout              time.Duration `envconfig:"IMPORT_TIMEOUT"`
+	DownloadTimeout            time.Duration `envconfig:"DOWNLOAD_TIMEOUT"`
+	ValidateTimeout            time.Duration `envconfig:"VALIDATE_TIMEOUT"`
+	FileUploadTimeout          time.Duration `envconfig:"FILE_UPLOAD_TIMEOUT"`
+	PatchTimeout               time.Duration `envconfig:"PATCH_TIMEOUT"`
+	PatchRetries               int           `envconfig:"PATCH_RETRIES"`
+	PatchRetryBackoff          time.Duration `envconfig:"PATCH_RETRY_BACKOFF"`
+	PendingReportsDir          string        `envconfig:"PENDING_REPORTS_DIR"`
+	PendingReportsInterval     time.Duration `envconfig:"PENDING_REPORTS_INTERVAL"`
+	FileUploadRetries          int           `envconfig:"FILE_UPLOAD_RETRIES"`
+	FileUploadRetryBackoff     time.Duration `envconfig:"FILE_UPLOAD_RETRY_BACKOFF"`
+	ImportRetries              int           `envconfig:"IMPORT_RETRIES"`
+	ImportRetryBackoff         time.Duration `envconfig:"IMPORT_RETRY_BACKOFF"`
+	ImportReportMaxBytes       int           `envconfig:"IMPORT_REPORT_MAX_BYTES"`
+	ImportMaxUploadBytes       int64         `envconfig:"IMPORT_MAX_UPLOAD_BYTES"`
+	TempDir                    string        `envconfig:"TEMP_DIR"`
+	TempDirMinFreeBytes        uint64        `envconfig:"TEMP_DIR_MIN_FREE_BYTES"`
+	TempFileMaxAge             time.Duration `envconfig:"TEMP_FILE_MAX_AGE"`
+	TempFileSweepInterval      time.Duration `envconfig:"TEMP_FILE_SWEEP_INTERVAL"`
+	DeduplicationEnabled       bool          `envconfig:"DEDUPLICATION_ENABLED"`
+	ContentIndexDir            string        `envconfig:"CONTENT_INDEX_DIR"`
+	IncrementalImportEnabled   bool          `envconfig:"INCREMENTAL_IMPORT_ENABLED"`
+	ManifestDir                string        `envconfig:"MANIFEST_DIR"`
+	CheckpointsEnabled         bool          `envconfig:"CHECKPOINTS_ENABLED"`
+	CheckpointDir              string        `envconfig:"CHECKPOINT_DIR"`
+	UploadRootsDir             string        `envconfig:"UPLOAD_ROOTS_DIR"`
+	CleanupFailedImports       bool          `envconfig:"CLEANUP_FAILED_IMPORTS"`
+	GCEnabled                  bool          `envconfig:"GC_ENABLED"`
+	GCInterval                 time.Duration `envconfig:"GC_INTERVAL"`
+	GCRetention                time.Duration `envconfig:"GC_RETENTION"`
+	GCDryRun                   bool          `envconfig:"GC_DRY_RUN"`
+	UploadRootStrategy         string        `envconfig:"UPLOAD_ROOT_STRATEGY"`
+	ImportHistorySize          int           `envconfig:"IMPORT_HISTORY_SIZE"`
+	ConsumerMaxLag             int64         `envconfig:"CONSUMER_MAX_LAG"`
+	ConsumerMaxMessageAge      time.Duration `envconfig:"CONSUMER_MAX_MESSAGE_AGE"`
+	MaxImportDuration          time.Duration `envconfig:"MAX_IMPORT_DURATION"`
+	OTelTracesExporter         string        `envconfig:"OTEL_TRACES_EXPORTER"`
+	OTelExporterOTLPEndpoint   string        `envconfig:"OTEL_EXPORTER_OTLP_ENDPOINT"`
+	OTelServiceName            string        `envconfig:"OTEL_SERVICE_NAME"`
 }
 
 var cfg *Config
@@ -41,19 +100,76 @@ func Get() (*Config, error) {
 	cfg = &Config{
 		BindAddr:                   ":27400",
 		UploadAPIURL:               "http://localhost:25100",
+		UploadBackend:              "upload-service",
+		UploadDir:                  filepath.Join(os.TempDir(), "dp-interactives-importer", "uploads"),
+		UploadBucketName:           "",
+		UploadPartSize:             5 * 1024 * 1024,
 		InteractivesAPIURL:         "http://localhost:27500",
 		AwsRegion:                  "eu-west-1",
 		DownloadBucketName:         "dp-interactives-file-uploads",
+		DownloadBackend:            "s3",
+		DownloadDir:                filepath.Join(os.TempDir(), "dp-interactives-importer", "archives"),
 		Brokers:                    []string{"localhost:9092"},
 		KafkaVersion:               "1.0.2",
 		KafkaMaxBytes:              2000000,
 		InteractivesReadTopic:      "interactives-import",
 		KafkaConsumerWorkers:       1,
 		InteractivesGroup:          "dp-interactives-importer",
+		ProgressEnabled:            false,
+		ProgressTopic:              "interactives-import-progress",
+		ProgressInterval:           5 * time.Second,
 		GracefulShutdownTimeout:    5 * time.Second,
+		ShutdownDrainTimeout:       time.Minute,
+		ShutdownInterruptTimeout:   10 * time.Second,
 		HealthCheckInterval:        30 * time.Second,
 		HealthCheckCriticalTimeout: 90 * time.Second,
 		BatchSize:                  5,
+		UploadConcurrency:          10,
+		UploadConcurrencyAdaptive:  false,
+		UploadConcurrencyMin:       1,
+		UploadLatencyTarget:        5 * time.Second,
+		CircuitBreakerFailures:     5,
+		CircuitBreakerOpenTimeout:  30 * time.Second,
+		CircuitBreakerMaxWait:      5 * time.Minute,
+		ImportTimeout:              0,
+		DownloadTimeout:            5 * time.Minute,
+		ValidateTimeout:            0,
+		FileUploadTimeout:          time.Minute,
+		PatchTimeout:               30 * time.Second,
+		PatchRetries:               3,
+		PatchRetryBackoff:          5 * time.Second,
+		PendingReportsDir:          "",
+		PendingReportsInterval:     time.Minute,
+		FileUploadRetries:          2,
+		FileUploadRetryBackoff:     time.Second,
+		ImportRetries:              2,
+		ImportRetryBackoff:         10 * time.Second,
+		ImportReportMaxBytes:       16 * 1024,
+		ImportMaxUploadBytes:       1 << 30,
+		TempDir:                    filepath.Join(os.TempDir(), "dp-interactives-importer", "work"),
+		TempDirMinFreeBytes:        1 << 30,
+		TempFileMaxAge:             24 * time.Hour,
+		TempFileSweepInterval:      time.Hour,
+		DeduplicationEnabled:       false,
+		ContentIndexDir:            "",
+		IncrementalImportEnabled:   false,
+		ManifestDir:                "",
+		CheckpointsEnabled:         false,
+		CheckpointDir:              "",
+		UploadRootsDir:             "",
+		CleanupFailedImports:       false,
+		GCEnabled:                  false,
+		GCInterval:                 time.Hour,
+		GCRetention:                7 * 24 * time.Hour,
+		GCDryRun:                   true,
+		UploadRootStrategy:         "random",
+		ImportHistorySize:          100,
+		ConsumerMaxLag:             100,
+		ConsumerMaxMessageAge:      15 * time.Minute,
+		MaxImportDuration:          30 * time.Minute,
+		OTelTracesExporter:         "none",
+		OTelExporterOTLPEndpoint:   "localhost:4318",
+		OTelServiceName:            "dp-interactives-importer",
 	}
 
 	return cfg, envconfig.Process("", cfg)
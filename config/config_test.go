@@ -24,9 +24,66 @@ func TestConfig(t *testing.T) {
 				So(cfg.KafkaSecProtocol, ShouldEqual, "")
 				So(cfg.KafkaMaxBytes, ShouldEqual, 2000000)
 				So(cfg.InteractivesReadTopic, ShouldEqual, "interactives-import")
+				So(cfg.ProgressEnabled, ShouldBeFalse)
+				So(cfg.ProgressTopic, ShouldEqual, "interactives-import-progress")
+				So(cfg.ProgressInterval, ShouldEqual, 5*time.Second)
 				So(cfg.GracefulShutdownTimeout, ShouldEqual, 5*time.Second)
+				So(cfg.ShutdownDrainTimeout, ShouldEqual, time.Minute)
+				So(cfg.ShutdownInterruptTimeout, ShouldEqual, 10*time.Second)
 				So(cfg.HealthCheckInterval, ShouldEqual, 30*time.Second)
 				So(cfg.HealthCheckCriticalTimeout, ShouldEqual, 90*time.Second)
+				So(cfg.DownloadBackend, ShouldEqual, "s3")
+				So(cfg.DownloadDir, ShouldEndWith, "dp-interactives-importer/archives")
+				So(cfg.UploadBackend, ShouldEqual, "upload-service")
+				So(cfg.UploadDir, ShouldEndWith, "dp-interactives-importer/uploads")
+				So(cfg.UploadBucketName, ShouldEqual, "")
+				So(cfg.UploadPartSize, ShouldEqual, 5*1024*1024)
+				So(cfg.UploadConcurrency, ShouldEqual, 10)
+				So(cfg.UploadConcurrencyAdaptive, ShouldBeFalse)
+				So(cfg.UploadConcurrencyMin, ShouldEqual, 1)
+				So(cfg.UploadLatencyTarget, ShouldEqual, 5*time.Second)
+				So(cfg.CircuitBreakerFailures, ShouldEqual, 5)
+				So(cfg.CircuitBreakerOpenTimeout, ShouldEqual, 30*time.Second)
+				So(cfg.CircuitBreakerMaxWait, ShouldEqual, 5*time.Minute)
+				So(cfg.ImportTimeout, ShouldEqual, 0)
+				So(cfg.DownloadTimeout, ShouldEqual, 5*time.Minute)
+				So(cfg.ValidateTimeout, ShouldEqual, 0)
+				So(cfg.FileUploadTimeout, ShouldEqual, time.Minute)
+				So(cfg.PatchTimeout, ShouldEqual, 30*time.Second)
+				So(cfg.PatchRetries, ShouldEqual, 3)
+				So(cfg.PatchRetryBackoff, ShouldEqual, 5*time.Second)
+				So(cfg.PendingReportsDir, ShouldBeEmpty)
+				So(cfg.PendingReportsInterval, ShouldEqual, time.Minute)
+				So(cfg.FileUploadRetries, ShouldEqual, 2)
+				So(cfg.FileUploadRetryBackoff, ShouldEqual, time.Second)
+				So(cfg.ImportRetries, ShouldEqual, 2)
+				So(cfg.ImportRetryBackoff, ShouldEqual, 10*time.Second)
+				So(cfg.ImportReportMaxBytes, ShouldEqual, 16*1024)
+				So(cfg.ImportMaxUploadBytes, ShouldEqual, 1<<30)
+				So(cfg.TempDir, ShouldEndWith, "dp-interactives-importer/work")
+				So(cfg.TempDirMinFreeBytes, ShouldEqual, 1<<30)
+				So(cfg.TempFileMaxAge, ShouldEqual, 24*time.Hour)
+				So(cfg.TempFileSweepInterval, ShouldEqual, time.Hour)
+				So(cfg.DeduplicationEnabled, ShouldBeFalse)
+				So(cfg.ContentIndexDir, ShouldBeEmpty)
+				So(cfg.IncrementalImportEnabled, ShouldBeFalse)
+				So(cfg.ManifestDir, ShouldBeEmpty)
+				So(cfg.CheckpointsEnabled, ShouldBeFalse)
+				So(cfg.CheckpointDir, ShouldBeEmpty)
+				So(cfg.UploadRootsDir, ShouldBeEmpty)
+				So(cfg.CleanupFailedImports, ShouldBeFalse)
+				So(cfg.GCEnabled, ShouldBeFalse)
+				So(cfg.GCInterval, ShouldEqual, time.Hour)
+				So(cfg.GCRetention, ShouldEqual, 7*24*time.Hour)
+				So(cfg.GCDryRun, ShouldBeTrue)
+				So(cfg.UploadRootStrategy, ShouldEqual, "random")
+				So(cfg.ImportHistorySize, ShouldEqual, 100)
+				So(cfg.ConsumerMaxLag, ShouldEqual, 100)
+				So(cfg.ConsumerMaxMessageAge, ShouldEqual, 15*time.Minute)
+				So(cfg.MaxImportDuration, ShouldEqual, 30*time.Minute)
+				So(cfg.OTelTracesExporter, ShouldEqual, "none")
+				So(cfg.OTelExporterOTLPEndpoint, ShouldEqual, "localhost:4318")
+				So(cfg.OTelServiceName, ShouldEqual, "dp-interactives-importer")
 			})
 
 			Convey("Then a second call to config should return the same config", func() {
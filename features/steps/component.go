@@ -134,6 +134,7 @@ func NewInteractivesImporterComponent() (*Component, error) {
 		DoGetHealthCheckFunc:           DoGetHealthcheckOk,
 		DoGetHealthClientFunc:          DoGetHealthClient,
 		DoGetKafkaConsumerFunc:         DoGetConsumer(c),
+		DoGetKafkaLagReaderFunc:        DoGetLagReader,
 		DoGetS3ClientFunc:              DoGetS3Client(c),
 		DoGetUploadServiceBackendFunc:  DoGetUploadServiceBackend(c),
 		DoGetInteractivesAPIClientFunc: DoGetInteractivesAPIClient(c),
@@ -158,6 +159,14 @@ func DoGetConsumer(c *Component) func(context.Context, *config.Config) (kafka.IC
 	}
 }
 
+func DoGetLagReader(_ context.Context, _ *config.Config) (importer.LagReader, error) {
+	return &mocks_importer.LagReaderMock{
+		LagFunc: func(context.Context) ([]importer.PartitionLag, error) {
+			return nil, nil
+		},
+	}, nil
+}
+
 func DoGetHealthcheckOk(cfg *config.Config, buildTime, gitCommit, version string) (service.HealthChecker, error) {
 	return &mocks_service.HealthCheckerMock{
 		AddCheckFunc: func(name string, checker healthcheck.Checker) error { return nil },
@@ -3,6 +3,8 @@ package importer
 import (
 	"archive/zip"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"github.com/h2non/filetype"
 	"github.com/pkg/errors"
@@ -27,19 +29,56 @@ var (
 
 type matcher func(string, string) bool
 
+// ErrUnknownType is the error of a file whose type cannot be told from its extension or content
+var ErrUnknownType = errors.New("type unknown")
+
 type File struct {
 	Context     context.Context
 	ReadCloser  io.ReadCloser
 	Name        string
 	MimeType    string
 	SizeInBytes int64
+	Hash        string
 	Closed      bool
 }
 
+// FileError is the failure of a single file in an archive
+type FileError struct {
+	File string
+	Code string
+	Err  error
+}
+
+func (e *FileError) Error() string {
+	if e.Code == CodeInvalidFile {
+		return fmt.Sprintf("cannot open zip file: %s %v", e.File, e.Err)
+	}
+	return fmt.Sprintf("cannot process zip file: %s %v", e.File, e.Err)
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// ArchiveErrors are the file errors found by a pass over an archive
+type ArchiveErrors []*FileError
+
+func (e ArchiveErrors) Error() string {
+	return fmt.Sprintf("found %d validation errors: %v", len(e), []*FileError(e))
+}
+
+func (e ArchiveErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, fileErr := range e {
+		errs[i] = fileErr
+	}
+	return errs
+}
+
 type batch struct {
 	mu             sync.Mutex
 	count          uint64
-	validationErrs []error
+	validationErrs ArchiveErrors
 }
 
 func (b *batch) inc() uint64 {
@@ -50,32 +89,42 @@ func (b *batch) inc() uint64 {
 	return b.count
 }
 
-func (b *batch) err(err error) {
+func (b *batch) err(err *FileError) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
 	b.validationErrs = append(b.validationErrs, err)
 }
 
-func Process(batchSize int, z string, processor func(count uint64, mimetype string, zip *zip.File) error) error {
+func Process(ctx context.Context, batchSize int, z string, processor func(count uint64, mimetype string, zip *zip.File) error) error {
 	zipReader, err := zip.OpenReader(z)
 	if err != nil {
-		return err
+		return archiveError(err)
 	}
+	defer zipReader.Close()
 
 	var wg sync.WaitGroup
 	b := batch{}
 	ch := make(chan struct{}, batchSize)
 
 	for _, f := range zipReader.File {
+		// stop starting files once the context is done, those already started are left to finish
+		select {
+		case ch <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
 		file := f
 		wg.Add(1)
-		ch <- struct{}{}
 		go func() {
 			defer wg.Done()
 			skip, mimetype, err := ValidateZipFile(file)
 			if err != nil {
-				b.err(fmt.Errorf("cannot open zip file: %s %w", file.Name, err))
+				filesProcessedTotal.WithLabelValues("invalid").Inc()
+				b.err(&FileError{File: file.Name, Code: CodeInvalidFile, Err: err})
 			}
 
 			if !skip {
@@ -83,7 +132,10 @@ func Process(batchSize int, z string, processor func(count uint64, mimetype stri
 				err = processor(currentCount, mimetype, file)
 				if err != nil {
 					//should we hit the kill switch here...
-					b.err(fmt.Errorf("cannot process zip file: %s %w", file.Name, err))
+					filesProcessedTotal.WithLabelValues("error").Inc()
+					b.err(&FileError{File: file.Name, Code: CodeProcessingFailed, Err: err})
+				} else {
+					filesProcessedTotal.WithLabelValues("ok").Inc()
 				}
 			}
 
@@ -93,17 +145,21 @@ func Process(batchSize int, z string, processor func(count uint64, mimetype stri
 	wg.Wait()
 
 	if len(b.validationErrs) > 0 {
-		return fmt.Errorf("found %d validation errors: %v", len(b.validationErrs), b.validationErrs)
+		return b.validationErrs
 	}
 
-	return nil
+	return ctx.Err()
 }
 
 func ValidateZipFile(file *zip.File) (skip bool, mimetype string, err error) {
 	if IsRegular(file) {
 		mimetype, err = MimeType(file)
+		if errors.Is(err, ErrUnknownType) {
+			err = categorise(CategoryPolicyViolation, fmt.Errorf("cannot determine mime type: %s %w", file.Name, err))
+			return
+		}
 		if err != nil {
-			err = fmt.Errorf("cannot determine mime type: %s %w", file.Name, err)
+			err = archiveError(fmt.Errorf("cannot determine mime type: %s %w", file.Name, err))
 			return
 		}
 	} else {
@@ -112,6 +168,23 @@ func ValidateZipFile(file *zip.File) (skip bool, mimetype string, err error) {
 	return
 }
 
+// Ignored lists the regular files of an archive that are left out of an import, such as hidden files
+func Ignored(z string) ([]string, error) {
+	zipReader, err := zip.OpenReader(z)
+	if err != nil {
+		return nil, err
+	}
+	defer zipReader.Close()
+
+	var ignored []string
+	for _, f := range zipReader.File {
+		if f.Mode().IsRegular() && !IsRegular(f) {
+			ignored = append(ignored, f.Name)
+		}
+	}
+	return ignored, nil
+}
+
 func IsRegular(f *zip.File) bool {
 	ignore := !f.Mode().IsRegular()
 	for _, m := range fileMatchersToIgnore {
@@ -138,10 +211,26 @@ func MimeType(f *zip.File) (string, error) {
 	if mimetype == "" {
 		kind, _ := filetype.MatchReader(rc)
 		if kind == filetype.Unknown {
-			return "", errors.New("type unknown")
+			return "", ErrUnknownType
 		}
 		mimetype = kind.MIME.Value
 	}
 
 	return mimetype, rc.Close()
 }
+
+// Hash returns the hex encoded SHA-256 of the uncompressed content of a zip entry
+func Hash(f *zip.File) (string, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+
+	h := sha256.New()
+	if _, err = io.Copy(h, rc); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
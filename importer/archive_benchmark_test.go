@@ -1,6 +1,7 @@
 package importer_test
 
 import (
+	"context"
 	"flag"
 	"testing"
 
@@ -16,7 +17,7 @@ func TestLargeArchive(t *testing.T) {
 	if *becnhmarkFlag {
 		Convey("Given a large zip file", t, func() {
 			Convey("Then open should run successfully", func() {
-				err := importer.Process(batchSize, "/Users/markryan/Postman/files/largetest.zip", importer.EmptyProcessor)
+				err := importer.Process(context.TODO(), batchSize, "/Users/markryan/Postman/files/largetest.zip", importer.EmptyProcessor)
 				So(err, ShouldBeNil)
 			})
 		})
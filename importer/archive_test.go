@@ -3,6 +3,7 @@ package importer_test
 import (
 	"archive/zip"
 	"bytes"
+	"context"
 	"embed"
 	"io"
 	"os"
@@ -37,7 +38,7 @@ func TestArchive(t *testing.T) {
 		So(err, ShouldBeNil)
 
 		Convey("Then there should an error returned when attempt to open", func() {
-			err = importer.Process(batchSize, archive.Name(), importer.EmptyProcessor)
+			err = importer.Process(context.TODO(), batchSize, archive.Name(), importer.EmptyProcessor)
 			So(err, ShouldBeError, zip.ErrFormat)
 		})
 	})
@@ -56,18 +57,33 @@ func TestArchive(t *testing.T) {
 				return nil
 			}
 
-			err = importer.Process(batchSize, archiveName, counter)
+			err = importer.Process(context.TODO(), batchSize, archiveName, counter)
 			So(err, ShouldBeNil)
 
 			Convey("And files in archive should be 4", func() {
 				So(count, ShouldEqual, 4)
 			})
 		})
+
+		Convey("Then no files should be processed once the context is done", func() {
+			ctx, cancel := context.WithCancel(context.TODO())
+			cancel()
+
+			var count uint64
+			counter := func(uint64, string, *zip.File) error {
+				atomic.AddUint64(&count, 1)
+				return nil
+			}
+
+			err = importer.Process(ctx, batchSize, archiveName, counter)
+			So(err, ShouldEqual, context.Canceled)
+			So(count, ShouldEqual, 0)
+		})
 	})
 
 	Convey("Given an actual valid zip file", t, func() {
 		Convey("Then open should run successfully", func() {
-			err := importer.Process(batchSize, "test/single-interactive.zip", importer.EmptyProcessor)
+			err := importer.Process(context.TODO(), batchSize, "test/single-interactive.zip", importer.EmptyProcessor)
 			So(err, ShouldBeNil)
 		})
 	})
@@ -161,3 +177,45 @@ func TestIsRegular(t *testing.T) {
 		So(b, ShouldBeFalse)
 	})
 }
+
+func TestIgnored(t *testing.T) {
+
+	Convey("Given a zip file with hidden and system files", t, func() {
+		archiveName, err := test.CreateTestZip("index.html", ".DS_Store", "__MACOSX/._index.html", "Thumbs.db")
+		defer os.Remove(archiveName)
+		So(err, ShouldBeNil)
+
+		Convey("Then only those files should be ignored", func() {
+			ignored, err := importer.Ignored(archiveName)
+			So(err, ShouldBeNil)
+			So(ignored, ShouldResemble, []string{".DS_Store", "__MACOSX/._index.html", "Thumbs.db"})
+		})
+	})
+}
+
+func TestHash(t *testing.T) {
+
+	Convey("Given a zip file with duplicated content", t, func() {
+		archiveName, err := test.CreateTestZip("a.css", "b.css")
+		defer os.Remove(archiveName)
+		So(err, ShouldBeNil)
+
+		zipReader, err := zip.OpenReader(archiveName)
+		So(err, ShouldBeNil)
+		defer zipReader.Close()
+
+		Convey("Then each file should hash to the SHA-256 of its content", func() {
+			h, err := importer.Hash(zipReader.File[0])
+			So(err, ShouldBeNil)
+			So(h, ShouldEqual, "96a7b1d9364fa20d6ca47fec3aa6011bd3bccef70d11f768de8bf94866bc843c")
+		})
+
+		Convey("Then files with different content should not share a hash", func() {
+			a, err := importer.Hash(zipReader.File[0])
+			So(err, ShouldBeNil)
+			b, err := importer.Hash(zipReader.File[1])
+			So(err, ShouldBeNil)
+			So(a, ShouldNotEqual, b)
+		})
+	})
+}
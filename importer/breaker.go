@@ -0,0 +1,326 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ONSdigital/dp-api-clients-go/v2/interactives"
+	"github.com/ONSdigital/dp-api-clients-go/v2/upload"
+	"github.com/ONSdigital/dp-healthcheck/healthcheck"
+)
+
+// BreakerState is the state of a circuit breaker
+type BreakerState int
+
+const (
+	BreakerClosed   BreakerState = iota // requests go through
+	BreakerHalfOpen                     // one request at a time tests whether the service has recovered
+	BreakerOpen                         // requests fail straight away
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "open"
+}
+
+// ErrCircuitOpen is returned instead of calling a service whose circuit breaker is open
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// CircuitBreaker stops calls to a service after a run of consecutive failures. Once open it waits for the open
+// timeout, then lets a single call through: the circuit closes if it succeeds and opens again if it fails.
+type CircuitBreaker struct {
+	Name string
+
+	mu          sync.Mutex
+	threshold   int
+	openTimeout time.Duration
+	state       BreakerState
+	failures    int
+	openedAt    time.Time
+	trial       bool          // a call is testing the service while half-open
+	changed     chan struct{} // closed when the state changes
+}
+
+// NewCircuitBreaker returns a closed breaker that opens after threshold consecutive failures
+func NewCircuitBreaker(name string, threshold int, openTimeout time.Duration) *CircuitBreaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	circuitBreakerState.WithLabelValues(name).Set(float64(BreakerClosed))
+	return &CircuitBreaker{Name: name, threshold: threshold, openTimeout: openTimeout, changed: make(chan struct{})}
+}
+
+// Allow returns ErrCircuitOpen if the call must not be made, otherwise done must be called with whether it failed
+func (b *CircuitBreaker) Allow() (done func(failed bool), err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refresh(time.Now())
+	return b.allow()
+}
+
+// AllowWait is Allow, except that while half-open it waits for the outcome of the call testing the service
+// rather than fail because another caller got to make it. It still fails straight away while open.
+func (b *CircuitBreaker) AllowWait(ctx context.Context) (done func(failed bool), err error) {
+	for {
+		b.mu.Lock()
+		b.refresh(time.Now())
+		if b.state != BreakerHalfOpen || !b.trial {
+			done, err = b.allow()
+			b.mu.Unlock()
+			return done, err
+		}
+		changed := b.changed
+		b.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (b *CircuitBreaker) allow() (done func(failed bool), err error) {
+	switch b.state {
+	case BreakerOpen:
+		return nil, ErrCircuitOpen
+	case BreakerHalfOpen:
+		if b.trial {
+			return nil, ErrCircuitOpen
+		}
+		b.trial = true
+		return b.trialDone, nil
+	}
+	return b.done, nil
+}
+
+func (b *CircuitBreaker) done(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// calls made before the circuit opened say nothing about whether the service has recovered since
+	if b.state != BreakerClosed {
+		return
+	}
+	if !failed {
+		b.failures = 0
+		return
+	}
+	if b.failures++; b.failures >= b.threshold {
+		b.open(time.Now())
+	}
+}
+
+func (b *CircuitBreaker) trialDone(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trial = false
+	if failed {
+		b.open(time.Now())
+		return
+	}
+	b.failures = 0
+	b.setState(BreakerClosed)
+}
+
+// State returns the current state of the breaker
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refresh(time.Now())
+	return b.state
+}
+
+// Ready is true if a call would be let through now
+func (b *CircuitBreaker) Ready() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refresh(time.Now())
+	return b.ready()
+}
+
+// Wait blocks until a call would be let through, or the context is done. Every waiter returns once the breaker is
+// half-open, so calls made after waiting use AllowWait to queue behind the one call that tests the service.
+func (b *CircuitBreaker) Wait(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		now := time.Now()
+		b.refresh(now)
+		if b.ready() {
+			b.mu.Unlock()
+			return nil
+		}
+		changed := b.changed
+		wait := b.openTimeout - now.Sub(b.openedAt)
+		if b.state == BreakerHalfOpen || wait <= 0 {
+			wait = b.openTimeout
+		}
+		b.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-changed:
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+		timer.Stop()
+	}
+}
+
+func (b *CircuitBreaker) ready() bool {
+	return b.state == BreakerClosed || (b.state == BreakerHalfOpen && !b.trial)
+}
+
+// refresh moves an open breaker to half-open once its open timeout has passed
+func (b *CircuitBreaker) refresh(now time.Time) {
+	if b.state == BreakerOpen && now.Sub(b.openedAt) >= b.openTimeout {
+		b.setState(BreakerHalfOpen)
+	}
+}
+
+func (b *CircuitBreaker) open(now time.Time) {
+	b.openedAt = now
+	b.setState(BreakerOpen)
+}
+
+func (b *CircuitBreaker) setState(state BreakerState) {
+	if b.state == state {
+		return
+	}
+	b.state = state
+	circuitBreakerState.WithLabelValues(b.Name).Set(float64(state))
+	close(b.changed)
+	b.changed = make(chan struct{})
+}
+
+// check downgrades a passing health check of the service to a warning while the breaker is not closed
+func (b *CircuitBreaker) check(state *healthcheck.CheckState) error {
+	b.mu.Lock()
+	b.refresh(time.Now())
+	breakerState, openedAt := b.state, b.openedAt
+	b.mu.Unlock()
+
+	if breakerState == BreakerClosed || state.Status() != healthcheck.StatusOK {
+		return nil
+	}
+	msg := fmt.Sprintf("circuit breaker %s since %s", breakerState, openedAt.UTC().Format(time.RFC3339))
+	return state.Update(healthcheck.StatusWarning, msg, 0)
+}
+
+// serviceFailure is true for failures that say a service is unhealthy, rather than that a request was bad
+func serviceFailure(err error) bool {
+	switch {
+	case err == nil, errors.Is(err, context.Canceled):
+		return false
+	case errors.Is(err, context.DeadlineExceeded):
+		return true
+	}
+	// a failure without a status never got a response the client could make sense of
+	code := statusCode(err)
+	return code == 0 || code >= http.StatusInternalServerError || code == http.StatusTooManyRequests
+}
+
+// BreakerUploadServiceBackend calls an upload backend through a circuit breaker
+type BreakerUploadServiceBackend struct {
+	UploadServiceBackend
+	Breaker *CircuitBreaker
+}
+
+func (u *BreakerUploadServiceBackend) Upload(ctx context.Context, fileContent io.ReadCloser, metadata upload.Metadata) error {
+	done, err := u.Breaker.AllowWait(ctx)
+	if err != nil {
+		return &ImportError{Category: CategoryStorageUnavailable, Err: err}
+	}
+	err = u.UploadServiceBackend.Upload(ctx, fileContent, metadata)
+	done(!errors.Is(err, upload.ErrNotAuthorized) && serviceFailure(err))
+	return err
+}
+
+func (u *BreakerUploadServiceBackend) Copy(ctx context.Context, sourcePath string, metadata upload.Metadata) error {
+	copier, ok := u.UploadServiceBackend.(UploadCopier)
+	if !ok {
+		return ErrCopyNotSupported
+	}
+	done, err := u.Breaker.AllowWait(ctx)
+	if err != nil {
+		return &ImportError{Category: CategoryStorageUnavailable, Err: err}
+	}
+	err = copier.Copy(ctx, sourcePath, metadata)
+	done(!errors.Is(err, upload.ErrNotAuthorized) && serviceFailure(err))
+	return err
+}
+
+func (u *BreakerUploadServiceBackend) DeleteRoot(ctx context.Context, uploadRootPath string) error {
+	deleter, ok := u.UploadServiceBackend.(UploadRootDeleter)
+	if !ok {
+		return ErrDeleteNotSupported
+	}
+	return deleter.DeleteRoot(ctx, uploadRootPath)
+}
+
+func (u *BreakerUploadServiceBackend) Unwrap() UploadServiceBackend {
+	return u.UploadServiceBackend
+}
+
+func (u *BreakerUploadServiceBackend) Checker(ctx context.Context, state *healthcheck.CheckState) error {
+	if err := u.UploadServiceBackend.Checker(ctx, state); err != nil {
+		return err
+	}
+	return u.Breaker.check(state)
+}
+
+// BreakerInteractivesAPIClient calls the interactives api through a circuit breaker
+type BreakerInteractivesAPIClient struct {
+	InteractivesAPIClient
+	Breaker *CircuitBreaker
+}
+
+func (c *BreakerInteractivesAPIClient) GetInteractive(ctx context.Context, userAuthToken, serviceAuthToken, interactiveID string) (interactives.Interactive, error) {
+	done, err := c.Breaker.AllowWait(ctx)
+	if err != nil {
+		return interactives.Interactive{}, err
+	}
+	interactive, err := c.InteractivesAPIClient.GetInteractive(ctx, userAuthToken, serviceAuthToken, interactiveID)
+	done(serviceFailure(err))
+	return interactive, err
+}
+
+// PatchInteractive waits for an open breaker rather than fail, as it reports the outcome of an import
+func (c *BreakerInteractivesAPIClient) PatchInteractive(ctx context.Context, userAuthToken, serviceAuthToken, interactiveID string, req interactives.PatchRequest) (interactives.Interactive, error) {
+	done, err := c.Breaker.AllowWait(ctx)
+	for errors.Is(err, ErrCircuitOpen) {
+		if err = c.Breaker.Wait(ctx); err != nil {
+			return interactives.Interactive{}, fmt.Errorf("%w: %w", ErrCircuitOpen, err)
+		}
+		done, err = c.Breaker.AllowWait(ctx)
+	}
+	if err != nil {
+		return interactives.Interactive{}, err
+	}
+	interactive, err := c.InteractivesAPIClient.PatchInteractive(ctx, userAuthToken, serviceAuthToken, interactiveID, req)
+	done(serviceFailure(err))
+	return interactive, err
+}
+
+func (c *BreakerInteractivesAPIClient) Checker(ctx context.Context, state *healthcheck.CheckState) error {
+	if err := c.InteractivesAPIClient.Checker(ctx, state); err != nil {
+		return err
+	}
+	return c.Breaker.check(state)
+}
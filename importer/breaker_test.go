@@ -0,0 +1,303 @@
+package importer_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	dperrors "github.com/ONSdigital/dp-api-clients-go/v2/errors"
+	"github.com/ONSdigital/dp-api-clients-go/v2/interactives"
+	"github.com/ONSdigital/dp-api-clients-go/v2/upload"
+	"github.com/ONSdigital/dp-healthcheck/healthcheck"
+	"github.com/ONSdigital/dp-interactives-importer/config"
+	"github.com/ONSdigital/dp-interactives-importer/importer"
+	mocks_importer "github.com/ONSdigital/dp-interactives-importer/importer/mocks"
+	"github.com/ONSdigital/dp-interactives-importer/internal/test"
+	"github.com/ONSdigital/log.go/v2/log"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+
+	Convey("Given a breaker that opens after two failures", t, func() {
+		breaker := importer.NewCircuitBreaker("test", 2, 20*time.Millisecond)
+		call := func(failed bool) error {
+			done, err := breaker.Allow()
+			if err == nil {
+				done(failed)
+			}
+			return err
+		}
+
+		Convey("Then a failure followed by a success should leave it closed", func() {
+			So(call(true), ShouldBeNil)
+			So(call(false), ShouldBeNil)
+			So(call(true), ShouldBeNil)
+			So(breaker.State(), ShouldEqual, importer.BreakerClosed)
+		})
+
+		Convey("When two calls fail in a row", func() {
+			So(call(true), ShouldBeNil)
+			So(call(true), ShouldBeNil)
+
+			Convey("Then it should open and refuse calls", func() {
+				So(breaker.State(), ShouldEqual, importer.BreakerOpen)
+				So(breaker.Ready(), ShouldBeFalse)
+				So(call(false), ShouldEqual, importer.ErrCircuitOpen)
+			})
+
+			Convey("Then after the open timeout only one call at a time should test the service", func() {
+				time.Sleep(25 * time.Millisecond)
+				So(breaker.State(), ShouldEqual, importer.BreakerHalfOpen)
+				done, err := breaker.Allow()
+				So(err, ShouldBeNil)
+				_, err = breaker.Allow()
+				So(err, ShouldEqual, importer.ErrCircuitOpen)
+
+				Convey("And it should close if that call succeeds", func() {
+					done(false)
+					So(breaker.State(), ShouldEqual, importer.BreakerClosed)
+				})
+
+				Convey("And it should open again if that call fails", func() {
+					done(true)
+					So(breaker.State(), ShouldEqual, importer.BreakerOpen)
+				})
+			})
+
+			Convey("Then a call waiting while another tests the service should wait for the outcome", func() {
+				time.Sleep(25 * time.Millisecond)
+				trialDone, err := breaker.Allow()
+				So(err, ShouldBeNil)
+				allowed := make(chan error, 1)
+				go func() {
+					done, err := breaker.AllowWait(context.Background())
+					if err == nil {
+						done(false)
+					}
+					allowed <- err
+				}()
+				time.Sleep(5 * time.Millisecond)
+				So(allowed, ShouldBeEmpty)
+
+				Convey("And go through if the test succeeds", func() {
+					trialDone(false)
+					So(<-allowed, ShouldBeNil)
+				})
+
+				Convey("And fail if the test fails", func() {
+					trialDone(true)
+					So(<-allowed, ShouldEqual, importer.ErrCircuitOpen)
+				})
+			})
+
+			Convey("Then waiting should return once the breaker lets a call through", func() {
+				So(breaker.Wait(context.Background()), ShouldBeNil)
+				So(breaker.State(), ShouldEqual, importer.BreakerHalfOpen)
+			})
+
+			Convey("Then waiting should stop when the context is done", func() {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				So(breaker.Wait(ctx), ShouldEqual, context.Canceled)
+			})
+		})
+	})
+}
+
+func TestBreakerClients(t *testing.T) {
+
+	Convey("Given an upload backend behind a breaker that opens after one failure", t, func() {
+		var uploadErr error
+		backend := &mocks_importer.UploadServiceBackendMock{
+			UploadFunc: func(context.Context, io.ReadCloser, upload.Metadata) error {
+				return uploadErr
+			},
+			CheckerFunc: func(_ context.Context, state *healthcheck.CheckState) error {
+				return state.Update(healthcheck.StatusOK, "ok", 200)
+			},
+		}
+		breaker := importer.NewCircuitBreaker("upload", 1, time.Hour)
+		wrapped := &importer.BreakerUploadServiceBackend{UploadServiceBackend: backend, Breaker: breaker}
+		send := func() error {
+			return wrapped.Upload(context.Background(), io.NopCloser(nil), upload.Metadata{})
+		}
+
+		Convey("Then requests the upload service rejects should not open it", func() {
+			uploadErr = upload.ErrNotAuthorized
+			So(send(), ShouldEqual, upload.ErrNotAuthorized)
+			uploadErr = &importer.StatusError{StatusCode: 409, Err: dperrors.NewErrorFromUnhandledStatusCode("upload-service", 409)}
+			So(send(), ShouldNotBeNil)
+			uploadErr = context.Canceled
+			So(send(), ShouldNotBeNil)
+			So(breaker.State(), ShouldEqual, importer.BreakerClosed)
+		})
+
+		Convey("When the upload service is unavailable", func() {
+			uploadErr = &importer.StatusError{StatusCode: 503, Err: dperrors.NewErrorFromUnhandledStatusCode("upload-service", 503)}
+			So(send(), ShouldNotBeNil)
+
+			Convey("Then later uploads should fail as retryable without calling it", func() {
+				err := send()
+				So(errors.Is(err, importer.ErrCircuitOpen), ShouldBeTrue)
+				So(importer.CategoryOf(err), ShouldEqual, importer.CategoryStorageUnavailable)
+				So(backend.UploadCalls(), ShouldHaveLength, 1)
+			})
+
+			Convey("Then its health check should warn", func() {
+				state := healthcheck.NewCheckState("Upload API")
+				So(wrapped.Checker(context.Background(), state), ShouldBeNil)
+				So(state.Status(), ShouldEqual, healthcheck.StatusWarning)
+				So(state.Message(), ShouldContainSubstring, "circuit breaker open")
+			})
+
+			Convey("Then deleting an upload root should say the backend cannot", func() {
+				So(wrapped.DeleteRoot(context.Background(), "root"), ShouldEqual, importer.ErrDeleteNotSupported)
+			})
+		})
+	})
+
+	Convey("Given an interactives api client behind a breaker that opens after one failure", t, func() {
+		var apiErr error
+		client := &mocks_importer.InteractivesAPIClientMock{
+			PatchInteractiveFunc: func(context.Context, string, string, string, interactives.PatchRequest) (interactives.Interactive, error) {
+				return interactives.Interactive{}, apiErr
+			},
+		}
+		breaker := importer.NewCircuitBreaker("interactives", 1, time.Hour)
+		wrapped := &importer.BreakerInteractivesAPIClient{InteractivesAPIClient: client, Breaker: breaker}
+		patch := func() error {
+			_, err := wrapped.PatchInteractive(context.Background(), "", "", "1", interactives.PatchRequest{})
+			return err
+		}
+
+		Convey("Then a request the api rejects should not open it", func() {
+			apiErr = &importer.StatusError{StatusCode: http.StatusBadRequest, Err: interactives.NewInteractivesAPIResponse(&http.Response{StatusCode: http.StatusBadRequest}, "/v1/interactives/1")}
+			So(patch(), ShouldNotBeNil)
+			So(breaker.State(), ShouldEqual, importer.BreakerClosed)
+		})
+
+		Convey("When the api is unavailable", func() {
+			apiErr = &importer.StatusError{StatusCode: 502, Err: dperrors.NewErrorFromUnhandledStatusCode("interactives-api", 502)}
+			So(patch(), ShouldNotBeNil)
+
+			Convey("Then it should open, and an update should wait for it until the context is done", func() {
+				So(breaker.State(), ShouldEqual, importer.BreakerOpen)
+				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+				defer cancel()
+				_, err := wrapped.PatchInteractive(ctx, "", "", "1", interactives.PatchRequest{})
+				So(errors.Is(err, importer.ErrCircuitOpen), ShouldBeTrue)
+				So(errors.Is(err, context.DeadlineExceeded), ShouldBeTrue)
+				So(client.PatchInteractiveCalls(), ShouldHaveLength, 1)
+			})
+		})
+	})
+
+	Convey("Given an interactives api client behind a breaker that has opened", t, func() {
+		client := &mocks_importer.InteractivesAPIClientMock{
+			PatchInteractiveFunc: func(context.Context, string, string, string, interactives.PatchRequest) (interactives.Interactive, error) {
+				return interactives.Interactive{}, nil
+			},
+		}
+		breaker := importer.NewCircuitBreaker("interactives", 1, 20*time.Millisecond)
+		done, err := breaker.Allow()
+		So(err, ShouldBeNil)
+		done(true)
+		wrapped := &importer.BreakerInteractivesAPIClient{InteractivesAPIClient: client, Breaker: breaker}
+
+		Convey("When several updates are made before it lets a call through", func() {
+			errs := make(chan error, 3)
+			for i := 0; i < 3; i++ {
+				go func() {
+					_, err := wrapped.PatchInteractive(context.Background(), "", "", "1", interactives.PatchRequest{})
+					errs <- err
+				}()
+			}
+
+			Convey("Then each should wait its turn and succeed", func() {
+				for i := 0; i < 3; i++ {
+					So(<-errs, ShouldBeNil)
+				}
+				So(client.PatchInteractiveCalls(), ShouldHaveLength, 3)
+				So(breaker.State(), ShouldEqual, importer.BreakerClosed)
+			})
+		})
+	})
+}
+
+func TestHandlerWaitsForBreakers(t *testing.T) {
+
+	Convey("Given a handler whose upload breaker is open", t, func() {
+		archive, err := test.CreateTestZip("index.html")
+		So(err, ShouldBeNil)
+		defer os.Remove(archive)
+
+		breaker := importer.NewCircuitBreaker("upload", 1, 50*time.Millisecond)
+		done, err := breaker.Allow()
+		So(err, ShouldBeNil)
+		done(true)
+
+		backend := &mocks_importer.UploadServiceBackendMock{
+			UploadFunc: func(context.Context, io.ReadCloser, upload.Metadata) error { return nil },
+		}
+		var patched []interactives.PatchRequest
+		handler := &importer.InteractivesUploadedHandler{
+			Cfg:           &config.Config{BatchSize: 1, TempDir: t.TempDir()},
+			UploadService: importer.NewUploadService(&importer.BreakerUploadServiceBackend{UploadServiceBackend: backend, Breaker: breaker}),
+			Breakers:      []*importer.CircuitBreaker{breaker},
+			InteractivesAPIClient: &mocks_importer.InteractivesAPIClientMock{
+				PatchInteractiveFunc: func(_ context.Context, _, _, _ string, req interactives.PatchRequest) (interactives.Interactive, error) {
+					patched = append(patched, req)
+					return interactives.Interactive{}, nil
+				},
+			},
+		}
+		event := &importer.InteractivesUploaded{ID: "1", Path: "archive.zip"}
+
+		Convey("When an import starts", func() {
+			start := time.Now()
+			err := handler.Import(context.Background(), handler.NewJob(context.Background(), event), event, archive, log.Data{})
+
+			Convey("Then it should wait for the breaker rather than fail, then import the archive", func() {
+				So(err, ShouldBeNil)
+				So(time.Since(start), ShouldBeGreaterThanOrEqualTo, 40*time.Millisecond)
+				So(backend.UploadCalls(), ShouldHaveLength, 1)
+				So(patched[0].Interactive.Archive.ImportSuccessful, ShouldBeTrue)
+				So(breaker.State(), ShouldEqual, importer.BreakerClosed)
+			})
+		})
+
+		Convey("When the breaker stays open for longer than the maximum wait", func() {
+			handler.Cfg.CircuitBreakerMaxWait = 10 * time.Millisecond
+			err := handler.Import(context.Background(), handler.NewJob(context.Background(), event), event, archive, log.Data{})
+
+			Convey("Then the import should fail as storage unavailable, so it is retried", func() {
+				So(errors.Is(err, importer.ErrCircuitOpen), ShouldBeTrue)
+				So(importer.CategoryOf(err), ShouldEqual, importer.CategoryStorageUnavailable)
+				So(importer.Retryable(err), ShouldBeTrue)
+				So(backend.UploadCalls(), ShouldBeEmpty)
+				So(patched[0].Interactive.Archive.ImportSuccessful, ShouldBeFalse)
+			})
+		})
+
+		Convey("When shutdown interrupts an import while it waits", func() {
+			imported := make(chan error)
+			go func() {
+				imported <- handler.Import(context.Background(), handler.NewJob(context.Background(), event), event, archive, log.Data{})
+			}()
+			time.Sleep(10 * time.Millisecond)
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			So(handler.Shutdown(ctx), ShouldEqual, context.Canceled)
+
+			Convey("Then it should be redelivered without having uploaded anything", func() {
+				So(<-imported, ShouldEqual, importer.ErrInterrupted)
+				So(backend.UploadCalls(), ShouldBeEmpty)
+			})
+		})
+	})
+}
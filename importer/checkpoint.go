@@ -0,0 +1,210 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// Checkpoint records the files uploaded so far by an import of an archive, so a retried or redelivered import
+// of the same archive carries on from where it stopped
+type Checkpoint struct {
+	InteractiveID   string    `json:"interactive_id"`
+	ArchiveChecksum string    `json:"archive_checksum"`
+	UploadRootPath  string    `json:"upload_root_path"`
+	CreatedAt       time.Time `json:"created_at"`
+
+	Files map[string]CheckpointEntry `json:"-"`
+}
+
+type CheckpointEntry struct {
+	Name string `json:"name"`
+	Hash string `json:"hash"`
+	Path string `json:"path"`
+}
+
+// CheckpointStore keeps the checkpoint of each unfinished import, keyed by interactive and archive checksum
+type CheckpointStore interface {
+	// Get returns nil when there is no checkpoint for the archive
+	Get(ctx context.Context, interactiveID, archiveChecksum string) (*Checkpoint, error)
+	// Create starts an empty checkpoint, replacing any left by an import of another archive of the interactive
+	Create(ctx context.Context, cp *Checkpoint) error
+	// Add records a file as uploaded, it is safe to call concurrently
+	Add(ctx context.Context, cp *Checkpoint, entry CheckpointEntry) error
+	Remove(ctx context.Context, interactiveID, archiveChecksum string) error
+}
+
+func NewCheckpoint(interactiveID, archiveChecksum, uploadRootPath string) *Checkpoint {
+	return &Checkpoint{
+		InteractiveID:   interactiveID,
+		ArchiveChecksum: archiveChecksum,
+		UploadRootPath:  uploadRootPath,
+		CreatedAt:       time.Now().UTC(),
+		Files:           make(map[string]CheckpointEntry),
+	}
+}
+
+// Uploaded returns the entry of a file uploaded before the checkpoint was loaded
+func (cp *Checkpoint) Uploaded(name string) (CheckpointEntry, bool) {
+	if cp == nil {
+		return CheckpointEntry{}, false
+	}
+	e, ok := cp.Files[name]
+	return e, ok
+}
+
+// FileCheckpointStore keeps each checkpoint as a JSON lines file in a directory per interactive: the checkpoint
+// itself, then one line per uploaded file, so recording a file is a single append
+type FileCheckpointStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+func NewFileCheckpointStore(dir string) (*FileCheckpointStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileCheckpointStore{dir: dir}, nil
+}
+
+func (s *FileCheckpointStore) Get(_ context.Context, interactiveID, archiveChecksum string) (*Checkpoint, error) {
+	b, err := os.ReadFile(s.path(interactiveID, archiveChecksum))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	if !scanner.Scan() {
+		return nil, nil
+	}
+	var cp Checkpoint
+	if err = json.Unmarshal(scanner.Bytes(), &cp); err != nil {
+		return nil, err
+	}
+	cp.Files = make(map[string]CheckpointEntry)
+	for scanner.Scan() {
+		var e CheckpointEntry
+		// a crash part way through an append leaves a truncated last line, that file is uploaded again
+		if json.Unmarshal(scanner.Bytes(), &e) != nil {
+			break
+		}
+		cp.Files[e.Name] = e
+	}
+	return &cp, nil
+}
+
+func (s *FileCheckpointStore) Create(_ context.Context, cp *Checkpoint) error {
+	b, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dir := filepath.Join(s.dir, url.PathEscape(cp.InteractiveID))
+	if err = os.RemoveAll(dir); err != nil {
+		return err
+	}
+	if err = os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(s.path(cp.InteractiveID, cp.ArchiveChecksum), append(b, '\n'), 0o644)
+}
+
+func (s *FileCheckpointStore) Add(_ context.Context, cp *Checkpoint, entry CheckpointEntry) error {
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// no O_CREATE, a checkpoint removed while the import runs stays removed
+	f, err := os.OpenFile(s.path(cp.InteractiveID, cp.ArchiveChecksum), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (s *FileCheckpointStore) Remove(_ context.Context, interactiveID, archiveChecksum string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.Remove(s.path(interactiveID, archiveChecksum)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	// only succeeds once the directory is empty
+	_ = os.Remove(filepath.Join(s.dir, url.PathEscape(interactiveID)))
+	return nil
+}
+
+func (s *FileCheckpointStore) path(interactiveID, archiveChecksum string) string {
+	return filepath.Join(s.dir, url.PathEscape(interactiveID), url.PathEscape(archiveChecksum)+".jsonl")
+}
+
+// resumeCheckpoint returns the checkpoint left by an earlier attempt at the archive, unless its files are gone
+func (h *InteractivesUploadedHandler) resumeCheckpoint(ctx context.Context, event *InteractivesUploaded, checksum string) *Checkpoint {
+	cp, err := h.Checkpoints.Get(ctx, event.ID, checksum)
+	if err != nil {
+		log.Warn(ctx, "failed to read import checkpoint, every file will be uploaded", log.Data{"id": event.ID, "error": err.Error()})
+		return nil
+	}
+	if cp == nil || h.UploadRoots == nil {
+		return cp
+	}
+
+	// the upload root of a failed import is garbage collected once it is no longer recorded
+	root, err := h.UploadRoots.Get(ctx, cp.UploadRootPath)
+	if err != nil || root == nil {
+		log.Info(ctx, "upload root of import checkpoint no longer recorded, every file will be uploaded", log.Data{"id": event.ID, "upload_root": cp.UploadRootPath})
+		return nil
+	}
+	return cp
+}
+
+func (h *InteractivesUploadedHandler) createCheckpoint(ctx context.Context, event *InteractivesUploaded, checksum, uploadRootPath string) *Checkpoint {
+	cp := NewCheckpoint(event.ID, checksum, uploadRootPath)
+	if err := h.Checkpoints.Create(ctx, cp); err != nil {
+		log.Warn(ctx, "failed to create import checkpoint, a retry will upload every file", log.Data{"id": event.ID, "error": err.Error()})
+		return nil
+	}
+	return cp
+}
+
+func (h *InteractivesUploadedHandler) checkpointFile(ctx context.Context, cp *Checkpoint, entry CheckpointEntry) {
+	if cp == nil {
+		return
+	}
+	if err := h.Checkpoints.Add(ctx, cp, entry); err != nil {
+		log.Warn(ctx, "failed to checkpoint uploaded file", log.Data{"id": cp.InteractiveID, "file": entry.Name, "error": err.Error()})
+	}
+}
+
+// finishCheckpoint keeps the checkpoint of an import that is to be retried or redelivered, and removes any other
+func (h *InteractivesUploadedHandler) finishCheckpoint(ctx context.Context, cp *Checkpoint, resume bool) {
+	if resume {
+		return
+	}
+	if removeErr := h.Checkpoints.Remove(ctx, cp.InteractiveID, cp.ArchiveChecksum); removeErr != nil {
+		log.Warn(ctx, "failed to remove import checkpoint", log.Data{"id": cp.InteractiveID, "error": removeErr.Error()})
+	}
+}
@@ -0,0 +1,188 @@
+package importer_test
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	dperrors "github.com/ONSdigital/dp-api-clients-go/v2/errors"
+	"github.com/ONSdigital/dp-api-clients-go/v2/interactives"
+	"github.com/ONSdigital/dp-api-clients-go/v2/upload"
+	"github.com/ONSdigital/dp-interactives-importer/config"
+	"github.com/ONSdigital/dp-interactives-importer/importer"
+	mocks_importer "github.com/ONSdigital/dp-interactives-importer/importer/mocks"
+	"github.com/ONSdigital/dp-interactives-importer/internal/test"
+	"github.com/ONSdigital/log.go/v2/log"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestFileCheckpointStore(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Given a checkpoint store in an empty directory", t, func() {
+		dir := t.TempDir()
+		store, err := importer.NewFileCheckpointStore(dir)
+		So(err, ShouldBeNil)
+
+		Convey("Then an archive without a checkpoint should have none", func() {
+			cp, err := store.Get(ctx, "1", "sum")
+			So(err, ShouldBeNil)
+			So(cp, ShouldBeNil)
+		})
+
+		Convey("When a checkpoint is created and files are added to it", func() {
+			cp := importer.NewCheckpoint("1", "sum", "interactives/1/root")
+			So(store.Create(ctx, cp), ShouldBeNil)
+			So(store.Add(ctx, cp, importer.CheckpointEntry{Name: "a.html", Hash: "ha", Path: "interactives/1/root"}), ShouldBeNil)
+			So(store.Add(ctx, cp, importer.CheckpointEntry{Name: "b.html", Hash: "hb", Path: "interactives/1/root"}), ShouldBeNil)
+
+			Convey("Then it should read back with its upload root and files", func() {
+				got, err := store.Get(ctx, "1", "sum")
+				So(err, ShouldBeNil)
+				So(got.UploadRootPath, ShouldEqual, "interactives/1/root")
+				So(got.Files, ShouldHaveLength, 2)
+				entry, ok := got.Uploaded("b.html")
+				So(ok, ShouldBeTrue)
+				So(entry.Hash, ShouldEqual, "hb")
+			})
+
+			Convey("Then a line cut short by a crash should be ignored", func() {
+				f, err := os.OpenFile(filepath.Join(dir, "1", "sum.jsonl"), os.O_WRONLY|os.O_APPEND, 0)
+				So(err, ShouldBeNil)
+				_, err = f.WriteString(`{"name":"c.ht`)
+				So(err, ShouldBeNil)
+				So(f.Close(), ShouldBeNil)
+
+				got, err := store.Get(ctx, "1", "sum")
+				So(err, ShouldBeNil)
+				So(got.Files, ShouldHaveLength, 2)
+			})
+
+			Convey("Then a checkpoint for another archive of the interactive should replace it", func() {
+				So(store.Create(ctx, importer.NewCheckpoint("1", "other", "interactives/1/other")), ShouldBeNil)
+				got, err := store.Get(ctx, "1", "sum")
+				So(err, ShouldBeNil)
+				So(got, ShouldBeNil)
+			})
+
+			Convey("Then once removed, files should no longer be added to it", func() {
+				So(store.Remove(ctx, "1", "sum"), ShouldBeNil)
+				So(store.Add(ctx, cp, importer.CheckpointEntry{Name: "c.html"}), ShouldNotBeNil)
+				got, err := store.Get(ctx, "1", "sum")
+				So(err, ShouldBeNil)
+				So(got, ShouldBeNil)
+				So(store.Remove(ctx, "1", "sum"), ShouldBeNil)
+			})
+		})
+	})
+}
+
+func TestHandlerResumesFromCheckpoint(t *testing.T) {
+
+	Convey("Given a handler with checkpoints whose upload service fails part way through an archive", t, func() {
+		archive, err := test.CreateTestZip("a.html", "b.html", "c.html", "d.html")
+		So(err, ShouldBeNil)
+		defer os.Remove(archive)
+
+		var mu sync.Mutex
+		var uploaded []string
+		failOn := "c.html"
+		backend := &deletingBackend{UploadServiceBackendMock: &mocks_importer.UploadServiceBackendMock{
+			UploadFunc: func(_ context.Context, _ io.ReadCloser, metadata upload.Metadata) error {
+				mu.Lock()
+				defer mu.Unlock()
+				if metadata.FileName == failOn {
+					return &importer.StatusError{StatusCode: 503, Err: dperrors.NewErrorFromUnhandledStatusCode("upload-service", 503)}
+				}
+				uploaded = append(uploaded, metadata.FileName)
+				return nil
+			},
+		}}
+		checkpointDir := filepath.Join(t.TempDir(), "checkpoints")
+		checkpoints, err := importer.NewFileCheckpointStore(checkpointDir)
+		So(err, ShouldBeNil)
+		roots, err := importer.NewFileUploadRootStore(filepath.Join(t.TempDir(), "roots"))
+		So(err, ShouldBeNil)
+		var patched []interactives.PatchRequest
+		handler := &importer.InteractivesUploadedHandler{
+			Cfg:           &config.Config{BatchSize: 1, TempDir: t.TempDir(), CleanupFailedImports: true},
+			UploadService: importer.NewUploadService(backend),
+			UploadRoots:   roots,
+			Checkpoints:   checkpoints,
+			InteractivesAPIClient: &mocks_importer.InteractivesAPIClientMock{
+				PatchInteractiveFunc: func(_ context.Context, _, _, _ string, req interactives.PatchRequest) (interactives.Interactive, error) {
+					patched = append(patched, req)
+					return interactives.Interactive{}, nil
+				},
+			},
+		}
+		event := &importer.InteractivesUploaded{ID: "1", Path: "archive.zip"}
+		runImport := func(retry bool) error {
+			uploadJob := handler.NewJob(context.Background(), event)
+			if retry {
+				uploadJob.RetryOnFailure()
+			}
+			return handler.Import(context.Background(), uploadJob, event, archive, log.Data{})
+		}
+
+		Convey("When the import fails and will be retried", func() {
+			So(runImport(true), ShouldNotBeNil)
+			list, err := roots.List(context.Background())
+			So(err, ShouldBeNil)
+			So(list, ShouldHaveLength, 1)
+			firstRoot := list[0].Path
+
+			Convey("Then the files it uploaded should be kept for the retry, without reporting the failure", func() {
+				So(backend.deleted, ShouldBeEmpty)
+				So(list[0].Status, ShouldEqual, importer.RootStatusFailed)
+				So(patched, ShouldBeEmpty)
+			})
+
+			Convey("And the retry runs once the upload service recovers", func() {
+				// every file uploaded before the failure is skipped, so each is uploaded exactly once
+				before := len(uploaded)
+				failOn = ""
+				So(runImport(false), ShouldBeNil)
+
+				Convey("Then only the files not uploaded before should be uploaded, under the same root", func() {
+					So(before, ShouldBeLessThan, 4)
+					So(uploaded[before:], ShouldContain, "c.html")
+					So(uploaded, ShouldHaveLength, 4)
+					So(patched[0].Interactive.Archive.ImportSuccessful, ShouldBeTrue)
+					So(patched[0].Interactive.Archive.UploadRootDirectory, ShouldEqual, firstRoot)
+				})
+
+				Convey("Then the checkpoint should be removed", func() {
+					entries, err := os.ReadDir(checkpointDir)
+					So(err, ShouldBeNil)
+					So(entries, ShouldBeEmpty)
+				})
+			})
+		})
+
+		Convey("When the import fails with no retries left", func() {
+			So(runImport(false), ShouldNotBeNil)
+
+			Convey("Then its upload root and checkpoint should not be kept, as nothing will resume them", func() {
+				So(backend.deleted, ShouldHaveLength, 1)
+				entries, err := os.ReadDir(checkpointDir)
+				So(err, ShouldBeNil)
+				So(entries, ShouldBeEmpty)
+			})
+		})
+
+		Convey("When the import fails for a reason a retry would not fix", func() {
+			backend.UploadFunc = func(context.Context, io.ReadCloser, upload.Metadata) error {
+				return upload.ErrNotAuthorized
+			}
+			So(runImport(true), ShouldNotBeNil)
+
+			Convey("Then its upload root should be deleted as usual", func() {
+				So(backend.deleted, ShouldHaveLength, 1)
+			})
+		})
+	})
+}
@@ -0,0 +1,102 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"syscall"
+
+	"github.com/ONSdigital/dp-healthcheck/healthcheck"
+)
+
+// ErrInsufficientDiskSpace is returned before downloading an archive that would not fit in the temp directory
+var ErrInsufficientDiskSpace = errors.New("insufficient disk space")
+
+// FreeSpace returns the bytes available to an unprivileged user on the filesystem of dir
+func FreeSpace(dir string) (uint64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		return 0, err
+	}
+	return uint64(st.Bavail) * uint64(st.Bsize), nil
+}
+
+// reserved is the space claimed in each directory by archives still being written
+var (
+	reservedMu sync.Mutex
+	reserved   = make(map[string]uint64)
+)
+
+// reserveSpace claims size bytes in dir, failing if the archive would not fit in the free space less minFree and
+// the space claimed by archives still being written. The claim is released once the archive has been written.
+func reserveSpace(dir string, size int64, minFree uint64) (release func(), err error) {
+	reservedMu.Lock()
+	defer reservedMu.Unlock()
+
+	free, err := FreeSpace(dir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read free space of %s: %w", dir, err)
+	}
+	var claim uint64
+	if size > 0 {
+		claim = uint64(size)
+	}
+	var available uint64
+	if held := reserved[dir]; free > held && free-held > minFree {
+		available = free - held - minFree
+	}
+	if claim > available || (claim == 0 && available == 0) {
+		return nil, fmt.Errorf("%w: archive is %d bytes but only %d bytes are available in %s, which has %d bytes free, %d claimed by other archives and keeps %d free",
+			ErrInsufficientDiskSpace, size, available, dir, free, reserved[dir], minFree)
+	}
+
+	reserved[dir] += claim
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			reservedMu.Lock()
+			defer reservedMu.Unlock()
+			reserved[dir] -= claim
+		})
+	}, nil
+}
+
+// CreateTempArchive creates a file in dir for an archive of size bytes, named so the TempFileSweeper removes it
+// if it is left behind. It fails before anything is written if the archive would not fit alongside the archives
+// still being written while keeping minFree bytes free. Release must be called once the archive has been written.
+func CreateTempArchive(dir string, size int64, minFree uint64) (_ *os.File, release func(), err error) {
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	if release, err = reserveSpace(dir, size, minFree); err != nil {
+		return nil, nil, err
+	}
+	f, err := os.CreateTemp(dir, tempFilePattern)
+	if err != nil {
+		release()
+		return nil, nil, err
+	}
+	return f, release, nil
+}
+
+// DiskSpaceChecker reports the free space of the temp directory archives are downloaded to
+type DiskSpaceChecker struct {
+	Dir          string
+	MinFreeBytes uint64
+}
+
+// Checker warns when free space falls below the minimum, and is critical when it cannot be read
+func (c *DiskSpaceChecker) Checker(_ context.Context, state *healthcheck.CheckState) error {
+	free, err := FreeSpace(c.Dir)
+	if err != nil {
+		return state.Update(healthcheck.StatusCritical, fmt.Sprintf("cannot read free space of %s: %s", c.Dir, err), 0)
+	}
+
+	msg := fmt.Sprintf("%d bytes free in %s, minimum %d", free, c.Dir, c.MinFreeBytes)
+	if free < c.MinFreeBytes {
+		return state.Update(healthcheck.StatusWarning, msg, 0)
+	}
+	return state.Update(healthcheck.StatusOK, msg, 0)
+}
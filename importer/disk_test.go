@@ -0,0 +1,108 @@
+package importer_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ONSdigital/dp-healthcheck/healthcheck"
+	"github.com/ONSdigital/dp-interactives-importer/importer"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDiskSpaceChecker(t *testing.T) {
+
+	Convey("Given a temp directory", t, func() {
+		dir := t.TempDir()
+
+		Convey("Then its free space can be read", func() {
+			free, err := importer.FreeSpace(dir)
+			So(err, ShouldBeNil)
+			So(free, ShouldBeGreaterThan, 0)
+		})
+
+		Convey("When it has more than the minimum free", func() {
+			state := healthcheck.NewCheckState("Temp disk space")
+			checker := &importer.DiskSpaceChecker{Dir: dir}
+			So(checker.Checker(context.TODO(), state), ShouldBeNil)
+
+			Convey("Then the check should be OK", func() {
+				So(state.Status(), ShouldEqual, healthcheck.StatusOK)
+				So(state.Message(), ShouldContainSubstring, dir)
+			})
+		})
+
+		Convey("When it has less than the minimum free", func() {
+			state := healthcheck.NewCheckState("Temp disk space")
+			checker := &importer.DiskSpaceChecker{Dir: dir, MinFreeBytes: math.MaxUint64}
+			So(checker.Checker(context.TODO(), state), ShouldBeNil)
+
+			Convey("Then the check should warn", func() {
+				So(state.Status(), ShouldEqual, healthcheck.StatusWarning)
+			})
+		})
+
+		Convey("When it does not exist", func() {
+			state := healthcheck.NewCheckState("Temp disk space")
+			checker := &importer.DiskSpaceChecker{Dir: filepath.Join(dir, "missing")}
+			So(checker.Checker(context.TODO(), state), ShouldBeNil)
+
+			Convey("Then the check should be critical", func() {
+				So(state.Status(), ShouldEqual, healthcheck.StatusCritical)
+			})
+		})
+	})
+}
+
+func TestCreateTempArchive(t *testing.T) {
+
+	Convey("Given a temp directory", t, func() {
+		dir := t.TempDir()
+		free, err := importer.FreeSpace(dir)
+		So(err, ShouldBeNil)
+
+		Convey("When an archive that fits is created", func() {
+			f, release, err := importer.CreateTempArchive(dir, 1, 0)
+			So(err, ShouldBeNil)
+			defer release()
+			defer f.Close()
+
+			Convey("Then it should be named for the sweeper", func() {
+				So(filepath.Dir(f.Name()), ShouldEqual, dir)
+				So(filepath.Base(f.Name()), ShouldStartWith, "s3-zip_")
+			})
+		})
+
+		Convey("When the minimum free space would not be left", func() {
+			_, _, err := importer.CreateTempArchive(dir, 1, free)
+
+			Convey("Then it should fail", func() {
+				So(errors.Is(err, importer.ErrInsufficientDiskSpace), ShouldBeTrue)
+				entries, _ := os.ReadDir(dir)
+				So(entries, ShouldBeEmpty)
+			})
+		})
+
+		Convey("When another archive has claimed most of the free space", func() {
+			size := int64(free / 4 * 3)
+			f, release, err := importer.CreateTempArchive(dir, size, 0)
+			So(err, ShouldBeNil)
+			defer f.Close()
+
+			Convey("Then an archive of the same size should not fit until the claim is released", func() {
+				_, _, err := importer.CreateTempArchive(dir, size, 0)
+				So(errors.Is(err, importer.ErrInsufficientDiskSpace), ShouldBeTrue)
+
+				release()
+				g, releaseAgain, err := importer.CreateTempArchive(dir, size, 0)
+				So(err, ShouldBeNil)
+				releaseAgain()
+				So(g.Close(), ShouldBeNil)
+			})
+		})
+	})
+}
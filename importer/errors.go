@@ -0,0 +1,201 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net"
+	"net/http"
+
+	"github.com/ONSdigital/dp-api-clients-go/v2/upload"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+)
+
+// Category is the kind of failure of an import, which decides whether it is retried and what editors are told
+type Category string
+
+const (
+	CategoryCorruptArchive     Category = "corrupt_archive"
+	CategoryPolicyViolation    Category = "policy_violation"
+	CategoryStorageUnavailable Category = "storage_unavailable"
+	CategoryTimeout            Category = "timeout"
+	CategoryUnauthorized       Category = "unauthorized"
+	CategoryInterrupted        Category = "interrupted"
+	CategoryUnknown            Category = "unknown"
+)
+
+// Errors to test the category of an error with errors.Is
+var (
+	ErrCorruptArchive     = errors.New("corrupt archive")
+	ErrPolicyViolation    = errors.New("policy violation")
+	ErrStorageUnavailable = errors.New("storage unavailable")
+	ErrTimeout            = errors.New("timeout")
+	ErrUnauthorized       = errors.New("unauthorized")
+)
+
+var categoryErrors = map[Category]error{
+	CategoryCorruptArchive:     ErrCorruptArchive,
+	CategoryPolicyViolation:    ErrPolicyViolation,
+	CategoryStorageUnavailable: ErrStorageUnavailable,
+	CategoryTimeout:            ErrTimeout,
+	CategoryUnauthorized:       ErrUnauthorized,
+}
+
+var editorMessages = map[Category]string{
+	CategoryCorruptArchive:     "The archive is corrupt or is not a zip file. Check it opens locally and upload it again.",
+	CategoryPolicyViolation:    "The archive contains files that cannot be published. Remove them and upload it again.",
+	CategoryStorageUnavailable: "File storage was unavailable. Upload the archive again later.",
+	CategoryTimeout:            "The import took too long. Upload the archive again, or split it into smaller archives.",
+	CategoryUnauthorized:       "The importer was not allowed to store the files. Contact support.",
+	CategoryInterrupted:        "The import was interrupted and will start again shortly.",
+	CategoryUnknown:            "The import failed. Contact support if uploading the archive again does not help.",
+}
+
+// Retryable is true for failures that may not happen again, such as storage being unavailable
+func (c Category) Retryable() bool {
+	switch c {
+	case CategoryStorageUnavailable, CategoryTimeout, CategoryInterrupted:
+		return true
+	}
+	return false
+}
+
+// EditorMessage is what editors are told about an import that failed for this reason
+func (c Category) EditorMessage() string {
+	if msg, ok := editorMessages[c]; ok {
+		return msg
+	}
+	return editorMessages[CategoryUnknown]
+}
+
+// ImportError puts an error into a category, leaving its message as it is
+type ImportError struct {
+	Category Category
+	Err      error
+}
+
+func (e *ImportError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ImportError) Unwrap() error {
+	return e.Err
+}
+
+// Is matches the error of the category, so errors.Is(err, ErrStorageUnavailable) finds a wrapped ImportError
+func (e *ImportError) Is(target error) bool {
+	return target != nil && categoryErrors[e.Category] == target
+}
+
+// categorise wraps err in an ImportError, unless it is nil, already categorised or the context being done,
+// which the stage or import deadline accounts for
+func categorise(category Category, err error) error {
+	var importErr *ImportError
+	if err == nil || errors.As(err, &importErr) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	return &ImportError{Category: category, Err: err}
+}
+
+// CategoryOf returns the category of an error, the outermost wins so a timeout beats the errors it cut short
+func CategoryOf(err error) Category {
+	var timeoutErr *TimeoutError
+	var importErr *ImportError
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrInterrupted):
+		return CategoryInterrupted
+	case errors.As(err, &timeoutErr):
+		return CategoryTimeout
+	case errors.As(err, &importErr):
+		return importErr.Category
+	}
+	return CategoryUnknown
+}
+
+// Retryable is true if the category of err says it may not happen again. An import whose outcome was not reported
+// is never imported again, only its report is sent again.
+func Retryable(err error) bool {
+	return err != nil && !errors.Is(err, ErrNotReported) && CategoryOf(err).Retryable()
+}
+
+// archiveError categorises the failure to read an archive or a file in it, which is down to its content
+// unless the local file could not be read
+func archiveError(err error) error {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return categorise(CategoryStorageUnavailable, err)
+	}
+	return categorise(CategoryCorruptArchive, err)
+}
+
+// s3Error categorises a failure to get an archive from S3
+func s3Error(err error) error {
+	var reqErr awserr.RequestFailure
+	if errors.As(err, &reqErr) {
+		return categorise(statusCategory(reqErr.StatusCode()), err)
+	}
+	return categorise(CategoryStorageUnavailable, err)
+}
+
+// uploadError categorises a failure to upload a file to the upload backend
+func uploadError(err error) error {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, upload.ErrNotAuthorized):
+		return categorise(CategoryUnauthorized, err)
+	case errors.Is(err, upload.ErrFileTooLarge):
+		return categorise(CategoryPolicyViolation, err)
+	case errors.As(err, &netErr):
+		return categorise(CategoryStorageUnavailable, err)
+	}
+	return categorise(statusCategory(statusCode(err)), err)
+}
+
+// StatusError is the failure of a request to an api that responded with an unexpected status. The clients of the
+// upload service and interactives api do not keep the status, so it is added where their requests are made.
+type StatusError struct {
+	StatusCode int
+	Err        error
+}
+
+func (e *StatusError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *StatusError) Unwrap() error {
+	return e.Err
+}
+
+// Code returns the status, the way the errors of dp-api-clients-go do
+func (e *StatusError) Code() int {
+	return e.StatusCode
+}
+
+// statusCode returns the http status of a failed request to an api, zero if it is not known
+func statusCode(err error) int {
+	var reqErr awserr.RequestFailure
+	if errors.As(err, &reqErr) {
+		return reqErr.StatusCode()
+	}
+	var coder interface{ Code() int }
+	if errors.As(err, &coder) {
+		return coder.Code()
+	}
+	return 0
+}
+
+// statusCategory categorises the http status of a failed request to a storage service. Only a status saying the
+// service is overloaded or failing is retryable, any other, or none, is unknown.
+func statusCategory(code int) Category {
+	switch {
+	case code == http.StatusUnauthorized || code == http.StatusForbidden:
+		return CategoryUnauthorized
+	case code == http.StatusRequestEntityTooLarge:
+		return CategoryPolicyViolation
+	case code == http.StatusRequestTimeout || code == http.StatusTooManyRequests || code >= http.StatusInternalServerError:
+		return CategoryStorageUnavailable
+	}
+	return CategoryUnknown
+}
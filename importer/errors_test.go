@@ -0,0 +1,175 @@
+package importer_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/ONSdigital/dp-api-clients-go/v2/interactives"
+	"github.com/ONSdigital/dp-api-clients-go/v2/upload"
+	"github.com/ONSdigital/dp-interactives-importer/config"
+	"github.com/ONSdigital/dp-interactives-importer/importer"
+	mocks_importer "github.com/ONSdigital/dp-interactives-importer/importer/mocks"
+	"github.com/ONSdigital/dp-interactives-importer/internal/test"
+	"github.com/ONSdigital/log.go/v2/log"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestErrorCategories(t *testing.T) {
+
+	Convey("Given an error put into a category and wrapped", t, func() {
+		err := fmt.Errorf("sending file: %w", &importer.ImportError{Category: importer.CategoryStorageUnavailable, Err: errors.New("503")})
+
+		Convey("Then its category should be found with errors.Is, errors.As and CategoryOf", func() {
+			So(errors.Is(err, importer.ErrStorageUnavailable), ShouldBeTrue)
+			So(errors.Is(err, importer.ErrCorruptArchive), ShouldBeFalse)
+			var importErr *importer.ImportError
+			So(errors.As(err, &importErr), ShouldBeTrue)
+			So(importErr.Category, ShouldEqual, importer.CategoryStorageUnavailable)
+			So(importer.CategoryOf(err), ShouldEqual, importer.CategoryStorageUnavailable)
+			So(importer.Retryable(err), ShouldBeTrue)
+			So(err.Error(), ShouldEqual, "sending file: 503")
+		})
+
+		Convey("Then a timeout around it should take precedence", func() {
+			timeoutErr := &importer.TimeoutError{Stage: importer.StageUpload, Import: true, Err: err}
+			So(errors.Is(timeoutErr, importer.ErrTimeout), ShouldBeTrue)
+			So(importer.CategoryOf(timeoutErr), ShouldEqual, importer.CategoryTimeout)
+		})
+	})
+
+	Convey("Given the file errors of an archive", t, func() {
+		err := importer.ArchiveErrors{
+			{File: "a.exe", Code: importer.CodeInvalidFile, Err: &importer.ImportError{Category: importer.CategoryPolicyViolation, Err: importer.ErrUnknownType}},
+		}
+
+		Convey("Then the category of the files should be found", func() {
+			So(errors.Is(err, importer.ErrPolicyViolation), ShouldBeTrue)
+			So(errors.Is(err, importer.ErrUnknownType), ShouldBeTrue)
+			So(importer.CategoryOf(err), ShouldEqual, importer.CategoryPolicyViolation)
+			So(importer.Retryable(err), ShouldBeFalse)
+		})
+	})
+
+	Convey("Given errors that are not categorised", t, func() {
+		Convey("Then they should be unknown and not retried", func() {
+			So(importer.CategoryOf(errors.New("boom")), ShouldEqual, importer.CategoryUnknown)
+			So(importer.Retryable(errors.New("boom")), ShouldBeFalse)
+			So(importer.CategoryOf(nil), ShouldEqual, importer.Category(""))
+			So(importer.CategoryOf(importer.ErrInterrupted), ShouldEqual, importer.CategoryInterrupted)
+		})
+
+		Convey("Then every category should have a message for editors", func() {
+			So(importer.Category("made up").EditorMessage(), ShouldEqual, importer.CategoryUnknown.EditorMessage())
+			So(importer.CategoryCorruptArchive.EditorMessage(), ShouldNotEqual, importer.CategoryUnknown.EditorMessage())
+		})
+	})
+}
+
+func TestS3ErrorCategories(t *testing.T) {
+
+	for status, category := range map[int]importer.Category{
+		403: importer.CategoryUnauthorized,
+		500: importer.CategoryStorageUnavailable,
+		503: importer.CategoryStorageUnavailable,
+	} {
+		Convey(fmt.Sprintf("Given S3 fails with status %d", status), t, func() {
+			handler := &importer.InteractivesUploadedHandler{
+				Cfg: &config.Config{BatchSize: 1, TempDir: t.TempDir()},
+				S3: &mocks_importer.S3InterfaceMock{
+					GetFunc: func(string) (io.ReadCloser, *int64, error) {
+						return nil, nil, awserr.NewRequestFailure(awserr.New("Failed", "failed", nil), status, "request-id")
+					},
+				},
+				InteractivesAPIClient: &mocks_importer.InteractivesAPIClientMock{
+					PatchInteractiveFunc: func(context.Context, string, string, string, interactives.PatchRequest) (interactives.Interactive, error) {
+						return interactives.Interactive{}, nil
+					},
+				},
+			}
+			event := &importer.InteractivesUploaded{ID: "1", Path: "archive.zip"}
+
+			Convey("Then the import should fail with category "+string(category), func() {
+				err := handler.Import(context.Background(), handler.NewJob(context.Background(), event), event, "", log.Data{})
+				So(importer.CategoryOf(err), ShouldEqual, category)
+			})
+		})
+	}
+}
+
+func TestUploadServiceErrorCategories(t *testing.T) {
+
+	for status, category := range map[int]importer.Category{
+		401: importer.CategoryUnauthorized,
+		409: importer.CategoryUnknown,
+		413: importer.CategoryPolicyViolation,
+		418: importer.CategoryUnknown,
+		429: importer.CategoryStorageUnavailable,
+		503: importer.CategoryStorageUnavailable,
+	} {
+		Convey(fmt.Sprintf("Given the upload service responds with status %d", status), t, func() {
+			archive, err := test.CreateTestZip("index.html")
+			So(err, ShouldBeNil)
+			defer os.Remove(archive)
+
+			handler := &importer.InteractivesUploadedHandler{
+				Cfg: &config.Config{BatchSize: 1, TempDir: t.TempDir()},
+				UploadService: importer.NewUploadService(&mocks_importer.UploadServiceBackendMock{
+					UploadFunc: func(context.Context, io.ReadCloser, upload.Metadata) error {
+						return &importer.StatusError{StatusCode: status, Err: errors.New("upload rejected")}
+					},
+				}),
+				InteractivesAPIClient: &mocks_importer.InteractivesAPIClientMock{
+					PatchInteractiveFunc: func(context.Context, string, string, string, interactives.PatchRequest) (interactives.Interactive, error) {
+						return interactives.Interactive{}, nil
+					},
+				},
+			}
+			event := &importer.InteractivesUploaded{ID: "1", Path: "archive.zip"}
+
+			Convey("Then the import should fail with category "+string(category), func() {
+				err := handler.Import(context.Background(), handler.NewJob(context.Background(), event), event, archive, log.Data{})
+				So(importer.CategoryOf(err), ShouldEqual, category)
+				So(importer.Retryable(err), ShouldEqual, category.Retryable())
+			})
+		})
+	}
+}
+
+func TestS3UploadErrorCategories(t *testing.T) {
+
+	for status, category := range map[int]importer.Category{
+		403: importer.CategoryUnauthorized,
+		503: importer.CategoryStorageUnavailable,
+	} {
+		Convey(fmt.Sprintf("Given an upload backend writing to S3 fails with status %d", status), t, func() {
+			archive, err := test.CreateTestZip("index.html")
+			So(err, ShouldBeNil)
+			defer os.Remove(archive)
+
+			handler := &importer.InteractivesUploadedHandler{
+				Cfg: &config.Config{BatchSize: 1, TempDir: t.TempDir()},
+				UploadService: importer.NewUploadService(&mocks_importer.UploadServiceBackendMock{
+					UploadFunc: func(context.Context, io.ReadCloser, upload.Metadata) error {
+						return fmt.Errorf("failed to upload: %w", awserr.NewRequestFailure(awserr.New("Failed", "failed", nil), status, "request-id"))
+					},
+				}),
+				InteractivesAPIClient: &mocks_importer.InteractivesAPIClientMock{
+					PatchInteractiveFunc: func(context.Context, string, string, string, interactives.PatchRequest) (interactives.Interactive, error) {
+						return interactives.Interactive{}, nil
+					},
+				},
+			}
+			event := &importer.InteractivesUploaded{ID: "1", Path: "archive.zip"}
+
+			Convey("Then the import should fail with category "+string(category), func() {
+				err := handler.Import(context.Background(), handler.NewJob(context.Background(), event), event, archive, log.Data{})
+				So(importer.CategoryOf(err), ShouldEqual, category)
+			})
+		})
+	}
+}
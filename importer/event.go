@@ -2,8 +2,17 @@ package importer
 
 // InteractivesUploaded provides an avro structure for an interactives uploaded event
 type InteractivesUploaded struct {
-	ID    string `avro:"id"`
-	Path  string `avro:"path"`
-	Title string `avro:"title"`
+	ID           string `avro:"id"`
+	Path         string `avro:"path"`
+	Title        string `avro:"title"`
+	CollectionID string `avro:"collection_id"`
+	Version      string `avro:"version"` // identifies the archive at the path, a new archive at the same path has a new version
+}
+
+// legacyInteractivesUploaded is an event sent before the version was added, every field must be in its schema
+type legacyInteractivesUploaded struct {
+	ID           string `avro:"id"`
+	Path         string `avro:"path"`
+	Title        string `avro:"title"`
 	CollectionID string `avro:"collection_id"`
 }
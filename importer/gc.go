@@ -0,0 +1,133 @@
+package importer
+
+import (
+	"context"
+	"time"
+
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// GarbageCollector removes upload roots superseded by a later import of the same interactive
+type GarbageCollector struct {
+	Roots                 UploadRootStore
+	UploadService         *UploadService
+	InteractivesAPIClient InteractivesAPIClient
+	ContentIndex          ContentIndex // optional, forgets the files of each removed root
+	ServiceAuthToken      string
+	Retention             time.Duration
+	DryRun                bool
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// GCReport lists what a garbage collection run did, or would do on a dry run
+type GCReport struct {
+	DryRun    bool              `json:"dry_run"`
+	Current   []string          `json:"current,omitempty"`
+	Retained  []string          `json:"retained,omitempty"`
+	Removed   []string          `json:"removed,omitempty"`
+	Unremoved map[string]string `json:"unremoved,omitempty"`
+}
+
+// Run makes a single pass over every recorded upload root
+func (gc *GarbageCollector) Run(ctx context.Context) (*GCReport, error) {
+	roots, err := gc.Roots.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byInteractive := make(map[string][]UploadRoot)
+	for _, r := range roots {
+		byInteractive[r.InteractiveID] = append(byInteractive[r.InteractiveID], r)
+	}
+
+	report := &GCReport{DryRun: gc.DryRun, Unremoved: make(map[string]string)}
+	cutoff := time.Now().Add(-gc.Retention)
+	for id, roots := range byInteractive {
+		interactive, err := gc.InteractivesAPIClient.GetInteractive(ctx, "", gc.ServiceAuthToken, id)
+		if err != nil {
+			// without knowing the current root nothing is safe to remove
+			for _, r := range roots {
+				report.Unremoved[r.Path] = err.Error()
+			}
+			continue
+		}
+		var current string
+		if interactive.Archive != nil {
+			current = interactive.Archive.UploadRootDirectory
+		}
+
+		for _, r := range roots {
+			switch {
+			case r.Path == current:
+				report.Current = append(report.Current, r.Path)
+			case r.UpdatedAt.After(cutoff):
+				report.Retained = append(report.Retained, r.Path)
+			default:
+				gc.remove(ctx, r, report)
+			}
+		}
+	}
+
+	return report, nil
+}
+
+func (gc *GarbageCollector) remove(ctx context.Context, r UploadRoot, report *GCReport) {
+	if gc.DryRun {
+		report.Removed = append(report.Removed, r.Path)
+		return
+	}
+
+	// imports copy deduplicated files from the paths in the index, so forget the root before deleting it
+	if gc.ContentIndex != nil {
+		if err := gc.ContentIndex.Forget(ctx, r.Path); err != nil {
+			report.Unremoved[r.Path] = err.Error()
+			return
+		}
+	}
+	if err := gc.UploadService.DeleteRoot(ctx, r.Path); err != nil {
+		report.Unremoved[r.Path] = err.Error()
+		return
+	}
+	if err := gc.Roots.Remove(ctx, r.Path); err != nil {
+		log.Warn(ctx, "failed to remove deleted upload root from store", log.Data{"path": r.Path, "error": err.Error()})
+	}
+	report.Removed = append(report.Removed, r.Path)
+}
+
+// Start runs the garbage collector every interval until Stop is called
+func (gc *GarbageCollector) Start(ctx context.Context, interval time.Duration) {
+	gc.stop = make(chan struct{})
+	gc.done = make(chan struct{})
+
+	go func() {
+		defer close(gc.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				report, err := gc.Run(ctx)
+				if err != nil {
+					log.Error(ctx, "garbage collection of upload roots failed", err)
+					continue
+				}
+				log.Info(ctx, "garbage collection of upload roots complete", log.Data{"report": report})
+			case <-gc.stop:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+func (gc *GarbageCollector) Stop() {
+	if gc.stop == nil {
+		return
+	}
+	close(gc.stop)
+	<-gc.done
+}
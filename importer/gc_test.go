@@ -0,0 +1,127 @@
+package importer_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-api-clients-go/v2/interactives"
+	"github.com/ONSdigital/dp-interactives-importer/importer"
+	mocks_importer "github.com/ONSdigital/dp-interactives-importer/importer/mocks"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type memoryRootStore struct {
+	roots map[string]importer.UploadRoot
+}
+
+func (s *memoryRootStore) Get(_ context.Context, path string) (*importer.UploadRoot, error) {
+	r, ok := s.roots[path]
+	if !ok {
+		return nil, nil
+	}
+	return &r, nil
+}
+
+func (s *memoryRootStore) Put(_ context.Context, root importer.UploadRoot) error {
+	s.roots[root.Path] = root
+	return nil
+}
+
+func (s *memoryRootStore) List(_ context.Context) ([]importer.UploadRoot, error) {
+	var list []importer.UploadRoot
+	for _, r := range s.roots {
+		list = append(list, r)
+	}
+	return list, nil
+}
+
+func (s *memoryRootStore) Remove(_ context.Context, path string) error {
+	delete(s.roots, path)
+	return nil
+}
+
+func TestGarbageCollector(t *testing.T) {
+	old := time.Now().Add(-48 * time.Hour)
+
+	Convey("Given an interactive imported three times", t, func() {
+		roots := &memoryRootStore{roots: map[string]importer.UploadRoot{
+			"interactives/1/old":     {InteractiveID: "1", Path: "interactives/1/old", UpdatedAt: old},
+			"interactives/1/recent":  {InteractiveID: "1", Path: "interactives/1/recent", UpdatedAt: time.Now()},
+			"interactives/1/current": {InteractiveID: "1", Path: "interactives/1/current", UpdatedAt: old},
+		}}
+		mockInteractivesAPI := &mocks_importer.InteractivesAPIClientMock{
+			GetInteractiveFunc: func(context.Context, string, string, string) (interactives.Interactive, error) {
+				return interactives.Interactive{ID: "1", Archive: &interactives.Archive{UploadRootDirectory: "interactives/1/current"}}, nil
+			},
+		}
+		backend := &deletingBackend{UploadServiceBackendMock: &mocks_importer.UploadServiceBackendMock{}}
+		gc := &importer.GarbageCollector{
+			Roots:                 roots,
+			UploadService:         importer.NewUploadService(backend),
+			InteractivesAPIClient: mockInteractivesAPI,
+			Retention:             24 * time.Hour,
+		}
+
+		Convey("When run as a dry run", func() {
+			gc.DryRun = true
+			report, err := gc.Run(context.TODO())
+			So(err, ShouldBeNil)
+
+			Convey("Then only the superseded root past retention should be reported and nothing deleted", func() {
+				So(report.DryRun, ShouldBeTrue)
+				So(report.Current, ShouldResemble, []string{"interactives/1/current"})
+				So(report.Retained, ShouldResemble, []string{"interactives/1/recent"})
+				So(report.Removed, ShouldResemble, []string{"interactives/1/old"})
+				So(backend.deleted, ShouldBeEmpty)
+				So(roots.roots, ShouldHaveLength, 3)
+			})
+		})
+
+		Convey("When run for real", func() {
+			report, err := gc.Run(context.TODO())
+			So(err, ShouldBeNil)
+
+			Convey("Then the superseded root should be deleted and forgotten", func() {
+				So(report.Removed, ShouldResemble, []string{"interactives/1/old"})
+				So(backend.deleted, ShouldResemble, []string{"interactives/1/old"})
+				So(roots.roots, ShouldNotContainKey, "interactives/1/old")
+			})
+		})
+
+		Convey("When the superseded root holds indexed content", func() {
+			idx, err := importer.NewFileContentIndex(t.TempDir())
+			So(err, ShouldBeNil)
+			So(idx.Put(context.TODO(), "hash", "interactives/1/old/js/jquery.js"), ShouldBeNil)
+			So(idx.Put(context.TODO(), "other", "interactives/1/current/js/app.js"), ShouldBeNil)
+			gc.ContentIndex = idx
+			report, err := gc.Run(context.TODO())
+			So(err, ShouldBeNil)
+
+			Convey("Then it should be removed, as later imports hold their own copies, and dropped from the index", func() {
+				So(report.Removed, ShouldResemble, []string{"interactives/1/old"})
+				So(backend.deleted, ShouldResemble, []string{"interactives/1/old"})
+				_, found, _ := idx.Get(context.TODO(), "hash")
+				So(found, ShouldBeFalse)
+				_, found, _ = idx.Get(context.TODO(), "other")
+				So(found, ShouldBeTrue)
+			})
+		})
+
+		Convey("When the interactives api is unavailable", func() {
+			mockInteractivesAPI.GetInteractiveFunc = func(context.Context, string, string, string) (interactives.Interactive, error) {
+				return interactives.Interactive{}, errors.New("unavailable")
+			}
+			report, err := gc.Run(context.TODO())
+			So(err, ShouldBeNil)
+
+			Convey("Then nothing should be removed", func() {
+				So(report.Removed, ShouldBeEmpty)
+				So(report.Unremoved, ShouldHaveLength, 3)
+				So(backend.deleted, ShouldBeEmpty)
+			})
+		})
+	})
+}
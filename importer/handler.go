@@ -3,15 +3,24 @@ package importer
 import (
 	"archive/zip"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"os"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/ONSdigital/dp-interactives-importer/config"
 	"github.com/ONSdigital/dp-interactives-importer/schema"
 	kafka "github.com/ONSdigital/dp-kafka/v3"
 	"github.com/ONSdigital/log.go/v2/log"
-	gonanoid "github.com/matoous/go-nanoid/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type InteractivesUploadedHandler struct {
@@ -19,10 +28,50 @@ type InteractivesUploadedHandler struct {
 	S3                    S3Interface
 	UploadService         *UploadService
 	InteractivesAPIClient InteractivesAPIClient
+	ContentIndex          ContentIndex       // optional, when set files already in storage are copied rather than uploaded again
+	Manifests             ManifestStore      // optional, when set a re-import copies unchanged files rather than upload them
+	UploadRoots           UploadRootStore    // optional, records upload roots for garbage collection
+	Registry              *Registry          // optional, tracks the progress of each import
+	Progress              ProgressPublisher  // optional, publishes the progress of each import while it runs
+	Uploads               *UploadLimiter     // optional, shared by every import to cap the uploads running at once
+	Breakers              []*CircuitBreaker  // optional, new imports wait while any of them is open
+	Checkpoints           CheckpointStore    // optional, lets a retried import skip the files already uploaded
+	PendingReports        PendingReportStore // optional, keeps the outcomes not reported for a ReportSender to send
+
+	mu          sync.Mutex
+	closing     bool
+	interrupted bool
+	interrupt   chan struct{} // closed when shutdown gives up waiting on running imports
+	running     sync.WaitGroup
+}
+
+// ErrInterrupted is the error of an import cancelled by shutdown, its event is not committed so it is redelivered
+var ErrInterrupted = errors.New("interrupted")
+
+// ErrNotReported is the error of an import whose outcome the interactives api could not be told. The import is not
+// run again, only its report is sent again. The event is committed once the report is kept in the pending report
+// store, as a later event on the partition commits past it anyway.
+var ErrNotReported = errors.New("outcome not reported")
+
+// maxReportBackoff caps the wait between sending the outcome of an import again without a pending report store
+const maxReportBackoff = 5 * time.Minute
+
+// redeliver is returned to the kafka consumer for an event that must not be committed
+type redeliver struct {
+	error
+}
+
+func (e redeliver) Commit() bool {
+	return false
+}
+
+func (e redeliver) Unwrap() error {
+	return e.error
 }
 
 func (h *InteractivesUploadedHandler) Handle(ctx context.Context, workerID int, msg kafka.Message) error {
 	logData := log.Data{"workerID": workerID}
+	ctx = otel.GetTextMapPropagator().Extract(ctx, messageCarrier{msg})
 
 	event, err := getAsEvent(ctx, msg)
 	if err != nil {
@@ -30,96 +79,680 @@ func (h *InteractivesUploadedHandler) Handle(ctx context.Context, workerID int,
 		return err
 	}
 
-	var zipSize int64
-	//no leading slash: https://github.com/ONSdigital/dp-upload-service/blob/ecc6062e6fe5856385b5fafbe1105606c1a958ff/api/upload.go#L25
-	randomString := gonanoid.Must(16)
-	uploadRootPath := fmt.Sprintf("%s/%s/%s", "interactives", event.ID, randomString)
+	err = h.importWithRetries(ctx, event, logData)
+	switch {
+	case errors.Is(err, ErrInterrupted):
+		return redeliver{err}
+	case errors.Is(err, ErrNotReported):
+		return h.keepReport(ctx, err)
+	}
+	h.forgetReport(ctx, event.ID)
+	return err
+}
+
+// keepReport keeps the outcome the interactives api could not be told in the pending report store, to be sent in
+// the background, so the event can be committed
+func (h *InteractivesUploadedHandler) keepReport(ctx context.Context, err error) error {
+	var notReported *NotReportedError
+	if h.PendingReports == nil || !errors.As(err, &notReported) {
+		return err
+	}
+	logData := log.Data{"id": notReported.InteractiveID}
+	report := PendingReport{InteractiveID: notReported.InteractiveID, Request: notReported.Request, CreatedAt: time.Now().UTC()}
+	if putErr := h.PendingReports.Put(ctx, report); putErr != nil {
+		log.Error(ctx, "failed to keep the outcome of import to report later, it is lost", putErr, logData)
+		return errors.Join(err, putErr)
+	}
+	log.Warn(ctx, "outcome of import kept to report later", logData)
+	return err
+}
+
+// forgetReport drops the pending outcome of an earlier import of the interactive, once a later one has reported
+func (h *InteractivesUploadedHandler) forgetReport(ctx context.Context, interactiveID string) {
+	if h.PendingReports == nil {
+		return
+	}
+	if err := h.PendingReports.Remove(ctx, interactiveID); err != nil {
+		log.Warn(ctx, "failed to remove superseded outcome of import from store", log.Data{"id": interactiveID, "error": err.Error()})
+	}
+}
+
+// importWithRetries imports the event, trying again with a new job while it fails for a reason that may not
+// happen again. A shutdown while waiting to try again interrupts the import, so the event is redelivered.
+// An import whose outcome is not reported is not tried again, only its report is.
+func (h *InteractivesUploadedHandler) importWithRetries(ctx context.Context, event *InteractivesUploaded, logData log.Data) error {
+	// the jobs keep the context as it is, only the wait between attempts is cut short by shutdown
+	waitCtx, done := h.track(ctx)
+	defer done()
+
+	backoff := h.Cfg.ImportRetryBackoff
+	for attempt := 0; ; attempt++ {
+		attemptLogData := log.Data{"attempt": attempt + 1}
+		for k, v := range logData {
+			attemptLogData[k] = v
+		}
+		uploadJob := h.NewJob(ctx, event)
+		if attempt < h.Cfg.ImportRetries {
+			uploadJob.RetryOnFailure()
+		}
+
+		err := h.Import(ctx, uploadJob, event, "", attemptLogData)
+		if errors.Is(err, ErrNotReported) && !errors.Is(err, ErrInterrupted) {
+			return h.reportAgain(ctx, waitCtx, uploadJob, err, attempt, backoff)
+		}
+		if !uploadJob.WillRetry(err) {
+			return err
+		}
+		importRetriesTotal.WithLabelValues(string(CategoryOf(err))).Inc()
+		select {
+		case <-time.After(backoff):
+		case <-waitCtx.Done():
+			return fmt.Errorf("%w before retrying import: %w", ErrInterrupted, err)
+		}
+		backoff *= 2
+	}
+}
+
+// reportAgain sends the outcome of a finished import that the interactives api could not be told again, rather
+// than importing it again. With a pending report store it uses the retries the import has left before the report
+// is kept there, without one it holds the event until the report is sent or shutdown interrupts it.
+func (h *InteractivesUploadedHandler) reportAgain(ctx, waitCtx context.Context, uploadJob *Job, err error, attempt int, backoff time.Duration) error {
+	for ; errors.Is(err, ErrNotReported) && (attempt < h.Cfg.ImportRetries || h.PendingReports == nil); attempt++ {
+		select {
+		case <-time.After(backoff):
+		case <-waitCtx.Done():
+			if h.PendingReports != nil {
+				return err
+			}
+			return fmt.Errorf("%w before reporting again: %w", ErrInterrupted, err)
+		}
+		err = uploadJob.ReportAgain(ctx)
+		if backoff *= 2; backoff > maxReportBackoff {
+			backoff = maxReportBackoff
+		}
+	}
+	return err
+}
 
+// NewJob creates the job for an event and adds it to the registry
+func (h *InteractivesUploadedHandler) NewJob(ctx context.Context, event *InteractivesUploaded) *Job {
 	uploadJob := NewJob(ctx, h.Cfg, h.InteractivesAPIClient)
-	defer uploadJob.Finish(&logData, event, uploadRootPath, &zipSize, &err) // defer finish() so we always attempt!
+	uploadJob.Start(event)
+	if h.Registry != nil {
+		h.Registry.Add(uploadJob)
+	}
+	return uploadJob
+}
+
+// Import validates and uploads the archive of an event, then reports the outcome to the interactives api.
+// The archive is downloaded from S3 unless the path of a local copy is given, which the caller removes.
+func (h *InteractivesUploadedHandler) Import(ctx context.Context, uploadJob *Job, event *InteractivesUploaded, archive string, logData log.Data) (err error) {
+	var zipSize int64
+	var uploadRootPath string
+
+	// the job keeps the context it was created with, so an interrupted import can still report and clean up
+	ctx, done := h.track(ctx)
+	defer done()
+	stopProgress := func() {}
+	defer func() {
+		if err != nil && errors.Is(context.Cause(ctx), ErrInterrupted) {
+			err = ErrInterrupted
+		}
+		uploadJob.Finish(&logData, event, uploadRootPath, &zipSize, &err) // defer finish() so we always attempt!
+		// after finishing, so the last progress published is the outcome
+		stopProgress()
+	}()
+	ctx = trace.ContextWithSpan(ctx, uploadJob.span)
+	if err = ctx.Err(); err != nil {
+		return err
+	}
 
 	logData["id"] = event.ID
 	logData["path"] = event.Path
 	logData["title"] = event.Title
 	logData["collection_id"] = event.CollectionID
-
-	log.Info(ctx, "download zip file from s3", logData)
-	readCloser, size, err := h.S3.Get(event.Path)
-	if err != nil {
-		log.Error(ctx, "cannot get zip from s3", err, logData)
+	logData["version"] = event.Version
+	logData["job_id"] = uploadJob.ID()
+	if err = h.waitForBreakers(ctx, logData); err != nil {
 		return err
 	}
-	zipSize = *size
-	tmpZip, err := os.CreateTemp("", "s3-zip_*.zip")
-	if err != nil {
-		return err
+	ctx, cancel := withTimeout(ctx, h.Cfg.ImportTimeout)
+	defer cancel()
+	stopProgress = h.reportProgress(uploadJob.ctx, uploadJob, event)
+
+	if archive == "" {
+		log.Info(ctx, "download zip file from s3", logData)
+		uploadJob.SetStage(StageDownload)
+		downloadCtx, cancel := withTimeout(ctx, h.Cfg.DownloadTimeout)
+		archive, zipSize, err = h.download(downloadCtx, event)
+		err = h.stageTimeout(ctx, downloadCtx, StageDownload, h.Cfg.DownloadTimeout, err)
+		cancel()
+		if err != nil {
+			log.Error(ctx, "cannot get zip from s3", err, logData)
+			return err
+		}
+		defer os.Remove(archive)
+	} else {
+		info, err := os.Stat(archive)
+		if err != nil {
+			return err
+		}
+		zipSize = info.Size()
 	}
-	if _, err = io.Copy(tmpZip, readCloser); err != nil {
+	logData["zip_size"] = zipSize
+	archiveSize.Observe(float64(zipSize))
+	tempDiskUsage.Add(float64(zipSize))
+	defer tempDiskUsage.Sub(float64(zipSize))
+
+	// an earlier attempt at the same archive may have left a checkpoint of the files it uploaded
+	var checksum string
+	var resumed *Checkpoint
+	if h.Checkpoints != nil {
+		if checksum, err = fileHash(archive); err != nil {
+			return archiveError(fmt.Errorf("cannot hash archive %w", err))
+		}
+		resumed = h.resumeCheckpoint(ctx, event, checksum)
+	}
+
+	// an update goes to a new root, copying unchanged files from the live root of the previous import, which
+	// stays as it is until the interactive is switched over on success
+	previous := h.previousManifest(ctx, event)
+	if previous != nil {
+		logData["previous_import"] = previous.CreatedAt
+	}
+	if resumed != nil {
+		uploadRootPath = resumed.UploadRootPath
+	} else if uploadRootPath, err = UploadRootPath(h.Cfg.UploadRootStrategy, event, archive); err != nil {
+		log.Error(ctx, "cannot determine upload root", err, logData)
 		return err
 	}
-	tmpZip.Close()
-	defer os.Remove(tmpZip.Name())
-	logData["zip_size"] = zipSize
+	// a root derived from the archive is the previous root when the archive has not changed
+	carryOver := previous != nil && previous.UploadRootPath != uploadRootPath
+	if resumed != nil {
+		logData["resumed_files"] = len(resumed.Files)
+		importsResumedTotal.Inc()
+	}
+	logData["upload_root"] = uploadRootPath
+	uploadJob.SetArchive(uploadRootPath, zipSize)
+	manifest := NewManifest(event.ID, uploadRootPath)
+
+	if h.imported(ctx, uploadRootPath) {
+		log.Info(ctx, "archive already imported to upload root, nothing to upload", logData)
+		return nil
+	}
 
 	log.Info(ctx, "validate zip", logData)
+	uploadJob.SetStage(StageValidate)
+	var validated uint64
 	counterFunc := func(count uint64, mimetype string, zip *zip.File) error {
 		if count%1000 == 0 {
 			log.Info(ctx, "processed 1000 files", logData)
 		}
+		atomic.AddUint64(&validated, 1)
 		return nil
 	}
-	err = Process(h.Cfg.BatchSize, tmpZip.Name(), counterFunc)
+	validateCtx, cancel := withTimeout(ctx, h.Cfg.ValidateTimeout)
+	validateCtx, span := tracer.Start(validateCtx, "validate")
+	err = Process(validateCtx, h.Cfg.BatchSize, archive, counterFunc)
+	err = h.stageTimeout(ctx, validateCtx, StageValidate, h.Cfg.ValidateTimeout, err)
+	endSpan(span, err)
+	cancel()
 	if err != nil {
 		log.Error(ctx, "cannot validate zip", err, logData)
 		return err
 	}
+	uploadJob.SetFilesTotal(validated)
+	archiveFiles.Observe(float64(validated))
+	h.reportIgnored(ctx, uploadJob, archive)
 
 	// Upload each file in zip
 	log.Info(ctx, "start upload of zip files", logData)
+	uploadJob.SetStage(StageUpload)
+	if (previous == nil || carryOver) && h.newRoot(ctx, uploadRootPath) {
+		// never clean up the root of a previous import, it is still live
+		uploadJob.TrackUploadRoot(h.UploadService, h.UploadRoots, event.ID, uploadRootPath)
+	}
+	checkpoint := resumed
+	if h.Checkpoints != nil && checkpoint == nil {
+		checkpoint = h.createCheckpoint(ctx, event, checksum, uploadRootPath)
+	}
+	if checkpoint != nil {
+		uploadJob.KeepUploadRootForRetry()
+		defer func() {
+			h.finishCheckpoint(ctx, checkpoint, err != nil && (uploadJob.WillRetry(err) || errors.Is(context.Cause(ctx), ErrInterrupted)))
+		}()
+	}
+	var deduplicated uint64
+	stored := newStoredFiles()
+	uploadCtx, span := tracer.Start(ctx, "upload")
 	uploadFunc := func(count uint64, mimetype string, zip *zip.File) error {
+		if err := uploadCtx.Err(); err != nil {
+			return err
+		}
 		if count%1000 == 0 {
 			log.Info(ctx, "processed 1000 files", logData)
 		}
 
-		rc, err := zip.Open()
-		if err != nil {
-			return err
+		// the checksum of the archive is unchanged, so neither is the hash of a file uploaded by an earlier attempt.
+		// Otherwise the file is hashed up front only if that decides whether to upload it, or else as it uploads.
+		entry, uploaded := resumed.Uploaded(zip.Name)
+		hash := entry.Hash
+		if !uploaded && (h.ContentIndex != nil || previous != nil) {
+			var err error
+			if hash, err = Hash(zip); err != nil {
+				return archiveError(err)
+			}
 		}
-		defer rc.Close()
 
 		file := &File{
 			Context:     ctx,
 			Name:        zip.Name,
-			ReadCloser:  rc,
 			SizeInBytes: int64(zip.UncompressedSize64),
 			MimeType:    mimetype,
+			Hash:        hash,
 		}
 
-		_, err = h.UploadService.SendFile(ctx, event, file, uploadRootPath)
-		return err
+		if uploaded {
+			manifest.Add(file)
+			stored.add(hash, entry.Path)
+			uploadJob.FileProcessed(0)
+			uploadJob.report.fileProcessed(false)
+			return nil
+		}
+
+		if previous.Unchanged(zip.Name, hash) {
+			path := previous.UploadRootPath + "/" + zip.Name
+			var err error
+			if carryOver {
+				path, err = h.UploadService.CopyFile(uploadCtx, event, file, path, uploadRootPath)
+			}
+			if err == nil {
+				manifest.Add(file)
+				stored.add(hash, path)
+				h.checkpointFile(ctx, checkpoint, CheckpointEntry{Name: zip.Name, Hash: hash, Path: path})
+				uploadJob.FileProcessed(0)
+				uploadJob.report.fileProcessed(false)
+				return nil
+			}
+			log.Warn(ctx, "cannot copy unchanged file from previous import, uploading it instead", log.Data{"id": event.ID, "file": zip.Name, "previous_root": previous.UploadRootPath, "error": err.Error()})
+		}
+
+		if h.ContentIndex != nil {
+			existing, found, err := h.ContentIndex.Get(ctx, hash)
+			if err != nil {
+				return categorise(CategoryStorageUnavailable, err)
+			}
+			if found {
+				// every import serves its files from its own root, so the stored copy is copied rather than uploaded
+				path, err := h.UploadService.CopyFile(uploadCtx, event, file, existing, uploadRootPath)
+				if err == nil {
+					atomic.AddUint64(&deduplicated, 1)
+					manifest.Add(file)
+					stored.add(hash, path)
+					h.checkpointFile(ctx, checkpoint, CheckpointEntry{Name: zip.Name, Hash: hash, Path: path})
+					uploadJob.FileProcessed(0)
+					uploadJob.report.fileProcessed(false)
+					return nil
+				}
+				log.Warn(ctx, "cannot copy file already in storage, uploading it instead", log.Data{"id": event.ID, "file": zip.Name, "hash": hash, "existing": existing, "error": err.Error()})
+			}
+		}
+
+		path, err := h.sendFile(uploadCtx, event, zip, file, uploadRootPath)
+		if err != nil {
+			return err
+		}
+		manifest.Add(file)
+		stored.add(file.Hash, path)
+		h.checkpointFile(ctx, checkpoint, CheckpointEntry{Name: zip.Name, Hash: file.Hash, Path: path})
+		uploadJob.FileProcessed(file.SizeInBytes)
+		uploadJob.report.fileProcessed(true)
+		return nil
 	}
-	err = Process(h.Cfg.BatchSize, tmpZip.Name(), uploadFunc)
+	err = Process(uploadCtx, h.Cfg.BatchSize, archive, uploadFunc)
+	err = h.stageTimeout(ctx, uploadCtx, StageUpload, 0, err)
+	span.SetAttributes(attribute.Int64("files.deduplicated", int64(deduplicated)))
+	endSpan(span, err)
 	if err != nil {
 		log.Error(ctx, "cannot process zip", err, logData)
 		return err
 	}
+	logData["deduplicated"] = deduplicated
+
+	// only index files of a successful import, a failed import may never be referenced
+	if h.ContentIndex != nil {
+		for hash, path := range stored.paths {
+			if err := h.ContentIndex.Put(ctx, hash, path); err != nil {
+				log.Warn(ctx, "failed to index stored file", log.Data{"id": event.ID, "hash": hash, "path": path, "error": err.Error()})
+			}
+		}
+	}
+
+	if h.Manifests != nil {
+		diff := manifest.Diff(previous)
+		logData["changes"] = diff.String()
+		if len(diff.Removed) > 0 {
+			logData["removed"] = diff.Removed
+		}
+		uploadJob.SetImportMessage(diff.String())
+		if err := h.Manifests.Put(ctx, manifest); err != nil {
+			log.Warn(ctx, "failed to store import manifest, next import will upload every file", log.Data{"id": event.ID, "error": err.Error()})
+		}
+	}
 
 	log.Info(ctx, "successfully processed", logData)
 
 	return nil
 }
 
-// getAsEvent unmarshals the provided kafka message into an event and calls the handler.
-func getAsEvent(ctx context.Context, message kafka.Message) (*InteractivesUploaded, error) {
-	defer message.Commit()
+// sendFile uploads a file of the archive, retrying failures whose category says they may not happen again
+func (h *InteractivesUploadedHandler) sendFile(ctx context.Context, event *InteractivesUploaded, zip *zip.File, file *File, uploadRootPath string) (string, error) {
+	backoff := h.Cfg.FileUploadRetryBackoff
+	for attempt := 0; ; attempt++ {
+		path, err := h.sendFileOnce(ctx, event, zip, file, uploadRootPath)
+		if err == nil || attempt >= h.Cfg.FileUploadRetries || !Retryable(err) {
+			return path, err
+		}
+
+		category := CategoryOf(err)
+		fileUploadRetriesTotal.WithLabelValues(string(category)).Inc()
+		log.Warn(ctx, "retrying file upload", log.Data{"id": event.ID, "file": zip.Name, "attempt": attempt + 1, "category": category, "error": err.Error()})
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return "", err
+		}
+		backoff *= 2
+	}
+}
+
+// sendFileOnce reads the file from the archive and uploads it within the file upload timeout, setting its hash
+// from the content read if it has none yet
+func (h *InteractivesUploadedHandler) sendFileOnce(ctx context.Context, event *InteractivesUploaded, zip *zip.File, file *File, uploadRootPath string) (string, error) {
+	rc, err := zip.Open()
+	if err != nil {
+		return "", archiveError(err)
+	}
+	defer rc.Close()
+	file.ReadCloser = rc
+	var hasher hash.Hash
+	if file.Hash == "" {
+		hasher = sha256.New()
+		file.ReadCloser = struct {
+			io.Reader
+			io.Closer
+		}{io.TeeReader(rc, hasher), rc}
+	}
+
+	if h.Uploads != nil {
+		if err = h.Uploads.Acquire(ctx); err != nil {
+			return "", err
+		}
+	}
+	fileCtx, cancel := withTimeout(ctx, h.Cfg.FileUploadTimeout)
+	defer cancel()
+	start := time.Now()
+	path, err := h.UploadService.SendFile(fileCtx, event, file, uploadRootPath)
+	if h.Uploads != nil {
+		h.Uploads.Release(time.Since(start), err)
+	}
+	if err != nil {
+		if ctx.Err() == nil && errors.Is(fileCtx.Err(), context.DeadlineExceeded) {
+			return "", &TimeoutError{Stage: StageUpload, File: zip.Name, Timeout: h.Cfg.FileUploadTimeout, Err: err}
+		}
+		return "", uploadError(err)
+	}
+	if hasher != nil {
+		// hash whatever the upload left unread
+		if _, err = io.Copy(hasher, rc); err != nil {
+			return "", archiveError(err)
+		}
+		file.Hash = hex.EncodeToString(hasher.Sum(nil))
+	}
+	return path, nil
+}
+
+// waitForBreakers pauses an import while a service it depends on is failing, rather than failing it. An import
+// still waiting after the maximum wait fails as storage_unavailable, so it is retried or reported.
+func (h *InteractivesUploadedHandler) waitForBreakers(ctx context.Context, logData log.Data) error {
+	waitCtx, cancel := withTimeout(ctx, h.Cfg.CircuitBreakerMaxWait)
+	defer cancel()
+	for _, breaker := range h.Breakers {
+		if breaker.Ready() {
+			continue
+		}
+		log.Warn(ctx, "import paused until circuit breaker closes", log.Data{"breaker": breaker.Name, "id": logData["id"], "job_id": logData["job_id"]})
+		err := breaker.Wait(waitCtx)
+		if err != nil && ctx.Err() == nil {
+			return &ImportError{
+				Category: CategoryStorageUnavailable,
+				Err:      fmt.Errorf("%w: %s not closed after %s", ErrCircuitOpen, breaker.Name, h.Cfg.CircuitBreakerMaxWait),
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// track counts the import as running until done is called. Its context is cancelled with ErrInterrupted
+// if shutdown gives up waiting, or straight away if shutdown has already begun.
+func (h *InteractivesUploadedHandler) track(ctx context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(ctx)
 
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closing {
+		cancel(ErrInterrupted)
+		return ctx, func() {}
+	}
+	if h.interrupt == nil {
+		h.interrupt = make(chan struct{})
+	}
+	interrupt := h.interrupt
+	h.running.Add(1)
+
+	go func() {
+		select {
+		case <-interrupt:
+			cancel(ErrInterrupted)
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, func() {
+		cancel(nil)
+		h.running.Done()
+	}
+}
+
+// Shutdown stops new imports from starting and waits for running imports to finish. If the context is done first
+// the remaining imports are interrupted, and Shutdown returns the context's error once they have reported or the
+// interrupt timeout has passed, whichever is first.
+func (h *InteractivesUploadedHandler) Shutdown(ctx context.Context) error {
+	h.mu.Lock()
+	h.closing = true
+	if h.interrupt == nil {
+		h.interrupt = make(chan struct{})
+	}
+	h.mu.Unlock()
+
+	finished := make(chan struct{})
+	go func() {
+		h.running.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		h.mu.Lock()
+		if !h.interrupted {
+			h.interrupted = true
+			close(h.interrupt)
+		}
+		h.mu.Unlock()
+
+		if h.Cfg.ShutdownInterruptTimeout <= 0 {
+			<-finished
+			return ctx.Err()
+		}
+		timer := time.NewTimer(h.Cfg.ShutdownInterruptTimeout)
+		defer timer.Stop()
+		select {
+		case <-finished:
+		case <-timer.C:
+			log.Warn(ctx, "interrupted imports did not report in time", log.Data{"shutdown_interrupt_timeout": h.Cfg.ShutdownInterruptTimeout})
+		}
+		return ctx.Err()
+	}
+}
+
+// download copies the archive from S3 to a temporary file in the configured temp directory
+func (h *InteractivesUploadedHandler) download(ctx context.Context, event *InteractivesUploaded) (_ string, _ int64, err error) {
+	_, span := tracer.Start(ctx, "s3 get", trace.WithAttributes(attribute.String("s3.key", event.Path)))
+	defer func() { endSpan(span, err) }()
+
+	readCloser, size, err := h.get(ctx, event.Path)
+	if err != nil {
+		return "", 0, s3Error(err)
+	}
+	defer readCloser.Close()
+	span.SetAttributes(attribute.Int64("s3.size", *size))
+
+	// fail before writing anything rather than fill the disk part way through
+	tmpZip, release, err := CreateTempArchive(h.Cfg.TempDir, *size, h.Cfg.TempDirMinFreeBytes)
+	if err != nil {
+		return "", 0, categorise(CategoryStorageUnavailable, err)
+	}
+	defer release()
+	defer tmpZip.Close()
+
+	// closing the body unblocks the copy if the context is done part way through
+	copied := make(chan struct{})
+	defer close(copied)
+	go func() {
+		select {
+		case <-ctx.Done():
+			readCloser.Close()
+		case <-copied:
+		}
+	}()
+
+	if _, err = io.Copy(tmpZip, readCloser); err != nil {
+		os.Remove(tmpZip.Name())
+		if ctx.Err() != nil {
+			return "", 0, ctx.Err()
+		}
+		return "", 0, s3Error(err)
+	}
+	return tmpZip.Name(), *size, nil
+}
+
+// get calls S3Interface.Get, which takes no context, and gives up waiting on it once the context is done
+func (h *InteractivesUploadedHandler) get(ctx context.Context, key string) (io.ReadCloser, *int64, error) {
+	type result struct {
+		readCloser io.ReadCloser
+		size       *int64
+		err        error
+	}
+	got := make(chan result, 1)
+	go func() {
+		readCloser, size, err := h.S3.Get(key)
+		got <- result{readCloser, size, err}
+	}()
+
+	select {
+	case r := <-got:
+		return r.readCloser, r.size, r.err
+	case <-ctx.Done():
+		go func() {
+			if r := <-got; r.readCloser != nil {
+				r.readCloser.Close()
+			}
+		}()
+		return nil, nil, ctx.Err()
+	}
+}
+
+// reportIgnored warns about the files of the archive that were left out of the import
+func (h *InteractivesUploadedHandler) reportIgnored(ctx context.Context, uploadJob *Job, archive string) {
+	ignored, err := Ignored(archive)
+	if err != nil {
+		log.Warn(ctx, "cannot list ignored files", log.Data{"archive": archive, "error": err.Error()})
+		return
+	}
+	for _, name := range ignored {
+		uploadJob.Report().AddWarning(ReportEntry{Code: CodeIgnoredFile, File: name, Stage: StageValidate, Message: "hidden or system file not imported"})
+	}
+}
+
+// previousManifest returns the manifest of the last successful import of the interactive, if any
+func (h *InteractivesUploadedHandler) previousManifest(ctx context.Context, event *InteractivesUploaded) *Manifest {
+	if h.Manifests == nil {
+		return nil
+	}
+
+	previous, err := h.Manifests.Get(ctx, event.ID)
+	if err != nil {
+		// fall back to a full import rather than fail
+		log.Warn(ctx, "cannot read previous import manifest", log.Data{"id": event.ID, "error": err.Error()})
+		return nil
+	}
+	return previous
+}
+
+// imported is true if a previous import has already successfully uploaded to the root,
+// which happens when the root is derived from the event or archive rather than random
+func (h *InteractivesUploadedHandler) imported(ctx context.Context, uploadRootPath string) bool {
+	if h.UploadRoots == nil {
+		return false
+	}
+
+	root, err := h.UploadRoots.Get(ctx, uploadRootPath)
+	if err != nil {
+		log.Warn(ctx, "cannot read upload root", log.Data{"path": uploadRootPath, "error": err.Error()})
+		return false
+	}
+	return root != nil && root.Status == RootStatusComplete
+}
+
+// newRoot is true if the root is not one a previous import may have published. A random root always is, a root
+// derived from the event or archive only when the recorded roots show it has never been imported successfully.
+func (h *InteractivesUploadedHandler) newRoot(ctx context.Context, uploadRootPath string) bool {
+	switch h.Cfg.UploadRootStrategy {
+	case RootStrategyRandom, "":
+		return true
+	}
+	if h.UploadRoots == nil {
+		return false
+	}
+
+	root, err := h.UploadRoots.Get(ctx, uploadRootPath)
+	if err != nil {
+		log.Warn(ctx, "cannot read upload root", log.Data{"path": uploadRootPath, "error": err.Error()})
+		return false
+	}
+	return root == nil || root.Status != RootStatusComplete
+}
+
+// getAsEvent unmarshals the provided kafka message into an event. The consumer commits the message once
+// the handler returns, unless the handler returns an error asking for it to be redelivered.
+func getAsEvent(ctx context.Context, message kafka.Message) (*InteractivesUploaded, error) {
 	logData := log.Data{"message_offset": message.Offset()}
 
 	var event InteractivesUploaded
 	err := schema.InteractivesUploadedEvent.Unmarshal(message.GetData(), &event)
 	if err != nil {
-		log.Error(ctx, "failed to unmarshal event", err, logData)
-		return nil, err
+		// events sent before the version was added end early
+		var legacy legacyInteractivesUploaded
+		if legacyErr := schema.LegacyInteractivesUploadedEvent.Unmarshal(message.GetData(), &legacy); legacyErr != nil {
+			log.Error(ctx, "failed to unmarshal event", err, logData)
+			return nil, err
+		}
+		event = InteractivesUploaded{ID: legacy.ID, Path: legacy.Path, Title: legacy.Title, CollectionID: legacy.CollectionID}
 	}
 
 	logData["event"] = event
@@ -128,3 +761,22 @@ func getAsEvent(ctx context.Context, message kafka.Message) (*InteractivesUpload
 
 	return &event, nil
 }
+
+// storedFiles collects the hash and path of each file uploaded during an import
+type storedFiles struct {
+	mu    sync.Mutex
+	paths map[string]string
+}
+
+func newStoredFiles() *storedFiles {
+	return &storedFiles{paths: make(map[string]string)}
+}
+
+func (s *storedFiles) add(hash, path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, found := s.paths[hash]; !found {
+		s.paths[hash] = path
+	}
+}
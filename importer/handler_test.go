@@ -0,0 +1,1065 @@
+package importer_test
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	dperrors "github.com/ONSdigital/dp-api-clients-go/v2/errors"
+	"github.com/ONSdigital/dp-api-clients-go/v2/interactives"
+	"github.com/ONSdigital/dp-api-clients-go/v2/upload"
+	"github.com/ONSdigital/dp-interactives-importer/config"
+	"github.com/ONSdigital/dp-interactives-importer/importer"
+	mocks_importer "github.com/ONSdigital/dp-interactives-importer/importer/mocks"
+	"github.com/ONSdigital/dp-interactives-importer/internal/test"
+	"github.com/ONSdigital/dp-interactives-importer/schema"
+	"github.com/ONSdigital/dp-interactives-importer/storage"
+	"github.com/ONSdigital/dp-kafka/v3/kafkatest"
+	"github.com/ONSdigital/log.go/v2/log"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestHandlerShutdown(t *testing.T) {
+
+	Convey("Given a handler importing an archive", t, func() {
+		archive, err := test.CreateTestZip("index.html")
+		So(err, ShouldBeNil)
+		defer os.Remove(archive)
+
+		data, err := schema.InteractivesUploadedEvent.Marshal(&importer.InteractivesUploaded{ID: "1", Path: "archive.zip"})
+		So(err, ShouldBeNil)
+		msg, err := kafkatest.NewMessage(data, 0)
+		So(err, ShouldBeNil)
+
+		uploading := make(chan struct{}, 1)
+		release := make(chan struct{})
+		backend := &mocks_importer.UploadServiceBackendMock{
+			UploadFunc: func(ctx context.Context, _ io.ReadCloser, _ upload.Metadata) error {
+				uploading <- struct{}{}
+				select {
+				case <-release:
+					return nil
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			},
+		}
+		var patched []interactives.PatchRequest
+		mockInteractivesAPI := &mocks_importer.InteractivesAPIClientMock{
+			PatchInteractiveFunc: func(_ context.Context, _, _, _ string, req interactives.PatchRequest) (interactives.Interactive, error) {
+				patched = append(patched, req)
+				return interactives.Interactive{}, nil
+			},
+		}
+		mockS3 := &mocks_importer.S3InterfaceMock{
+			GetFunc: func(string) (io.ReadCloser, *int64, error) {
+				f, err := os.Open(archive)
+				if err != nil {
+					return nil, nil, err
+				}
+				info, _ := f.Stat()
+				size := info.Size()
+				return f, &size, nil
+			},
+		}
+		handler := &importer.InteractivesUploadedHandler{
+			Cfg:                   &config.Config{BatchSize: 1, UploadRootStrategy: importer.RootStrategyRandom},
+			S3:                    mockS3,
+			UploadService:         importer.NewUploadService(backend),
+			InteractivesAPIClient: mockInteractivesAPI,
+		}
+
+		handled := make(chan error, 1)
+		go func() {
+			handled <- handler.Handle(context.Background(), 1, msg)
+		}()
+		<-uploading
+
+		Convey("When it shuts down and the import finishes within the drain timeout", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			go func() {
+				time.Sleep(10 * time.Millisecond)
+				close(release)
+			}()
+			err := handler.Shutdown(ctx)
+
+			Convey("Then the import should complete", func() {
+				So(err, ShouldBeNil)
+				So(<-handled, ShouldBeNil)
+				So(patched, ShouldHaveLength, 1)
+				So(patched[0].Interactive.Archive.ImportSuccessful, ShouldBeTrue)
+			})
+		})
+
+		Convey("When it shuts down and the import outlasts the drain timeout", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			err := handler.Shutdown(ctx)
+
+			Convey("Then the import should be interrupted", func() {
+				So(errors.Is(err, context.DeadlineExceeded), ShouldBeTrue)
+				handleErr := <-handled
+				So(errors.Is(handleErr, importer.ErrInterrupted), ShouldBeTrue)
+				So(patched, ShouldHaveLength, 1)
+				So(patched[0].Interactive.Archive.ImportSuccessful, ShouldBeFalse)
+				report := decodeReport(patched[0].Interactive.Archive.ImportMessage)
+				So(report.Category, ShouldEqual, importer.CategoryInterrupted)
+				So(report.Errors[0].Message, ShouldEqual, "interrupted")
+				So(report.Errors[0].Code, ShouldEqual, importer.CodeInterrupted)
+			})
+
+			Convey("And the event should not be committed, so it is redelivered", func() {
+				commiter, ok := (<-handled).(interface{ Commit() bool })
+				So(ok, ShouldBeTrue)
+				So(commiter.Commit(), ShouldBeFalse)
+				So(msg.IsCommitted(), ShouldBeFalse)
+			})
+
+			Convey("And a later event should not be imported", func() {
+				<-handled
+				err := handler.Handle(context.Background(), 1, msg)
+				So(errors.Is(err, importer.ErrInterrupted), ShouldBeTrue)
+				So(mockS3.GetCalls(), ShouldHaveLength, 1)
+			})
+		})
+
+		Convey("When it shuts down and the interrupted import cannot report within the interrupt timeout", func() {
+			handler.Cfg.ShutdownInterruptTimeout = 20 * time.Millisecond
+			reported := make(chan struct{})
+			mockInteractivesAPI.PatchInteractiveFunc = func(context.Context, string, string, string, interactives.PatchRequest) (interactives.Interactive, error) {
+				<-reported
+				return interactives.Interactive{}, nil
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			start := time.Now()
+			err := handler.Shutdown(ctx)
+
+			Convey("Then shutdown should stop waiting for it", func() {
+				So(errors.Is(err, context.DeadlineExceeded), ShouldBeTrue)
+				So(time.Since(start), ShouldBeLessThan, time.Second)
+				close(reported)
+				So(errors.Is(<-handled, importer.ErrInterrupted), ShouldBeTrue)
+			})
+		})
+	})
+}
+
+func TestHandlerRetries(t *testing.T) {
+
+	Convey("Given a handler whose download of an archive fails for a reason that may not happen again", t, func() {
+		archive, err := test.CreateTestZip("index.html")
+		So(err, ShouldBeNil)
+		defer os.Remove(archive)
+
+		data, err := schema.InteractivesUploadedEvent.Marshal(&importer.InteractivesUploaded{ID: "1", Path: "archive.zip"})
+		So(err, ShouldBeNil)
+		msg, err := kafkatest.NewMessage(data, 0)
+		So(err, ShouldBeNil)
+
+		failures := 1
+		downloads := make(chan struct{}, 10)
+		mockS3 := &mocks_importer.S3InterfaceMock{
+			GetFunc: func(string) (io.ReadCloser, *int64, error) {
+				downloads <- struct{}{}
+				if len(downloads) <= failures {
+					return nil, nil, errors.New("s3 unavailable")
+				}
+				f, err := os.Open(archive)
+				if err != nil {
+					return nil, nil, err
+				}
+				info, _ := f.Stat()
+				size := info.Size()
+				return f, &size, nil
+			},
+		}
+		var patched []interactives.PatchRequest
+		handler := &importer.InteractivesUploadedHandler{
+			Cfg: &config.Config{BatchSize: 1, UploadRootStrategy: importer.RootStrategyRandom, ImportRetries: 2, ImportRetryBackoff: time.Millisecond},
+			S3:  mockS3,
+			UploadService: importer.NewUploadService(&mocks_importer.UploadServiceBackendMock{
+				UploadFunc: func(context.Context, io.ReadCloser, upload.Metadata) error { return nil },
+			}),
+			InteractivesAPIClient: &mocks_importer.InteractivesAPIClientMock{
+				PatchInteractiveFunc: func(_ context.Context, _, _, _ string, req interactives.PatchRequest) (interactives.Interactive, error) {
+					patched = append(patched, req)
+					return interactives.Interactive{}, nil
+				},
+			},
+		}
+
+		Convey("When it succeeds on a retry", func() {
+			err := handler.Handle(context.Background(), 1, msg)
+
+			Convey("Then only the successful attempt should be reported", func() {
+				So(err, ShouldBeNil)
+				So(mockS3.GetCalls(), ShouldHaveLength, 2)
+				So(patched, ShouldHaveLength, 1)
+				So(patched[0].Interactive.Archive.ImportSuccessful, ShouldBeTrue)
+			})
+		})
+
+		Convey("When it fails on every attempt", func() {
+			failures = 10
+			err := handler.Handle(context.Background(), 1, msg)
+
+			Convey("Then it should give up after the retries and report the failure once", func() {
+				So(errors.Is(err, importer.ErrStorageUnavailable), ShouldBeTrue)
+				So(mockS3.GetCalls(), ShouldHaveLength, 3)
+				So(patched, ShouldHaveLength, 1)
+				So(patched[0].Interactive.Archive.ImportSuccessful, ShouldBeFalse)
+				So(decodeReport(patched[0].Interactive.Archive.ImportMessage).Category, ShouldEqual, importer.CategoryStorageUnavailable)
+			})
+
+			Convey("And the event should be committed", func() {
+				_, redelivered := err.(interface{ Commit() bool })
+				So(redelivered, ShouldBeFalse)
+			})
+		})
+
+		Convey("When it succeeds but the interactives api cannot be told", func() {
+			unavailable := true
+			handler.InteractivesAPIClient = &mocks_importer.InteractivesAPIClientMock{
+				PatchInteractiveFunc: func(_ context.Context, _, _, _ string, req interactives.PatchRequest) (interactives.Interactive, error) {
+					patched = append(patched, req)
+					if unavailable {
+						return interactives.Interactive{}, &importer.StatusError{StatusCode: http.StatusServiceUnavailable, Err: dperrors.NewErrorFromUnhandledStatusCode("interactives-api", http.StatusServiceUnavailable)}
+					}
+					return interactives.Interactive{}, nil
+				},
+			}
+			pendingReports, err := importer.NewFilePendingReportStore(t.TempDir())
+			So(err, ShouldBeNil)
+			handler.PendingReports = pendingReports
+			err = handler.Handle(context.Background(), 1, msg)
+
+			Convey("Then only the report should be sent again, with the retries the import had left", func() {
+				So(mockS3.GetCalls(), ShouldHaveLength, 2)
+				So(patched, ShouldHaveLength, 2)
+				So(patched[1], ShouldResemble, patched[0])
+				So(patched[1].Interactive.Archive.ImportSuccessful, ShouldBeTrue)
+				So(importer.Retryable(err), ShouldBeFalse)
+			})
+
+			Convey("Then the outcome should be kept to report later, and the event committed", func() {
+				So(errors.Is(err, importer.ErrNotReported), ShouldBeTrue)
+				_, redelivered := err.(interface{ Commit() bool })
+				So(redelivered, ShouldBeFalse)
+				report, err := pendingReports.Get(context.Background(), "1")
+				So(err, ShouldBeNil)
+				So(report, ShouldNotBeNil)
+				So(report.Request, ShouldResemble, patched[0])
+			})
+
+			Convey("And the next event on the partition is imported once the api is back", func() {
+				unavailable = false
+				data, err := schema.InteractivesUploadedEvent.Marshal(&importer.InteractivesUploaded{ID: "2", Path: "archive.zip"})
+				So(err, ShouldBeNil)
+				next, err := kafkatest.NewMessage(data, 1)
+				So(err, ShouldBeNil)
+				So(handler.Handle(context.Background(), 1, next), ShouldBeNil)
+
+				Convey("Then the outcome of the first event should still be sent, though the next one commits past it", func() {
+					reports, err := pendingReports.List(context.Background())
+					So(err, ShouldBeNil)
+					So(reports, ShouldHaveLength, 1)
+
+					sender := &importer.ReportSender{Reports: pendingReports, InteractivesAPIClient: handler.InteractivesAPIClient}
+					So(sender.Run(context.Background()), ShouldBeNil)
+					So(patched[len(patched)-1], ShouldResemble, patched[0])
+					reports, err = pendingReports.List(context.Background())
+					So(err, ShouldBeNil)
+					So(reports, ShouldBeEmpty)
+				})
+			})
+
+			Convey("And the interactive is imported again and reported", func() {
+				unavailable = false
+				So(handler.Handle(context.Background(), 1, msg), ShouldBeNil)
+
+				Convey("Then the earlier outcome should no longer be pending", func() {
+					report, err := pendingReports.Get(context.Background(), "1")
+					So(err, ShouldBeNil)
+					So(report, ShouldBeNil)
+				})
+			})
+		})
+
+		Convey("When it succeeds but the interactives api cannot be told, without a pending report store", func() {
+			reports := make(chan struct{}, 100)
+			handler.InteractivesAPIClient = &mocks_importer.InteractivesAPIClientMock{
+				PatchInteractiveFunc: func(context.Context, string, string, string, interactives.PatchRequest) (interactives.Interactive, error) {
+					reports <- struct{}{}
+					return interactives.Interactive{}, &importer.StatusError{StatusCode: http.StatusServiceUnavailable, Err: dperrors.NewErrorFromUnhandledStatusCode("interactives-api", http.StatusServiceUnavailable)}
+				},
+			}
+			handled := make(chan error, 1)
+			go func() {
+				handled <- handler.Handle(context.Background(), 1, msg)
+			}()
+
+			Convey("Then the event should be held, sending the report again, until shutdown interrupts it", func() {
+				for i := 0; i < 4; i++ {
+					<-reports
+				}
+				So(mockS3.GetCalls(), ShouldHaveLength, 2)
+				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+				defer cancel()
+				So(errors.Is(handler.Shutdown(ctx), context.DeadlineExceeded), ShouldBeTrue)
+
+				err := <-handled
+				So(errors.Is(err, importer.ErrInterrupted), ShouldBeTrue)
+				commiter, ok := err.(interface{ Commit() bool })
+				So(ok, ShouldBeTrue)
+				So(commiter.Commit(), ShouldBeFalse)
+			})
+		})
+
+		Convey("When it succeeds and the interactives api can only be told on a retry", func() {
+			handler.InteractivesAPIClient = &mocks_importer.InteractivesAPIClientMock{
+				PatchInteractiveFunc: func(_ context.Context, _, _, _ string, req interactives.PatchRequest) (interactives.Interactive, error) {
+					patched = append(patched, req)
+					if len(patched) == 1 {
+						return interactives.Interactive{}, &importer.TimeoutError{Stage: importer.StagePatch, Err: context.DeadlineExceeded}
+					}
+					return interactives.Interactive{}, nil
+				},
+			}
+			failures = 0
+			err := handler.Handle(context.Background(), 1, msg)
+
+			Convey("Then the import should not be run again", func() {
+				So(err, ShouldBeNil)
+				So(mockS3.GetCalls(), ShouldHaveLength, 1)
+				So(patched, ShouldHaveLength, 2)
+				So(patched[1].Interactive.Archive.ImportSuccessful, ShouldBeTrue)
+			})
+		})
+
+		Convey("When it shuts down while waiting to retry", func() {
+			failures = 10
+			handler.Cfg.ImportRetryBackoff = time.Minute
+			handled := make(chan error, 1)
+			go func() {
+				handled <- handler.Handle(context.Background(), 1, msg)
+			}()
+			<-downloads
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			So(errors.Is(handler.Shutdown(ctx), context.DeadlineExceeded), ShouldBeTrue)
+
+			Convey("Then the event should be redelivered without reporting the failure", func() {
+				err := <-handled
+				So(errors.Is(err, importer.ErrInterrupted), ShouldBeTrue)
+				commiter, ok := err.(interface{ Commit() bool })
+				So(ok, ShouldBeTrue)
+				So(commiter.Commit(), ShouldBeFalse)
+				So(mockS3.GetCalls(), ShouldHaveLength, 1)
+				So(patched, ShouldBeEmpty)
+			})
+		})
+	})
+
+	Convey("Given a handler whose archive is corrupt", t, func() {
+		data, err := schema.InteractivesUploadedEvent.Marshal(&importer.InteractivesUploaded{ID: "1", Path: "archive.zip"})
+		So(err, ShouldBeNil)
+		msg, err := kafkatest.NewMessage(data, 0)
+		So(err, ShouldBeNil)
+		mockS3 := &mocks_importer.S3InterfaceMock{
+			GetFunc: func(string) (io.ReadCloser, *int64, error) {
+				size := int64(len("not a zip"))
+				return io.NopCloser(strings.NewReader("not a zip")), &size, nil
+			},
+		}
+		handler := &importer.InteractivesUploadedHandler{
+			Cfg: &config.Config{BatchSize: 1, UploadRootStrategy: importer.RootStrategyRandom, ImportRetries: 2, ImportRetryBackoff: time.Millisecond},
+			S3:  mockS3,
+			InteractivesAPIClient: &mocks_importer.InteractivesAPIClientMock{
+				PatchInteractiveFunc: func(context.Context, string, string, string, interactives.PatchRequest) (interactives.Interactive, error) {
+					return interactives.Interactive{}, nil
+				},
+			},
+		}
+
+		Convey("When it is handled", func() {
+			err := handler.Handle(context.Background(), 1, msg)
+
+			Convey("Then it should not be retried", func() {
+				So(errors.Is(err, importer.ErrCorruptArchive), ShouldBeTrue)
+				So(mockS3.GetCalls(), ShouldHaveLength, 1)
+			})
+		})
+	})
+}
+
+func TestHandlerDiskSpace(t *testing.T) {
+
+	Convey("Given an archive too large for the temp directory", t, func() {
+		tempDir := t.TempDir()
+		mockS3 := &mocks_importer.S3InterfaceMock{
+			GetFunc: func(string) (io.ReadCloser, *int64, error) {
+				size := int64(math.MaxInt64)
+				return io.NopCloser(strings.NewReader("too large")), &size, nil
+			},
+		}
+		var patched []interactives.PatchRequest
+		handler := &importer.InteractivesUploadedHandler{
+			Cfg: &config.Config{BatchSize: 1, TempDir: tempDir},
+			S3:  mockS3,
+			InteractivesAPIClient: &mocks_importer.InteractivesAPIClientMock{
+				PatchInteractiveFunc: func(_ context.Context, _, _, _ string, req interactives.PatchRequest) (interactives.Interactive, error) {
+					patched = append(patched, req)
+					return interactives.Interactive{}, nil
+				},
+			},
+		}
+		event := &importer.InteractivesUploaded{ID: "1", Path: "archive.zip"}
+
+		Convey("When it is imported", func() {
+			err := handler.Import(context.Background(), handler.NewJob(context.Background(), event), event, "", log.Data{})
+
+			Convey("Then it should fail before downloading with the reason", func() {
+				So(errors.Is(err, importer.ErrInsufficientDiskSpace), ShouldBeTrue)
+				So(errors.Is(err, importer.ErrStorageUnavailable), ShouldBeTrue)
+				So(patched, ShouldHaveLength, 1)
+				report := decodeReport(patched[0].Interactive.Archive.ImportMessage)
+				So(report.Category, ShouldEqual, importer.CategoryStorageUnavailable)
+				So(report.Message, ShouldEqual, importer.CategoryStorageUnavailable.EditorMessage())
+				So(report.Errors[0].Message, ShouldContainSubstring, "insufficient disk space")
+				So(report.Errors[0].Code, ShouldEqual, importer.CodeDiskSpace)
+				entries, err := os.ReadDir(tempDir)
+				So(err, ShouldBeNil)
+				So(entries, ShouldBeEmpty)
+			})
+		})
+	})
+
+	Convey("Given an archive that would leave less than the minimum free space", t, func() {
+		tempDir := t.TempDir()
+		free, err := importer.FreeSpace(tempDir)
+		So(err, ShouldBeNil)
+		mockS3 := &mocks_importer.S3InterfaceMock{
+			GetFunc: func(string) (io.ReadCloser, *int64, error) {
+				size := int64(len("small"))
+				return io.NopCloser(strings.NewReader("small")), &size, nil
+			},
+		}
+		handler := &importer.InteractivesUploadedHandler{
+			Cfg: &config.Config{BatchSize: 1, TempDir: tempDir, TempDirMinFreeBytes: free},
+			S3:  mockS3,
+			InteractivesAPIClient: &mocks_importer.InteractivesAPIClientMock{
+				PatchInteractiveFunc: func(context.Context, string, string, string, interactives.PatchRequest) (interactives.Interactive, error) {
+					return interactives.Interactive{}, nil
+				},
+			},
+		}
+		event := &importer.InteractivesUploaded{ID: "1", Path: "archive.zip"}
+
+		Convey("When it is imported", func() {
+			err := handler.Import(context.Background(), handler.NewJob(context.Background(), event), event, "", log.Data{})
+
+			Convey("Then it should fail before downloading", func() {
+				So(errors.Is(err, importer.ErrInsufficientDiskSpace), ShouldBeTrue)
+				So(mockS3.GetCalls(), ShouldHaveLength, 1)
+				entries, err := os.ReadDir(tempDir)
+				So(err, ShouldBeNil)
+				So(entries, ShouldBeEmpty)
+			})
+		})
+	})
+}
+
+func TestHandlerTimeouts(t *testing.T) {
+
+	Convey("Given a handler importing an archive", t, func() {
+		archive, err := test.CreateTestZip("index.html")
+		So(err, ShouldBeNil)
+		defer os.Remove(archive)
+
+		hang := make(chan struct{})
+		defer close(hang)
+		var s3Hangs, uploadHangs bool
+		mockS3 := &mocks_importer.S3InterfaceMock{
+			GetFunc: func(string) (io.ReadCloser, *int64, error) {
+				if s3Hangs {
+					<-hang
+				}
+				f, err := os.Open(archive)
+				if err != nil {
+					return nil, nil, err
+				}
+				info, _ := f.Stat()
+				size := info.Size()
+				return f, &size, nil
+			},
+		}
+		backend := &mocks_importer.UploadServiceBackendMock{
+			UploadFunc: func(ctx context.Context, _ io.ReadCloser, _ upload.Metadata) error {
+				if uploadHangs {
+					<-ctx.Done()
+					return ctx.Err()
+				}
+				return nil
+			},
+		}
+		var patched []interactives.PatchRequest
+		cfg := &config.Config{BatchSize: 1, TempDir: t.TempDir()}
+		handler := &importer.InteractivesUploadedHandler{
+			Cfg:           cfg,
+			S3:            mockS3,
+			UploadService: importer.NewUploadService(backend),
+			InteractivesAPIClient: &mocks_importer.InteractivesAPIClientMock{
+				PatchInteractiveFunc: func(_ context.Context, _, _, _ string, req interactives.PatchRequest) (interactives.Interactive, error) {
+					patched = append(patched, req)
+					return interactives.Interactive{}, nil
+				},
+			},
+		}
+		event := &importer.InteractivesUploaded{ID: "1", Path: "archive.zip"}
+		runImport := func() error {
+			return handler.Import(context.Background(), handler.NewJob(context.Background(), event), event, "", log.Data{})
+		}
+
+		Convey("When the download takes longer than its deadline", func() {
+			s3Hangs = true
+			cfg.DownloadTimeout = 10 * time.Millisecond
+			err := runImport()
+
+			Convey("Then the import should fail in the download stage", func() {
+				var timeoutErr *importer.TimeoutError
+				So(errors.As(err, &timeoutErr), ShouldBeTrue)
+				So(timeoutErr.Stage, ShouldEqual, importer.StageDownload)
+				So(timeoutErr.Import, ShouldBeFalse)
+				So(patched, ShouldHaveLength, 1)
+				report := decodeReport(patched[0].Interactive.Archive.ImportMessage)
+				So(report.Category, ShouldEqual, importer.CategoryTimeout)
+				So(report.Errors[0].Message, ShouldStartWith, "download stage timed out after 10ms")
+				So(report.Errors[0].Code, ShouldEqual, importer.CodeTimeout)
+				So(report.Errors[0].Stage, ShouldEqual, importer.StageDownload)
+			})
+		})
+
+		Convey("When a file upload takes longer than its deadline", func() {
+			uploadHangs = true
+			cfg.FileUploadTimeout = 10 * time.Millisecond
+			err := runImport()
+
+			Convey("Then the import should fail naming the file", func() {
+				So(err, ShouldNotBeNil)
+				So(patched, ShouldHaveLength, 1)
+				report := decodeReport(patched[0].Interactive.Archive.ImportMessage)
+				So(report.Errors, ShouldHaveLength, 1)
+				So(report.Errors[0].File, ShouldEqual, "index.html")
+				So(report.Errors[0].Code, ShouldEqual, importer.CodeTimeout)
+				So(report.Errors[0].Message, ShouldStartWith, "upload of index.html timed out after 10ms")
+			})
+		})
+
+		Convey("When the whole import takes longer than its deadline", func() {
+			uploadHangs = true
+			cfg.ImportTimeout = 10 * time.Millisecond
+			err := runImport()
+
+			Convey("Then the import should fail in the stage it had reached", func() {
+				var timeoutErr *importer.TimeoutError
+				So(errors.As(err, &timeoutErr), ShouldBeTrue)
+				So(timeoutErr.Import, ShouldBeTrue)
+				So(timeoutErr.Stage, ShouldEqual, importer.StageUpload)
+				report := decodeReport(patched[0].Interactive.Archive.ImportMessage)
+				So(report.Category, ShouldEqual, importer.CategoryTimeout)
+				So(errors.Is(err, importer.ErrTimeout), ShouldBeTrue)
+			})
+		})
+
+		Convey("When every stage finishes within its deadline", func() {
+			cfg.ImportTimeout = time.Minute
+			cfg.DownloadTimeout = time.Minute
+			cfg.ValidateTimeout = time.Minute
+			cfg.FileUploadTimeout = time.Minute
+			err := runImport()
+
+			Convey("Then the import should succeed", func() {
+				So(err, ShouldBeNil)
+				So(patched[0].Interactive.Archive.ImportSuccessful, ShouldBeTrue)
+			})
+		})
+	})
+}
+
+func decodeReport(message string) *importer.Report {
+	report := &importer.Report{}
+	So(json.Unmarshal([]byte(message), report), ShouldBeNil)
+	return report
+}
+
+func TestHandlerErrorCategories(t *testing.T) {
+
+	Convey("Given a handler importing a local archive", t, func() {
+		archive, err := test.CreateTestZip("index.html")
+		So(err, ShouldBeNil)
+		defer os.Remove(archive)
+
+		var uploadErrs []error
+		var uploads int
+		backend := &mocks_importer.UploadServiceBackendMock{
+			UploadFunc: func(context.Context, io.ReadCloser, upload.Metadata) error {
+				uploads++
+				if len(uploadErrs) == 0 {
+					return nil
+				}
+				err := uploadErrs[0]
+				if len(uploadErrs) > 1 {
+					uploadErrs = uploadErrs[1:]
+				}
+				return err
+			},
+		}
+		var patched []interactives.PatchRequest
+		cfg := &config.Config{BatchSize: 1, TempDir: t.TempDir(), FileUploadRetries: 2, FileUploadRetryBackoff: time.Millisecond}
+		handler := &importer.InteractivesUploadedHandler{
+			Cfg:           cfg,
+			UploadService: importer.NewUploadService(backend),
+			InteractivesAPIClient: &mocks_importer.InteractivesAPIClientMock{
+				PatchInteractiveFunc: func(_ context.Context, _, _, _ string, req interactives.PatchRequest) (interactives.Interactive, error) {
+					patched = append(patched, req)
+					return interactives.Interactive{}, nil
+				},
+			},
+		}
+		event := &importer.InteractivesUploaded{ID: "1", Path: "archive.zip"}
+		runImport := func(archive string) error {
+			return handler.Import(context.Background(), handler.NewJob(context.Background(), event), event, archive, log.Data{})
+		}
+		unavailable := &importer.StatusError{StatusCode: 503, Err: dperrors.NewErrorFromUnhandledStatusCode("upload-service", 503)}
+
+		Convey("When the upload service is briefly unavailable", func() {
+			uploadErrs = []error{unavailable, unavailable, nil}
+			err := runImport(archive)
+
+			Convey("Then the upload should be retried until it succeeds", func() {
+				So(err, ShouldBeNil)
+				So(uploads, ShouldEqual, 3)
+				So(patched[0].Interactive.Archive.ImportSuccessful, ShouldBeTrue)
+			})
+		})
+
+		Convey("When the upload service stays unavailable", func() {
+			uploadErrs = []error{unavailable}
+			err := runImport(archive)
+
+			Convey("Then the import should fail as storage unavailable once the retries run out", func() {
+				So(errors.Is(err, importer.ErrStorageUnavailable), ShouldBeTrue)
+				So(importer.CategoryOf(err), ShouldEqual, importer.CategoryStorageUnavailable)
+				So(uploads, ShouldEqual, 3)
+				report := decodeReport(patched[0].Interactive.Archive.ImportMessage)
+				So(report.Category, ShouldEqual, importer.CategoryStorageUnavailable)
+				So(report.Errors[0].File, ShouldEqual, "index.html")
+			})
+		})
+
+		Convey("When the importer is not authorised to upload", func() {
+			uploadErrs = []error{upload.ErrNotAuthorized}
+			err := runImport(archive)
+
+			Convey("Then the import should fail as unauthorized without retrying", func() {
+				So(errors.Is(err, importer.ErrUnauthorized), ShouldBeTrue)
+				So(uploads, ShouldEqual, 1)
+				report := decodeReport(patched[0].Interactive.Archive.ImportMessage)
+				So(report.Category, ShouldEqual, importer.CategoryUnauthorized)
+				So(report.Message, ShouldEqual, importer.CategoryUnauthorized.EditorMessage())
+			})
+		})
+
+		Convey("When the archive is not a zip file", func() {
+			notZip := filepath.Join(t.TempDir(), "not-a.zip")
+			So(os.WriteFile(notZip, []byte("not a zip"), 0o600), ShouldBeNil)
+			err := runImport(notZip)
+
+			Convey("Then the import should fail as a corrupt archive", func() {
+				So(errors.Is(err, importer.ErrCorruptArchive), ShouldBeTrue)
+				So(errors.Is(err, zip.ErrFormat), ShouldBeTrue)
+				So(uploads, ShouldEqual, 0)
+				report := decodeReport(patched[0].Interactive.Archive.ImportMessage)
+				So(report.Category, ShouldEqual, importer.CategoryCorruptArchive)
+			})
+		})
+	})
+}
+
+// countingBackend stores files on disk, counting the uploads and refusing any of the file named fail
+type countingBackend struct {
+	*storage.FileUploadBackend
+	uploaded []string
+	fail     string
+}
+
+func (b *countingBackend) Upload(ctx context.Context, fileContent io.ReadCloser, metadata upload.Metadata) error {
+	if metadata.FileName == b.fail {
+		return upload.ErrFileTooLarge
+	}
+	b.uploaded = append(b.uploaded, metadata.Path+"/"+metadata.FileName)
+	return b.FileUploadBackend.Upload(ctx, fileContent, metadata)
+}
+
+// writeZip writes an archive holding each file with its content
+func writeZip(t *testing.T, files map[string]string) string {
+	f, err := os.CreateTemp(t.TempDir(), "archive_*.zip")
+	So(err, ShouldBeNil)
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		So(err, ShouldBeNil)
+		_, err = io.WriteString(w, content)
+		So(err, ShouldBeNil)
+	}
+	So(zw.Close(), ShouldBeNil)
+	return f.Name()
+}
+
+// newStorageHandler returns a handler importing local archives into a filesystem upload backend under dir,
+// and the upload root each import reported
+func newStorageHandler(t *testing.T, dir string) (*importer.InteractivesUploadedHandler, *countingBackend, *[]interactives.PatchRequest) {
+	fileBackend, err := storage.NewFileUploadBackend(dir)
+	So(err, ShouldBeNil)
+	backend := &countingBackend{FileUploadBackend: fileBackend}
+	var patched []interactives.PatchRequest
+	handler := &importer.InteractivesUploadedHandler{
+		Cfg:           &config.Config{BatchSize: 1, TempDir: t.TempDir(), UploadRootStrategy: importer.RootStrategyRandom},
+		UploadService: importer.NewUploadService(backend),
+		InteractivesAPIClient: &mocks_importer.InteractivesAPIClientMock{
+			PatchInteractiveFunc: func(_ context.Context, _, _, _ string, req interactives.PatchRequest) (interactives.Interactive, error) {
+				patched = append(patched, req)
+				return interactives.Interactive{}, nil
+			},
+		},
+	}
+	return handler, backend, &patched
+}
+
+// storedFile reads a file served under an upload root of the filesystem backend
+func storedFile(dir, uploadRootPath, name string) string {
+	b, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(uploadRootPath), filepath.FromSlash(name)))
+	So(err, ShouldBeNil)
+	return string(b)
+}
+
+func TestHandlerDeduplication(t *testing.T) {
+
+	Convey("Given a handler deduplicating files into a filesystem upload backend", t, func() {
+		dir := t.TempDir()
+		handler, backend, patched := newStorageHandler(t, dir)
+		contentIndex, err := importer.NewFileContentIndex(t.TempDir())
+		So(err, ShouldBeNil)
+		handler.ContentIndex = contentIndex
+		archive, err := test.CreateTestZip("index.html", "js/app.js")
+		So(err, ShouldBeNil)
+		defer os.Remove(archive)
+		runImport := func(id string) string {
+			event := &importer.InteractivesUploaded{ID: id, Path: "archive.zip"}
+			So(handler.Import(context.Background(), handler.NewJob(context.Background(), event), event, archive, log.Data{}), ShouldBeNil)
+			last := (*patched)[len(*patched)-1]
+			So(last.Interactive.Archive.ImportSuccessful, ShouldBeTrue)
+			return last.Interactive.Archive.UploadRootDirectory
+		}
+		first := runImport("1")
+		So(backend.uploaded, ShouldHaveLength, 2)
+
+		Convey("When another interactive with the same files is imported", func() {
+			second := runImport("2")
+
+			Convey("Then its files should be served from its own upload root without being uploaded again", func() {
+				So(second, ShouldNotEqual, first)
+				So(backend.uploaded, ShouldHaveLength, 2)
+				So(storedFile(dir, second, "index.html"), ShouldEqual, "index.html")
+				So(storedFile(dir, second, "js/app.js"), ShouldEqual, "js/app.js")
+			})
+
+			Convey("Then deleting the first root should leave them in place", func() {
+				So(backend.DeleteRoot(context.Background(), first), ShouldBeNil)
+				So(storedFile(dir, second, "index.html"), ShouldEqual, "index.html")
+			})
+		})
+
+		Convey("When the stored copies have gone by the time the same files are imported again", func() {
+			So(backend.DeleteRoot(context.Background(), first), ShouldBeNil)
+			second := runImport("2")
+
+			Convey("Then they should be uploaded instead", func() {
+				So(backend.uploaded, ShouldHaveLength, 4)
+				So(storedFile(dir, second, "index.html"), ShouldEqual, "index.html")
+				So(storedFile(dir, second, "js/app.js"), ShouldEqual, "js/app.js")
+			})
+		})
+	})
+}
+
+func TestHandlerHashing(t *testing.T) {
+
+	Convey("Given a handler recording manifests, with no earlier import or content index to compare against", t, func() {
+		dir := t.TempDir()
+		handler, backend, _ := newStorageHandler(t, dir)
+		manifests, err := importer.NewFileManifestStore(t.TempDir())
+		So(err, ShouldBeNil)
+		handler.Manifests = manifests
+		archive, err := test.CreateTestZip("index.html", "js/app.js")
+		So(err, ShouldBeNil)
+		defer os.Remove(archive)
+
+		Convey("When an archive is imported", func() {
+			event := &importer.InteractivesUploaded{ID: "1", Path: "archive.zip"}
+			So(handler.Import(context.Background(), handler.NewJob(context.Background(), event), event, archive, log.Data{}), ShouldBeNil)
+
+			Convey("Then each file should be hashed as it uploads, with its content intact", func() {
+				So(backend.uploaded, ShouldHaveLength, 2)
+				manifest, err := manifests.Get(context.Background(), "1")
+				So(err, ShouldBeNil)
+				for _, name := range []string{"index.html", "js/app.js"} {
+					sum := sha256.Sum256([]byte(name))
+					So(manifest.Files[name].Hash, ShouldEqual, hex.EncodeToString(sum[:]))
+					So(storedFile(dir, manifest.UploadRootPath, name), ShouldEqual, name)
+				}
+			})
+		})
+	})
+}
+
+func TestHandlerIncrementalImport(t *testing.T) {
+
+	Convey("Given an interactive imported incrementally into a filesystem upload backend", t, func() {
+		dir := t.TempDir()
+		handler, backend, patched := newStorageHandler(t, dir)
+		manifests, err := importer.NewFileManifestStore(t.TempDir())
+		So(err, ShouldBeNil)
+		handler.Manifests = manifests
+		event := &importer.InteractivesUploaded{ID: "1", Path: "archive.zip"}
+		runImport := func(files map[string]string) (string, error) {
+			err := handler.Import(context.Background(), handler.NewJob(context.Background(), event), event, writeZip(t, files), log.Data{})
+			return (*patched)[len(*patched)-1].Interactive.Archive.UploadRootDirectory, err
+		}
+		first, err := runImport(map[string]string{"a.html": "a", "b.html": "b", "c.html": "c"})
+		So(err, ShouldBeNil)
+		So(backend.uploaded, ShouldHaveLength, 3)
+
+		Convey("When a new version changes one file, removes one and adds one", func() {
+			second, err := runImport(map[string]string{"a.html": "a", "b.html": "b2", "d.html": "d"})
+			So(err, ShouldBeNil)
+
+			Convey("Then it should be served from a new root, holding the unchanged file without uploading it again", func() {
+				So(second, ShouldNotEqual, first)
+				So(backend.uploaded, ShouldHaveLength, 5)
+				So(backend.uploaded, ShouldContain, second+"/b.html")
+				So(backend.uploaded, ShouldContain, second+"/d.html")
+				So(storedFile(dir, second, "a.html"), ShouldEqual, "a")
+				So(storedFile(dir, second, "b.html"), ShouldEqual, "b2")
+				So(storedFile(dir, second, "d.html"), ShouldEqual, "d")
+				_, err := os.Stat(filepath.Join(dir, filepath.FromSlash(second), "c.html"))
+				So(errors.Is(err, os.ErrNotExist), ShouldBeTrue)
+				report := decodeReport((*patched)[1].Interactive.Archive.ImportMessage)
+				So(report.Message, ShouldEqual, "1 added, 1 changed, 1 unchanged, 1 removed")
+			})
+
+			Convey("Then the previous root should be left as it was", func() {
+				So(storedFile(dir, first, "a.html"), ShouldEqual, "a")
+				So(storedFile(dir, first, "b.html"), ShouldEqual, "b")
+				So(storedFile(dir, first, "c.html"), ShouldEqual, "c")
+			})
+		})
+
+		Convey("When a new version fails part way through", func() {
+			backend.fail = "d.html"
+			_, err := runImport(map[string]string{"a.html": "a", "b.html": "b2", "d.html": "d"})
+
+			Convey("Then the previous root should be left as it was, and stay the base of the next import", func() {
+				So(err, ShouldNotBeNil)
+				So(storedFile(dir, first, "b.html"), ShouldEqual, "b")
+				manifest, err := manifests.Get(context.Background(), "1")
+				So(err, ShouldBeNil)
+				So(manifest.UploadRootPath, ShouldEqual, first)
+			})
+		})
+	})
+}
+
+func TestHandlerUploadRootStrategies(t *testing.T) {
+
+	Convey("Given a handler recording upload roots", t, func() {
+		dir := t.TempDir()
+		handler, backend, patched := newStorageHandler(t, dir)
+		handler.UploadRoots = &memoryRootStore{roots: make(map[string]importer.UploadRoot)}
+		archive := writeZip(t, map[string]string{"index.html": "one"})
+		newArchive := writeZip(t, map[string]string{"index.html": "two"})
+		runImport := func(event *importer.InteractivesUploaded, archive string) string {
+			So(handler.Import(context.Background(), handler.NewJob(context.Background(), event), event, archive, log.Data{}), ShouldBeNil)
+			last := (*patched)[len(*patched)-1]
+			So(last.Interactive.Archive.ImportSuccessful, ShouldBeTrue)
+			return last.Interactive.Archive.UploadRootDirectory
+		}
+		event := &importer.InteractivesUploaded{ID: "1", Path: "archive.zip"}
+
+		Convey("When the random strategy imports the same archive twice", func() {
+			handler.Cfg.UploadRootStrategy = importer.RootStrategyRandom
+			first := runImport(event, archive)
+			second := runImport(event, archive)
+
+			Convey("Then it should be uploaded to a new root each time", func() {
+				So(second, ShouldNotEqual, first)
+				So(backend.uploaded, ShouldHaveLength, 2)
+			})
+		})
+
+		Convey("When the content strategy imports the same archive twice", func() {
+			handler.Cfg.UploadRootStrategy = importer.RootStrategyContent
+			first := runImport(event, archive)
+			second := runImport(event, archive)
+
+			Convey("Then the second import should find it already imported and upload nothing", func() {
+				So(second, ShouldEqual, first)
+				So(backend.uploaded, ShouldHaveLength, 1)
+			})
+
+			Convey("And a new archive at the same path should be imported to a new root", func() {
+				third := runImport(event, newArchive)
+				So(third, ShouldNotEqual, first)
+				So(backend.uploaded, ShouldHaveLength, 2)
+				So(storedFile(dir, third, "index.html"), ShouldEqual, "two")
+			})
+		})
+
+		Convey("When the event strategy imports a redelivered event", func() {
+			handler.Cfg.UploadRootStrategy = importer.RootStrategyEvent
+			versioned := &importer.InteractivesUploaded{ID: "1", Path: "archive.zip", Version: "1"}
+			first := runImport(versioned, archive)
+			second := runImport(versioned, archive)
+
+			Convey("Then the second import should find it already imported and upload nothing", func() {
+				So(second, ShouldEqual, first)
+				So(backend.uploaded, ShouldHaveLength, 1)
+			})
+
+			Convey("And a new version at the same path should be imported to a new root", func() {
+				third := runImport(&importer.InteractivesUploaded{ID: "1", Path: "archive.zip", Version: "2"}, newArchive)
+				So(third, ShouldNotEqual, first)
+				So(backend.uploaded, ShouldHaveLength, 2)
+				So(storedFile(dir, third, "index.html"), ShouldEqual, "two")
+			})
+		})
+
+		Convey("When the event strategy imports a new archive at the same path from events without a version", func() {
+			handler.Cfg.UploadRootStrategy = importer.RootStrategyEvent
+			first := runImport(event, archive)
+			second := runImport(event, newArchive)
+
+			Convey("Then the new archive should not be skipped", func() {
+				So(second, ShouldNotEqual, first)
+				So(backend.uploaded, ShouldHaveLength, 2)
+				So(storedFile(dir, second, "index.html"), ShouldEqual, "two")
+			})
+		})
+	})
+}
+
+func TestHandlerCleanupFixedRoot(t *testing.T) {
+
+	Convey("Given a handler cleaning up failed imports to roots derived from the archive", t, func() {
+		dir := t.TempDir()
+		handler, backend, patched := newStorageHandler(t, dir)
+		handler.Cfg.UploadRootStrategy = importer.RootStrategyContent
+		handler.Cfg.CleanupFailedImports = true
+		archive := writeZip(t, map[string]string{"index.html": "one", "app.js": "two"})
+		event := &importer.InteractivesUploaded{ID: "1", Path: "archive.zip"}
+		runImport := func() (string, error) {
+			err := handler.Import(context.Background(), handler.NewJob(context.Background(), event), event, archive, log.Data{})
+			return (*patched)[len(*patched)-1].Interactive.Archive.UploadRootDirectory, err
+		}
+		live, err := runImport()
+		So(err, ShouldBeNil)
+
+		Convey("When the same archive fails to import again without upload roots recorded", func() {
+			backend.fail = "app.js"
+			_, err = runImport()
+
+			Convey("Then the live root should not be deleted", func() {
+				So(err, ShouldNotBeNil)
+				So(storedFile(dir, live, "index.html"), ShouldEqual, "one")
+				So(storedFile(dir, live, "app.js"), ShouldEqual, "two")
+			})
+		})
+
+		Convey("When a new archive fails to import to a root never recorded", func() {
+			handler.UploadRoots = &memoryRootStore{roots: make(map[string]importer.UploadRoot)}
+			archive = writeZip(t, map[string]string{"index.html": "three", "app.js": "four"})
+			backend.fail = "app.js"
+			failed, err := runImport()
+
+			Convey("Then the root created by the import should be deleted", func() {
+				So(err, ShouldNotBeNil)
+				So(failed, ShouldNotEqual, live)
+				_, err = os.Stat(filepath.Join(dir, filepath.FromSlash(failed)))
+				So(os.IsNotExist(err), ShouldBeTrue)
+				So(storedFile(dir, live, "index.html"), ShouldEqual, "one")
+			})
+		})
+	})
+}
+
+func TestHandlerLegacyEvent(t *testing.T) {
+
+	Convey("Given an event sent before the version was added to the schema", t, func() {
+		data, err := schema.LegacyInteractivesUploadedEvent.Marshal(&struct {
+			ID           string `avro:"id"`
+			Path         string `avro:"path"`
+			Title        string `avro:"title"`
+			CollectionID string `avro:"collection_id"`
+		}{ID: "1", Path: "archive.zip", Title: "Title"})
+		So(err, ShouldBeNil)
+		msg, err := kafkatest.NewMessage(data, 0)
+		So(err, ShouldBeNil)
+		archive, err := test.CreateTestZip("index.html")
+		So(err, ShouldBeNil)
+		defer os.Remove(archive)
+		var got []string
+		handler := &importer.InteractivesUploadedHandler{
+			Cfg: &config.Config{BatchSize: 1, TempDir: t.TempDir()},
+			S3: &mocks_importer.S3InterfaceMock{
+				GetFunc: func(key string) (io.ReadCloser, *int64, error) {
+					got = append(got, key)
+					f, err := os.Open(archive)
+					if err != nil {
+						return nil, nil, err
+					}
+					info, _ := f.Stat()
+					size := info.Size()
+					return f, &size, nil
+				},
+			},
+			UploadService: importer.NewUploadService(&mocks_importer.UploadServiceBackendMock{
+				UploadFunc: func(context.Context, io.ReadCloser, upload.Metadata) error { return nil },
+			}),
+			InteractivesAPIClient: &mocks_importer.InteractivesAPIClientMock{
+				PatchInteractiveFunc: func(context.Context, string, string, string, interactives.PatchRequest) (interactives.Interactive, error) {
+					return interactives.Interactive{}, nil
+				},
+			},
+		}
+
+		Convey("Then it should still be imported", func() {
+			So(handler.Handle(context.Background(), 1, msg), ShouldBeNil)
+			So(got, ShouldResemble, []string{"archive.zip"})
+		})
+	})
+}
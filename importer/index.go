@@ -0,0 +1,97 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ContentIndex maps the SHA-256 hash of a file's content to the path it is already stored under. Each import
+// stores its own copy of a deduplicated file, so an indexed path is only ever a source to copy from.
+type ContentIndex interface {
+	Get(ctx context.Context, hash string) (path string, found bool, err error)
+	Put(ctx context.Context, hash, path string) error
+	// Forget drops every indexed path under the upload root, once it has been deleted
+	Forget(ctx context.Context, uploadRootPath string) error
+}
+
+// FileContentIndex keeps one file per hash in a local directory, holding the path the content is stored under
+type FileContentIndex struct {
+	dir string
+}
+
+func NewFileContentIndex(dir string) (*FileContentIndex, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileContentIndex{dir: dir}, nil
+}
+
+func (i *FileContentIndex) Get(_ context.Context, hash string) (string, bool, error) {
+	b, err := os.ReadFile(i.path(hash))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return string(b), true, nil
+}
+
+func (i *FileContentIndex) Put(_ context.Context, hash, path string) error {
+	tmp, err := os.CreateTemp(i.dir, "index_*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.WriteString(path); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	// linking fails if the hash is already indexed, so the first stored copy wins even between instances
+	if err = os.Link(tmp.Name(), i.path(hash)); err != nil && !errors.Is(err, os.ErrExist) {
+		return err
+	}
+	return nil
+}
+
+func (i *FileContentIndex) Forget(_ context.Context, uploadRootPath string) error {
+	entries, err := os.ReadDir(i.dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if e.IsDir() || strings.HasSuffix(e.Name(), ".tmp") {
+			continue
+		}
+		name := filepath.Join(i.dir, e.Name())
+		b, err := os.ReadFile(name)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if underRoot(string(b), uploadRootPath) {
+			if err = os.Remove(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (i *FileContentIndex) path(hash string) string {
+	return filepath.Join(i.dir, url.PathEscape(hash))
+}
+
+func underRoot(path, uploadRootPath string) bool {
+	return strings.HasPrefix(path, strings.TrimSuffix(uploadRootPath, "/")+"/")
+}
@@ -0,0 +1,56 @@
+package importer_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ONSdigital/dp-interactives-importer/importer"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestContentIndex(t *testing.T) {
+
+	Convey("Given an empty content index", t, func() {
+		idx, err := importer.NewFileContentIndex(t.TempDir())
+		So(err, ShouldBeNil)
+
+		Convey("Then an unknown hash should not be found", func() {
+			_, found, err := idx.Get(context.TODO(), "unknown")
+			So(err, ShouldBeNil)
+			So(found, ShouldBeFalse)
+		})
+
+		Convey("When a hash is stored", func() {
+			So(idx.Put(context.TODO(), "hash", "interactives/1/abc/js/jquery.js"), ShouldBeNil)
+
+			Convey("Then it should be found with its path", func() {
+				path, found, err := idx.Get(context.TODO(), "hash")
+				So(err, ShouldBeNil)
+				So(found, ShouldBeTrue)
+				So(path, ShouldEqual, "interactives/1/abc/js/jquery.js")
+			})
+
+			Convey("Then storing it again should keep the original path", func() {
+				So(idx.Put(context.TODO(), "hash", "interactives/2/def/js/jquery.js"), ShouldBeNil)
+				path, _, _ := idx.Get(context.TODO(), "hash")
+				So(path, ShouldEqual, "interactives/1/abc/js/jquery.js")
+			})
+
+			Convey("Then forgetting another root should keep it", func() {
+				So(idx.Forget(context.TODO(), "interactives/1/ab"), ShouldBeNil)
+				_, found, _ := idx.Get(context.TODO(), "hash")
+				So(found, ShouldBeTrue)
+			})
+
+			Convey("Then forgetting its root should drop it, so the next copy stored wins", func() {
+				So(idx.Forget(context.TODO(), "interactives/1/abc"), ShouldBeNil)
+				_, found, _ := idx.Get(context.TODO(), "hash")
+				So(found, ShouldBeFalse)
+				So(idx.Put(context.TODO(), "hash", "interactives/2/def/js/jquery.js"), ShouldBeNil)
+				path, _, _ := idx.Get(context.TODO(), "hash")
+				So(path, ShouldEqual, "interactives/2/def/js/jquery.js")
+			})
+		})
+	})
+}
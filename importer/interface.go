@@ -22,7 +22,19 @@ type UploadServiceBackend interface {
 	Checker(ctx context.Context, state *health.CheckState) error
 }
 
+// UploadRootDeleter is implemented by upload backends that can remove everything stored under an upload root
+type UploadRootDeleter interface {
+	DeleteRoot(ctx context.Context, uploadRootPath string) error
+}
+
+// UploadCopier is implemented by upload backends that can store a copy of a file they already hold, so identical
+// content is not uploaded again
+type UploadCopier interface {
+	Copy(ctx context.Context, sourcePath string, metadata upload.Metadata) error
+}
+
 type InteractivesAPIClient interface {
+	GetInteractive(context.Context, string, string, string) (interactives.Interactive, error)
 	PatchInteractive(context.Context, string, string, string, interactives.PatchRequest) (interactives.Interactive, error)
 	Checker(ctx context.Context, state *health.CheckState) error
 }
@@ -2,23 +2,187 @@ package importer
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
 	"github.com/ONSdigital/dp-api-clients-go/v2/interactives"
 	"github.com/ONSdigital/dp-interactives-importer/config"
 	"github.com/ONSdigital/log.go/v2/log"
+	gonanoid "github.com/matoous/go-nanoid/v2"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Job struct {
+	id                    string
 	ctx                   context.Context
 	interactivesAPIClient InteractivesAPIClient
 	serviceAuthToken      string
+	patchTimeout          time.Duration
+	patchRetries          int
+	patchRetryBackoff     time.Duration
+	report                *Report
+	reportMaxBytes        int
+	importMessage         string
+	span                  trace.Span // spans the whole import, from Start until Finish
+
+	// set when the job created a new upload root, so a failed import can clean up after itself
+	cleanupFailed bool // delete the root of a failed import, rather than leave it to garbage collection
+	uploadService *UploadService
+	roots         UploadRootStore
+	uploadRoot    *UploadRoot
+	resumable     bool // a retryable failure keeps the upload root for the retry to resume
+
+	retry       bool              // a retryable failure is tried again by a new job, which reports the outcome instead
+	notReported *NotReportedError // the outcome the interactives api could not be told, to be sent again
+
+	mu           sync.RWMutex
+	started      bool
+	status       Status
+	stageStarted time.Time
 }
 
 func NewJob(ctx context.Context, cfg *config.Config, interactivesAPIClient InteractivesAPIClient) *Job {
+	id := gonanoid.Must(16)
+	now := time.Now().UTC()
 	return &Job{
+		id:                    id,
 		ctx:                   ctx,
 		serviceAuthToken:      cfg.ServiceAuthToken,
+		patchTimeout:          cfg.PatchTimeout,
+		patchRetries:          cfg.PatchRetries,
+		patchRetryBackoff:     cfg.PatchRetryBackoff,
+		report:                &Report{},
+		reportMaxBytes:        cfg.ImportReportMaxBytes,
+		cleanupFailed:         cfg.CleanupFailedImports,
 		interactivesAPIClient: interactivesAPIClient,
+		status: Status{
+			ID:            id,
+			Stage:         StageQueued,
+			InFlight:      true,
+			StartedAt:     now,
+			TimingsMillis: make(map[string]int64),
+		},
+		stageStarted: now,
+	}
+}
+
+func (j *Job) ID() string {
+	return j.id
+}
+
+// Status returns a copy of the job's progress, safe to call concurrently with the import
+func (j *Job) Status() Status {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	s := j.status
+	s.Errors = append([]string(nil), j.status.Errors...)
+	s.TimingsMillis = make(map[string]int64, len(j.status.TimingsMillis))
+	for k, v := range j.status.TimingsMillis {
+		s.TimingsMillis[k] = v
+	}
+	return s
+}
+
+// Start records the event being imported and starts the span the import is traced under
+func (j *Job) Start(event *InteractivesUploaded) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.ctx, j.span = tracer.Start(j.ctx, "import", trace.WithAttributes(
+		append(interactiveAttributes(event), attribute.String("job.id", j.id))...,
+	))
+
+	j.status.InteractiveID = event.ID
+	j.status.Path = event.Path
+	j.started = true
+	importsInFlight.Inc()
+}
+
+// SetStage moves the import on to the given stage, recording how long the previous one took
+func (j *Job) SetStage(stage string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.setStage(stage)
+}
+
+func (j *Job) setStage(stage string) {
+	now := time.Now().UTC()
+	elapsed := now.Sub(j.stageStarted)
+	j.status.TimingsMillis[j.status.Stage] += elapsed.Milliseconds()
+	stageDuration.WithLabelValues(j.status.Stage).Observe(elapsed.Seconds())
+	j.status.Stage = stage
+	j.stageStarted = now
+}
+
+func (j *Job) SetArchive(uploadRootPath string, sizeInBytes int64) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.status.UploadRoot = uploadRootPath
+	j.status.ArchiveBytes = sizeInBytes
+}
+
+func (j *Job) SetFilesTotal(total uint64) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.status.FilesTotal = total
+}
+
+// FileProcessed counts a file through the upload stage, uploaded is zero if the file did not need uploading
+func (j *Job) FileProcessed(uploaded int64) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.status.FilesProcessed++
+	j.status.BytesUploaded += uploaded
+}
+
+// Report returns the report sent to the interactives api when the job finishes
+func (j *Job) Report() *Report {
+	return j.report
+}
+
+// SetImportMessage sets the message of the report of a successful import, a failed import always reports its error
+func (j *Job) SetImportMessage(msg string) {
+	j.importMessage = msg
+}
+
+// TrackUploadRoot marks the root as created by this job: on failure everything uploaded under it is deleted
+// if cleanup of failed imports is enabled, otherwise it is recorded for garbage collection. Roots is optional.
+func (j *Job) TrackUploadRoot(uploadService *UploadService, roots UploadRootStore, interactiveID, uploadRootPath string) {
+	j.uploadService = uploadService
+	j.roots = roots
+	j.uploadRoot = &UploadRoot{
+		InteractiveID: interactiveID,
+		Path:          uploadRootPath,
+		Status:        RootStatusImporting,
+		CreatedAt:     time.Now().UTC(),
 	}
+	j.recordUploadRoot()
+}
+
+// KeepUploadRootForRetry stops a failure that is retried or redelivered from deleting the files uploaded under
+// the root, as a checkpoint lets the next attempt resume rather than upload them again
+func (j *Job) KeepUploadRootForRetry() {
+	j.resumable = true
+}
+
+// RetryOnFailure marks the job as one whose retryable failure is tried again by a new job, so the failure is not
+// reported to the interactives api
+func (j *Job) RetryOnFailure() {
+	j.retry = true
+}
+
+// WillRetry is true if the job failed with err and the import is to be tried again, an interrupted import is
+// redelivered instead
+func (j *Job) WillRetry(err error) bool {
+	return j.retry && Retryable(err) && CategoryOf(err) != CategoryInterrupted
 }
 
 func (j *Job) Finish(logData *log.Data, event *InteractivesUploaded, uploadRootDirectory string, zipSize *int64, err *error) {
@@ -35,22 +199,204 @@ func (j *Job) Finish(logData *log.Data, event *InteractivesUploaded, uploadRootD
 			},
 		},
 	}
+	failedStage := j.Status().Stage
+	j.SetStage(StagePatch)
+	defer j.finishStatus(e)
+
+	ctx, span := tracer.Start(j.ctx, "finish", trace.WithAttributes(
+		attribute.Bool("import.successful", e == nil),
+	))
+	var apiErr error
+	defer func() { endSpan(span, apiErr) }()
+	category := CategoryOf(e)
+	if category == CategoryInterrupted {
+		importsTotal.WithLabelValues("interrupted", failedStage, string(category)).Inc()
+	} else if j.WillRetry(e) {
+		importsTotal.WithLabelValues("retried", failedStage, string(category)).Inc()
+	} else if e != nil {
+		importsTotal.WithLabelValues("failure", failedStage, string(category)).Inc()
+	} else {
+		importsTotal.WithLabelValues("success", "", "").Inc()
+	}
+
+	if j.WillRetry(e) {
+		// the next attempt reports the outcome
+		j.cleanup(l, e)
+		l["error"] = e.Error()
+		l["category"] = category
+		log.Warn(j.ctx, "import failed and will be retried", l)
+		return
+	}
+
+	j.report.finish(e, failedStage, j.importMessage, j.Status().FilesTotal)
 	if e != nil {
+		j.cleanup(l, e)
 		l["error"] = e.Error()
-		patchReq.Interactive.Archive.ImportMessage = e.Error()
+		l["category"] = category
+		patchReq.Interactive.Archive.ImportMessage = j.encodeReport(e.Error())
 		patchReq.Interactive.Archive.UploadRootDirectory = uploadRootDirectory
 	} else {
 		patchReq.Interactive.Archive.ImportSuccessful = true
+		patchReq.Interactive.Archive.ImportMessage = j.encodeReport(j.importMessage)
+		if j.uploadRoot != nil {
+			j.uploadRoot.Status = RootStatusComplete
+			j.recordUploadRoot()
+		}
 		patchReq.Interactive.Archive.UploadRootDirectory = uploadRootDirectory
 		if zipSize != nil {
 			patchReq.Interactive.Archive.Size = *zipSize
 		}
 	}
-	// user token not valid - we auth user on api endpoints
-	_, apiErr := j.interactivesAPIClient.PatchInteractive(j.ctx, "", j.serviceAuthToken, event.ID, patchReq)
+	// an interrupted import is redelivered whether or not it reports, so it does not hold up shutdown retrying
+	apiErr = j.patch(ctx, event.ID, patchReq, category != CategoryInterrupted)
 	if apiErr != nil {
-		//todo what if this fails - retry?
 		l["apiError"] = apiErr.Error()
 		log.Warn(j.ctx, "failed to update interactive", logData)
+		if patchRetryable(apiErr) {
+			// only the report is sent again, the import keeps its outcome
+			j.notReported = &NotReportedError{InteractiveID: event.ID, Request: patchReq, Err: apiErr, importErr: e}
+			*err = errors.Join(e, j.notReported)
+		}
+	}
+}
+
+// NotReportedError is the failure to tell the interactives api the outcome of an import, with the update that was
+// not sent. It does not unwrap to the failure of the api, so the import keeps its own category.
+type NotReportedError struct {
+	InteractiveID string
+	Request       interactives.PatchRequest
+	Err           error
+
+	importErr error // the outcome that was not reported
+}
+
+func (e *NotReportedError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrNotReported, e.Err)
+}
+
+func (e *NotReportedError) Is(target error) bool {
+	return target == ErrNotReported
+}
+
+// ReportAgain sends the outcome the job could not report to the interactives api again, without importing again.
+// It returns the error of the import, joined with ErrNotReported while the outcome is still not reported.
+func (j *Job) ReportAgain(ctx context.Context) error {
+	if j.notReported == nil {
+		return nil
+	}
+	if err := j.patch(ctx, j.notReported.InteractiveID, j.notReported.Request, true); err != nil {
+		j.notReported.Err = err
+		return errors.Join(j.notReported.importErr, j.notReported)
+	}
+	importErr := j.notReported.importErr
+	j.notReported = nil
+	return importErr
+}
+
+// patch updates the interactive within the patch timeout, trying again while the api is unavailable
+func (j *Job) patch(ctx context.Context, interactiveID string, req interactives.PatchRequest, retry bool) error {
+	backoff := j.patchRetryBackoff
+	for attempt := 0; ; attempt++ {
+		// user token not valid - we auth user on api endpoints
+		patchCtx, cancel := withTimeout(ctx, j.patchTimeout)
+		_, err := j.interactivesAPIClient.PatchInteractive(patchCtx, "", j.serviceAuthToken, interactiveID, req)
+		if err != nil && errors.Is(patchCtx.Err(), context.DeadlineExceeded) {
+			err = &TimeoutError{Stage: StagePatch, Timeout: j.patchTimeout, Err: err}
+		}
+		cancel()
+		if err == nil || !retry || attempt >= j.patchRetries || !patchRetryable(err) {
+			return err
+		}
+
+		log.Warn(ctx, "retrying update of interactive", log.Data{"id": interactiveID, "attempt": attempt + 1, "error": err.Error()})
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return err
+		}
+		backoff *= 2
+	}
+}
+
+// patchRetryable is true if the interactives api may accept the update later, rather than having rejected it
+func patchRetryable(err error) bool {
+	var timeoutErr *TimeoutError
+	return errors.Is(err, ErrCircuitOpen) || errors.As(err, &timeoutErr) || serviceFailure(err)
+}
+
+// encodeReport returns the report as JSON, falling back to the plain message if it cannot be encoded
+func (j *Job) encodeReport(message string) string {
+	encoded, err := j.report.Encode(j.reportMaxBytes)
+	if err != nil {
+		log.Warn(j.ctx, "failed to encode import report", log.Data{"error": err.Error()})
+		return message
+	}
+	return encoded
+}
+
+func (j *Job) finishStatus(err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := time.Now().UTC()
+	if err != nil {
+		j.status.Errors = append(j.status.Errors, err.Error())
+		j.setStage(StageFailed)
+	} else {
+		j.setStage(StageComplete)
+	}
+	if j.started {
+		importsInFlight.Dec()
+	}
+	j.status.InFlight = false
+	j.status.FinishedAt = &now
+	if j.started {
+		endSpan(j.span, err)
+	}
+}
+
+// cleanup is the compensating step for a failed import, it removes the partial upload root
+func (j *Job) cleanup(logData log.Data, err error) {
+	if j.uploadRoot == nil {
+		return
+	}
+	if j.resumable && (j.WillRetry(err) || CategoryOf(err) == CategoryInterrupted) {
+		// recorded as failed, so it is garbage collected if the retry or redelivery never comes
+		logData["cleanup"] = "kept for retry"
+		j.uploadRoot.Status = RootStatusFailed
+		j.recordUploadRoot()
+		return
+	}
+
+	if !j.cleanupFailed {
+		logData["cleanup"] = "garbage collection"
+		j.uploadRoot.Status = RootStatusFailed
+		j.recordUploadRoot()
+		return
+	}
+
+	err = j.uploadService.DeleteRoot(j.ctx, j.uploadRoot.Path)
+	if err == nil {
+		logData["cleanup"] = "deleted"
+		if j.roots != nil {
+			if err = j.roots.Remove(j.ctx, j.uploadRoot.Path); err != nil {
+				log.Warn(j.ctx, "failed to remove deleted upload root from store", log.Data{"path": j.uploadRoot.Path, "error": err.Error()})
+			}
+		}
+		return
+	}
+
+	log.Warn(j.ctx, "failed to delete partial upload root", log.Data{"path": j.uploadRoot.Path, "error": err.Error()})
+	logData["cleanup"] = "garbage collection"
+	j.uploadRoot.Status = RootStatusFailed
+	j.recordUploadRoot()
+}
+
+func (j *Job) recordUploadRoot() {
+	if j.roots == nil {
+		return
+	}
+	if err := j.roots.Put(j.ctx, *j.uploadRoot); err != nil {
+		log.Warn(j.ctx, "failed to record upload root", log.Data{"path": j.uploadRoot.Path, "status": j.uploadRoot.Status, "error": err.Error()})
 	}
 }
@@ -3,6 +3,11 @@ package importer_test
 import (
 	"context"
 	"errors"
+	"net/http"
+	"os"
+	"time"
+
+	dperrors "github.com/ONSdigital/dp-api-clients-go/v2/errors"
 	"github.com/ONSdigital/dp-api-clients-go/v2/interactives"
 	"github.com/ONSdigital/dp-interactives-importer/config"
 	"github.com/ONSdigital/dp-interactives-importer/importer"
@@ -18,6 +23,16 @@ var (
 	cfg = &config.Config{}
 )
 
+type deletingBackend struct {
+	*mocks_importer.UploadServiceBackendMock
+	deleted []string
+}
+
+func (b *deletingBackend) DeleteRoot(_ context.Context, uploadRootPath string) error {
+	b.deleted = append(b.deleted, uploadRootPath)
+	return nil
+}
+
 func TestJobFinish(t *testing.T) {
 	anErr := errors.New("an error")
 	logData := log.Data{}
@@ -52,9 +67,170 @@ func TestJobFinish(t *testing.T) {
 			Convey("Then there should be an expected error when we add new files concurrently", func() {
 				mockPatchReq := mockInteractivesAPI.PatchInteractiveCalls()[0].PatchRequest
 				So(mockPatchReq.Interactive.Archive.ImportSuccessful, ShouldBeFalse)
-				So(mockPatchReq.Interactive.Archive.ImportMessage, ShouldEqual, "an error")
+				report := decodeReport(mockPatchReq.Interactive.Archive.ImportMessage)
+				So(report.Successful, ShouldBeFalse)
+				So(report.Category, ShouldEqual, importer.CategoryUnknown)
+				So(report.Message, ShouldEqual, importer.CategoryUnknown.EditorMessage())
+				So(report.Errors, ShouldResemble, []importer.ReportEntry{{Code: importer.CodeImportFailed, Stage: importer.StageQueued, Message: "an error"}})
+			})
+		})
+
+		Convey("And a successful upload job with an import message", func() {
+			var err error
+			zipSize := int64(10)
+
+			uploadJob := importer.NewJob(context.TODO(), cfg, mockInteractivesAPI)
+			uploadJob.SetImportMessage("1 added, 0 changed, 0 unchanged, 0 removed")
+			uploadJob.Finish(&logData, event, rootPath, &zipSize, &err)
+
+			Convey("Then the import message should be sent with the successful import", func() {
+				mockPatchReq := mockInteractivesAPI.PatchInteractiveCalls()[0].PatchRequest
+				So(mockPatchReq.Interactive.Archive.ImportSuccessful, ShouldBeTrue)
+				So(mockPatchReq.Interactive.Archive.Size, ShouldEqual, 10)
+				report := decodeReport(mockPatchReq.Interactive.Archive.ImportMessage)
+				So(report.Successful, ShouldBeTrue)
+				So(report.Message, ShouldEqual, "1 added, 0 changed, 0 unchanged, 0 removed")
+				So(report.Errors, ShouldBeEmpty)
+			})
+		})
+
+		Convey("And a failed upload job that created an upload root", func() {
+			dir, err := os.MkdirTemp("", "roots_*")
+			So(err, ShouldBeNil)
+			defer os.RemoveAll(dir)
+			roots, err := importer.NewFileUploadRootStore(dir)
+			So(err, ShouldBeNil)
+
+			Convey("When cleanup is enabled and the upload backend can delete", func() {
+				backend := &deletingBackend{UploadServiceBackendMock: &mocks_importer.UploadServiceBackendMock{}}
+				uploadJob := importer.NewJob(context.TODO(), &config.Config{CleanupFailedImports: true}, mockInteractivesAPI)
+				uploadJob.TrackUploadRoot(importer.NewUploadService(backend), roots, event.ID, "interactives/1/abc")
+				uploadJob.Finish(&logData, event, "interactives/1/abc", nil, &anErr)
+
+				Convey("Then the partial upload root should be deleted and forgotten", func() {
+					So(backend.deleted, ShouldResemble, []string{"interactives/1/abc"})
+					list, err := roots.List(context.TODO())
+					So(err, ShouldBeNil)
+					So(list, ShouldBeEmpty)
+				})
+			})
+
+			Convey("When cleanup is disabled", func() {
+				backend := &deletingBackend{UploadServiceBackendMock: &mocks_importer.UploadServiceBackendMock{}}
+				uploadJob := importer.NewJob(context.TODO(), cfg, mockInteractivesAPI)
+				uploadJob.TrackUploadRoot(importer.NewUploadService(backend), roots, event.ID, "interactives/1/abc")
+				uploadJob.Finish(&logData, event, "interactives/1/abc", nil, &anErr)
+
+				Convey("Then the partial upload root should be left for garbage collection", func() {
+					So(backend.deleted, ShouldBeEmpty)
+					list, err := roots.List(context.TODO())
+					So(err, ShouldBeNil)
+					So(list, ShouldHaveLength, 1)
+					So(list[0].Status, ShouldEqual, importer.RootStatusFailed)
+				})
+			})
+
+			Convey("When the upload backend cannot delete", func() {
+				backend := &mocks_importer.UploadServiceBackendMock{}
+				uploadJob := importer.NewJob(context.TODO(), &config.Config{CleanupFailedImports: true}, mockInteractivesAPI)
+				uploadJob.TrackUploadRoot(importer.NewUploadService(backend), roots, event.ID, "interactives/1/abc")
+				uploadJob.Finish(&logData, event, "interactives/1/abc", nil, &anErr)
+
+				Convey("Then the partial upload root should be recorded for garbage collection", func() {
+					list, err := roots.List(context.TODO())
+					So(err, ShouldBeNil)
+					So(list, ShouldHaveLength, 1)
+					So(list[0].Path, ShouldEqual, "interactives/1/abc")
+					So(list[0].InteractiveID, ShouldEqual, "1")
+					So(list[0].Status, ShouldEqual, importer.RootStatusFailed)
+				})
+			})
+		})
+	})
+
+	Convey("Given an interactives api that is unavailable", t, func() {
+		var patchErrs []error
+		mockInteractivesAPI = &mocks_importer.InteractivesAPIClientMock{
+			PatchInteractiveFunc: func(context.Context, string, string, string, interactives.PatchRequest) (interactives.Interactive, error) {
+				if len(patchErrs) == 0 {
+					return interactives.Interactive{}, nil
+				}
+				err := patchErrs[0]
+				if len(patchErrs) > 1 {
+					patchErrs = patchErrs[1:]
+				}
+				return interactives.Interactive{}, err
+			},
+		}
+		unavailable := &importer.StatusError{StatusCode: http.StatusServiceUnavailable, Err: dperrors.NewErrorFromUnhandledStatusCode("interactives-api", http.StatusServiceUnavailable)}
+		retryCfg := &config.Config{PatchRetries: 2, PatchRetryBackoff: time.Millisecond}
+		finish := func() error {
+			var err error
+			uploadJob := importer.NewJob(context.TODO(), retryCfg, mockInteractivesAPI)
+			uploadJob.Finish(&log.Data{}, event, rootPath, nil, &err)
+			return err
+		}
+
+		Convey("When it recovers before the retries run out", func() {
+			patchErrs = []error{unavailable, nil}
+			err := finish()
+
+			Convey("Then the outcome should be reported", func() {
+				So(err, ShouldBeNil)
+				So(mockInteractivesAPI.PatchInteractiveCalls(), ShouldHaveLength, 2)
+			})
+		})
+
+		Convey("When it stays unavailable", func() {
+			patchErrs = []error{unavailable}
+			err := finish()
+
+			Convey("Then the import should fail as not reported, and not be imported again", func() {
+				So(errors.Is(err, importer.ErrNotReported), ShouldBeTrue)
+				So(importer.Retryable(err), ShouldBeFalse)
+				So(mockInteractivesAPI.PatchInteractiveCalls(), ShouldHaveLength, 3)
 			})
 		})
 
+		Convey("When it times out for an import that is retried on failure", func() {
+			patchErrs = []error{&importer.TimeoutError{Stage: importer.StagePatch, Err: context.DeadlineExceeded}}
+			var err error
+			uploadJob := importer.NewJob(context.TODO(), retryCfg, mockInteractivesAPI)
+			uploadJob.RetryOnFailure()
+			uploadJob.Finish(&log.Data{}, event, rootPath, nil, &err)
+
+			Convey("Then the import should not be tried again, only its report", func() {
+				So(errors.Is(err, importer.ErrNotReported), ShouldBeTrue)
+				So(uploadJob.WillRetry(err), ShouldBeFalse)
+
+				patchErrs = []error{nil}
+				So(uploadJob.ReportAgain(context.TODO()), ShouldBeNil)
+				calls := mockInteractivesAPI.PatchInteractiveCalls()
+				So(calls, ShouldHaveLength, 4)
+				So(calls[3].PatchRequest, ShouldResemble, calls[0].PatchRequest)
+			})
+		})
+
+		Convey("When it stays unavailable for a failed import", func() {
+			patchErrs = []error{unavailable}
+			err := error(&importer.ImportError{Category: importer.CategoryCorruptArchive, Err: anErr})
+			uploadJob := importer.NewJob(context.TODO(), retryCfg, mockInteractivesAPI)
+			uploadJob.Finish(&log.Data{}, event, rootPath, nil, &err)
+
+			Convey("Then the import should keep its category and fail as not reported", func() {
+				So(errors.Is(err, importer.ErrNotReported), ShouldBeTrue)
+				So(importer.CategoryOf(err), ShouldEqual, importer.CategoryCorruptArchive)
+			})
+		})
+
+		Convey("When it rejects the update", func() {
+			patchErrs = []error{&importer.StatusError{StatusCode: http.StatusBadRequest, Err: interactives.NewInteractivesAPIResponse(&http.Response{StatusCode: http.StatusBadRequest}, "/v1/interactives/1")}}
+			err := finish()
+
+			Convey("Then it should not be retried, as it would never be accepted", func() {
+				So(err, ShouldBeNil)
+				So(mockInteractivesAPI.PatchInteractiveCalls(), ShouldHaveLength, 1)
+			})
+		})
 	})
 }
@@ -0,0 +1,88 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ONSdigital/dp-healthcheck/healthcheck"
+)
+
+//go:generate moq -out mocks/lag.go -pkg mocks_importer . LagReader
+
+// PartitionLag is how far the consumer group is behind the end of one partition
+type PartitionLag struct {
+	Partition int32
+	Lag       int64
+	// OldestUnprocessed is when the next message to process was produced, zero if the partition is caught up
+	OldestUnprocessed time.Time
+}
+
+// LagReader reads the lag of the consumer group on each partition of the consumed topic
+type LagReader interface {
+	Lag(ctx context.Context) ([]PartitionLag, error)
+}
+
+// ProcessingChecker reports whether imports are keeping up with events. It warns while an import has run longer
+// than MaxImportDuration, or when a partition's lag or oldest unprocessed message crosses its threshold. It is only
+// critical while an import has outlived ImportTimeout, as it should have been cancelled and is holding its worker.
+type ProcessingChecker struct {
+	Registry          *Registry
+	Lag               LagReader // optional, only import durations are checked without it
+	MaxLag            int64
+	MaxMessageAge     time.Duration
+	MaxImportDuration time.Duration
+	ImportTimeout     time.Duration // zero if imports have no deadline
+}
+
+func (c *ProcessingChecker) Checker(ctx context.Context, state *healthcheck.CheckState) error {
+	now := time.Now().UTC()
+
+	var warnings []string
+	if inFlight := c.Registry.InFlight(); len(inFlight) > 0 {
+		oldest := inFlight[0]
+		running := now.Sub(oldest.StartedAt)
+		msg := fmt.Sprintf("import %s of interactive %s has been running for %s, in stage %s", oldest.ID, oldest.InteractiveID, running.Round(time.Second), oldest.Stage)
+		if c.ImportTimeout > 0 && running > c.ImportTimeout {
+			return state.Update(healthcheck.StatusCritical, msg+", past its timeout of "+c.ImportTimeout.String(), 0)
+		}
+		if running > c.MaxImportDuration {
+			warnings = append(warnings, msg)
+		}
+	}
+
+	if c.Lag == nil {
+		if len(warnings) > 0 {
+			return state.Update(healthcheck.StatusWarning, strings.Join(warnings, "; "), 0)
+		}
+		return state.Update(healthcheck.StatusOK, "no import running longer than "+c.MaxImportDuration.String(), 0)
+	}
+
+	partitions, err := c.Lag.Lag(ctx)
+	if err != nil {
+		warnings = append(warnings, "cannot read consumer lag: "+err.Error())
+		return state.Update(healthcheck.StatusWarning, strings.Join(warnings, "; "), 0)
+	}
+
+	var total int64
+	var behind []string
+	for _, p := range partitions {
+		total += p.Lag
+		if p.Lag > c.MaxLag {
+			behind = append(behind, fmt.Sprintf("partition %d lag %d", p.Partition, p.Lag))
+		}
+		if !p.OldestUnprocessed.IsZero() {
+			if age := now.Sub(p.OldestUnprocessed); age > c.MaxMessageAge {
+				behind = append(behind, fmt.Sprintf("partition %d oldest unprocessed message %s old", p.Partition, age.Round(time.Second)))
+			}
+		}
+	}
+	if len(behind) > 0 {
+		warnings = append(warnings, "consumer falling behind: "+strings.Join(behind, ", "))
+	}
+	if len(warnings) > 0 {
+		return state.Update(healthcheck.StatusWarning, strings.Join(warnings, "; "), 0)
+	}
+	return state.Update(healthcheck.StatusOK, fmt.Sprintf("consumer lag %d across %d partitions", total, len(partitions)), 0)
+}
@@ -0,0 +1,123 @@
+package importer_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-healthcheck/healthcheck"
+	"github.com/ONSdigital/dp-interactives-importer/importer"
+	mocks_importer "github.com/ONSdigital/dp-interactives-importer/importer/mocks"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestProcessingChecker(t *testing.T) {
+
+	Convey("Given a processing checker", t, func() {
+		registry := importer.NewRegistry(10)
+		var lags []importer.PartitionLag
+		var lagErr error
+		checker := &importer.ProcessingChecker{
+			Registry: registry,
+			Lag: &mocks_importer.LagReaderMock{
+				LagFunc: func(context.Context) ([]importer.PartitionLag, error) {
+					return lags, lagErr
+				},
+			},
+			MaxLag:            10,
+			MaxMessageAge:     time.Minute,
+			MaxImportDuration: time.Hour,
+		}
+		check := func() *healthcheck.CheckState {
+			state := healthcheck.NewCheckState("Import processing")
+			So(checker.Checker(context.TODO(), state), ShouldBeNil)
+			return state
+		}
+
+		Convey("When the consumer is keeping up", func() {
+			lags = []importer.PartitionLag{{Partition: 0, Lag: 2, OldestUnprocessed: time.Now().Add(-time.Second)}, {Partition: 1}}
+
+			Convey("Then the check should be OK", func() {
+				state := check()
+				So(state.Status(), ShouldEqual, healthcheck.StatusOK)
+				So(state.Message(), ShouldEqual, "consumer lag 2 across 2 partitions")
+			})
+		})
+
+		Convey("When a partition lags more than the maximum", func() {
+			lags = []importer.PartitionLag{{Partition: 3, Lag: 11, OldestUnprocessed: time.Now()}}
+
+			Convey("Then the check should warn", func() {
+				state := check()
+				So(state.Status(), ShouldEqual, healthcheck.StatusWarning)
+				So(state.Message(), ShouldContainSubstring, "partition 3 lag 11")
+			})
+		})
+
+		Convey("When the oldest unprocessed message is older than the maximum", func() {
+			lags = []importer.PartitionLag{{Partition: 0, Lag: 1, OldestUnprocessed: time.Now().Add(-time.Hour)}}
+
+			Convey("Then the check should warn", func() {
+				state := check()
+				So(state.Status(), ShouldEqual, healthcheck.StatusWarning)
+				So(state.Message(), ShouldContainSubstring, "oldest unprocessed message 1h0m0s old")
+			})
+		})
+
+		Convey("When the lag cannot be read", func() {
+			lagErr = errors.New("broker unavailable")
+
+			Convey("Then the check should warn", func() {
+				state := check()
+				So(state.Status(), ShouldEqual, healthcheck.StatusWarning)
+				So(state.Message(), ShouldContainSubstring, "broker unavailable")
+			})
+		})
+
+		Convey("When an import has run longer than the maximum", func() {
+			checker.MaxImportDuration = time.Millisecond
+			job := importer.NewJob(context.TODO(), cfg, &mocks_importer.InteractivesAPIClientMock{})
+			job.Start(&importer.InteractivesUploaded{ID: "stuck"})
+			registry.Add(job)
+			time.Sleep(5 * time.Millisecond)
+
+			Convey("Then the check should warn", func() {
+				state := check()
+				So(state.Status(), ShouldEqual, healthcheck.StatusWarning)
+				So(state.Message(), ShouldContainSubstring, "interactive stuck")
+			})
+
+			Convey("And longer than the import timeout", func() {
+				checker.ImportTimeout = time.Millisecond
+
+				Convey("Then the check should be critical", func() {
+					state := check()
+					So(state.Status(), ShouldEqual, healthcheck.StatusCritical)
+					So(state.Message(), ShouldContainSubstring, "past its timeout of 1ms")
+				})
+			})
+
+			Convey("And the consumer is falling behind", func() {
+				lags = []importer.PartitionLag{{Partition: 3, Lag: 11, OldestUnprocessed: time.Now()}}
+
+				Convey("Then the check should warn of both", func() {
+					state := check()
+					So(state.Status(), ShouldEqual, healthcheck.StatusWarning)
+					So(state.Message(), ShouldContainSubstring, "interactive stuck")
+					So(state.Message(), ShouldContainSubstring, "partition 3 lag 11")
+				})
+			})
+		})
+
+		Convey("When there is no lag reader", func() {
+			checker.Lag = nil
+
+			Convey("Then only imports should be checked", func() {
+				state := check()
+				So(state.Status(), ShouldEqual, healthcheck.StatusOK)
+			})
+		})
+	})
+}
@@ -0,0 +1,147 @@
+package importer
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// latencyWeight is how much each upload moves the average latency the adaptive limit is compared against
+const latencyWeight = 0.2
+
+// UploadLimiter caps the file uploads running at once across every import. An adaptive limiter halves its limit
+// when the upload service throttles or slows down, at most once a cooldown, and raises it by one after a limit's
+// worth of quick uploads.
+type UploadLimiter struct {
+	mu            sync.Mutex
+	max           int
+	min           int
+	limit         int
+	inUse         int
+	changed       chan struct{} // closed when a slot may have come free
+	adaptive      bool
+	latencyTarget time.Duration
+	latency       time.Duration // moving average
+	successes     int
+	cooldown      time.Duration
+	decreasedAt   time.Time
+}
+
+// NewUploadLimiter returns a limiter allowing max uploads at once
+func NewUploadLimiter(max int) *UploadLimiter {
+	if max < 1 {
+		max = 1
+	}
+	uploadConcurrencyLimit.Set(float64(max))
+	return &UploadLimiter{max: max, min: max, limit: max, changed: make(chan struct{})}
+}
+
+// NewAdaptiveUploadLimiter returns a limiter allowing between min and max uploads at once, starting at max, which
+// backs off when uploads are throttled or their average latency rises above latencyTarget (zero to ignore latency)
+func NewAdaptiveUploadLimiter(max, min int, latencyTarget time.Duration) *UploadLimiter {
+	l := NewUploadLimiter(max)
+	if min < 1 {
+		min = 1
+	}
+	if min < l.max {
+		l.min = min
+	}
+	l.adaptive = true
+	l.latencyTarget = latencyTarget
+	l.cooldown = time.Second
+	if latencyTarget > l.cooldown {
+		l.cooldown = latencyTarget
+	}
+	return l
+}
+
+// Acquire waits for a free slot, or until the context is done
+func (l *UploadLimiter) Acquire(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		if l.inUse < l.limit {
+			l.inUse++
+			uploadsInFlight.Inc()
+			l.mu.Unlock()
+			return nil
+		}
+		changed := l.changed
+		l.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Release frees the slot of an upload, telling an adaptive limiter how long it took and how it went
+func (l *UploadLimiter) Release(latency time.Duration, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.inUse--
+	uploadsInFlight.Dec()
+	if l.adaptive {
+		l.adapt(time.Now(), latency, err)
+	}
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+// Limit returns how many uploads may currently run at once
+func (l *UploadLimiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.limit
+}
+
+func (l *UploadLimiter) adapt(now time.Time, latency time.Duration, err error) {
+	if throttled(err) {
+		uploadsThrottledTotal.Inc()
+		l.decrease(now)
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	if l.latency == 0 {
+		l.latency = latency
+	} else {
+		l.latency += time.Duration(latencyWeight * float64(latency-l.latency))
+	}
+	if l.latencyTarget > 0 && l.latency > l.latencyTarget {
+		l.decrease(now)
+		return
+	}
+
+	l.successes++
+	if l.successes >= l.limit && l.limit < l.max {
+		l.limit++
+		l.successes = 0
+		uploadConcurrencyLimit.Set(float64(l.limit))
+	}
+}
+
+// decrease halves the limit, unless it was lowered within the cooldown, as the uploads already running at the
+// time all report the same trouble
+func (l *UploadLimiter) decrease(now time.Time) {
+	if now.Sub(l.decreasedAt) < l.cooldown {
+		return
+	}
+	l.decreasedAt = now
+	l.successes = 0
+	if l.limit /= 2; l.limit < l.min {
+		l.limit = l.min
+	}
+	uploadConcurrencyLimit.Set(float64(l.limit))
+}
+
+// throttled is true if the upload service asked for fewer requests
+func throttled(err error) bool {
+	return err != nil && statusCode(err) == http.StatusTooManyRequests
+}
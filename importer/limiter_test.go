@@ -0,0 +1,144 @@
+package importer_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	dperrors "github.com/ONSdigital/dp-api-clients-go/v2/errors"
+	"github.com/ONSdigital/dp-api-clients-go/v2/interactives"
+	"github.com/ONSdigital/dp-api-clients-go/v2/upload"
+	"github.com/ONSdigital/dp-interactives-importer/config"
+	"github.com/ONSdigital/dp-interactives-importer/importer"
+	mocks_importer "github.com/ONSdigital/dp-interactives-importer/importer/mocks"
+	"github.com/ONSdigital/dp-interactives-importer/internal/test"
+	"github.com/ONSdigital/log.go/v2/log"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestUploadLimiter(t *testing.T) {
+
+	Convey("Given a limiter of two uploads", t, func() {
+		limiter := importer.NewUploadLimiter(2)
+		So(limiter.Acquire(context.Background()), ShouldBeNil)
+		So(limiter.Acquire(context.Background()), ShouldBeNil)
+
+		Convey("Then a third upload should wait until a slot is free", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			So(errors.Is(limiter.Acquire(ctx), context.DeadlineExceeded), ShouldBeTrue)
+
+			acquired := make(chan error)
+			go func() { acquired <- limiter.Acquire(context.Background()) }()
+			limiter.Release(time.Millisecond, nil)
+			So(<-acquired, ShouldBeNil)
+		})
+
+		Convey("Then its limit should not change with how uploads go", func() {
+			limiter.Release(time.Hour, &importer.StatusError{StatusCode: 429, Err: dperrors.NewErrorFromUnhandledStatusCode("upload-service", 429)})
+			So(limiter.Limit(), ShouldEqual, 2)
+		})
+	})
+
+	Convey("Given an adaptive limiter of up to eight uploads", t, func() {
+		limiter := importer.NewAdaptiveUploadLimiter(8, 2, 100*time.Millisecond)
+		release := func(latency time.Duration, err error) {
+			So(limiter.Acquire(context.Background()), ShouldBeNil)
+			limiter.Release(latency, err)
+		}
+		tooMany := &importer.StatusError{StatusCode: 429, Err: dperrors.NewErrorFromUnhandledStatusCode("upload-service", 429)}
+
+		Convey("When the upload service throttles several uploads at once", func() {
+			release(time.Millisecond, tooMany)
+			release(time.Millisecond, tooMany)
+
+			Convey("Then the limit should be halved once", func() {
+				So(limiter.Limit(), ShouldEqual, 4)
+			})
+
+			Convey("And then uploads go quickly, the limit should rise again one at a time", func() {
+				for i := 0; i < 4; i++ {
+					release(time.Millisecond, nil)
+				}
+				So(limiter.Limit(), ShouldEqual, 5)
+			})
+		})
+
+		Convey("When uploads slow down past the latency target", func() {
+			release(time.Second, nil)
+
+			Convey("Then the limit should be halved", func() {
+				So(limiter.Limit(), ShouldEqual, 4)
+			})
+		})
+
+		Convey("When uploads fail for other reasons", func() {
+			release(time.Millisecond, errors.New("bad request"))
+
+			Convey("Then the limit should be left alone", func() {
+				So(limiter.Limit(), ShouldEqual, 8)
+			})
+		})
+	})
+}
+
+func TestHandlerUploadLimit(t *testing.T) {
+
+	Convey("Given two imports sharing a limiter of two uploads", t, func() {
+		archive, err := test.CreateTestZip("a.html", "b.html", "c.html", "d.html", "e.html", "f.html")
+		So(err, ShouldBeNil)
+		defer os.Remove(archive)
+
+		var mu sync.Mutex
+		var running, maxRunning int
+		backend := &mocks_importer.UploadServiceBackendMock{
+			UploadFunc: func(context.Context, io.ReadCloser, upload.Metadata) error {
+				mu.Lock()
+				running++
+				if running > maxRunning {
+					maxRunning = running
+				}
+				mu.Unlock()
+				time.Sleep(5 * time.Millisecond)
+				mu.Lock()
+				running--
+				mu.Unlock()
+				return nil
+			},
+		}
+		handler := &importer.InteractivesUploadedHandler{
+			Cfg:           &config.Config{BatchSize: 5, TempDir: t.TempDir()},
+			UploadService: importer.NewUploadService(backend),
+			Uploads:       importer.NewUploadLimiter(2),
+			InteractivesAPIClient: &mocks_importer.InteractivesAPIClientMock{
+				PatchInteractiveFunc: func(context.Context, string, string, string, interactives.PatchRequest) (interactives.Interactive, error) {
+					return interactives.Interactive{}, nil
+				},
+			},
+		}
+
+		Convey("When they run at the same time", func() {
+			var wg sync.WaitGroup
+			errs := make([]error, 2)
+			for i := range errs {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					event := &importer.InteractivesUploaded{ID: "1", Path: "archive.zip"}
+					errs[i] = handler.Import(context.Background(), handler.NewJob(context.Background(), event), event, archive, log.Data{})
+				}(i)
+			}
+			wg.Wait()
+
+			Convey("Then no more than two files should be uploading at once", func() {
+				So(errs, ShouldResemble, []error{nil, nil})
+				So(maxRunning, ShouldBeLessThanOrEqualTo, 2)
+				So(backend.UploadCalls(), ShouldHaveLength, 12)
+			})
+		})
+	})
+}
@@ -0,0 +1,171 @@
+package importer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Manifest records every file stored for an interactive by an import, keyed on the file name within the archive
+type Manifest struct {
+	InteractiveID  string                   `json:"interactive_id"`
+	UploadRootPath string                   `json:"upload_root_path"`
+	CreatedAt      time.Time                `json:"created_at"`
+	Files          map[string]ManifestEntry `json:"files"`
+
+	mu sync.Mutex
+}
+
+type ManifestEntry struct {
+	Hash        string `json:"hash"`
+	SizeInBytes int64  `json:"size_in_bytes"`
+	MimeType    string `json:"mime_type"`
+}
+
+// ManifestDiff lists file names by how they changed between two imports
+type ManifestDiff struct {
+	Added     []string `json:"added"`
+	Changed   []string `json:"changed"`
+	Unchanged []string `json:"unchanged"`
+	Removed   []string `json:"removed"`
+}
+
+// ManifestStore keeps the manifest of the last successful import of each interactive
+type ManifestStore interface {
+	// Get returns nil when the interactive has not been imported before
+	Get(ctx context.Context, interactiveID string) (*Manifest, error)
+	Put(ctx context.Context, m *Manifest) error
+}
+
+func NewManifest(interactiveID, uploadRootPath string) *Manifest {
+	return &Manifest{
+		InteractiveID:  interactiveID,
+		UploadRootPath: uploadRootPath,
+		CreatedAt:      time.Now().UTC(),
+		Files:          make(map[string]ManifestEntry),
+	}
+}
+
+// Add is safe to call concurrently
+func (m *Manifest) Add(f *File) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.Files[f.Name] = ManifestEntry{
+		Hash:        f.Hash,
+		SizeInBytes: f.SizeInBytes,
+		MimeType:    f.MimeType,
+	}
+}
+
+// Unchanged is true if the previous manifest holds a file with the same name and content
+func (m *Manifest) Unchanged(name, hash string) bool {
+	if m == nil {
+		return false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.Files[name]
+	return ok && e.Hash == hash
+}
+
+// Diff compares this manifest against the previous one, a nil previous means every file was added
+func (m *Manifest) Diff(previous *Manifest) ManifestDiff {
+	var d ManifestDiff
+	for name, e := range m.Files {
+		switch {
+		case previous == nil:
+			d.Added = append(d.Added, name)
+		case !previous.has(name):
+			d.Added = append(d.Added, name)
+		case previous.Files[name].Hash != e.Hash:
+			d.Changed = append(d.Changed, name)
+		default:
+			d.Unchanged = append(d.Unchanged, name)
+		}
+	}
+	if previous != nil {
+		for name := range previous.Files {
+			if !m.has(name) {
+				d.Removed = append(d.Removed, name)
+			}
+		}
+	}
+
+	sort.Strings(d.Added)
+	sort.Strings(d.Changed)
+	sort.Strings(d.Unchanged)
+	sort.Strings(d.Removed)
+	return d
+}
+
+func (m *Manifest) has(name string) bool {
+	_, ok := m.Files[name]
+	return ok
+}
+
+func (d ManifestDiff) String() string {
+	return fmt.Sprintf("%d added, %d changed, %d unchanged, %d removed", len(d.Added), len(d.Changed), len(d.Unchanged), len(d.Removed))
+}
+
+// FileManifestStore keeps one JSON document per interactive in a local directory
+type FileManifestStore struct {
+	dir string
+}
+
+func NewFileManifestStore(dir string) (*FileManifestStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileManifestStore{dir: dir}, nil
+}
+
+func (s *FileManifestStore) Get(_ context.Context, interactiveID string) (*Manifest, error) {
+	b, err := os.ReadFile(s.path(interactiveID))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var m Manifest
+	if err = json.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("cannot read manifest for %s %w", interactiveID, err)
+	}
+	return &m, nil
+}
+
+func (s *FileManifestStore) Put(_ context.Context, m *Manifest) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	// write then rename so a crash never leaves a truncated manifest behind
+	tmp, err := os.CreateTemp(s.dir, "manifest_*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path(m.InteractiveID))
+}
+
+func (s *FileManifestStore) path(interactiveID string) string {
+	return filepath.Join(s.dir, url.PathEscape(interactiveID)+".json")
+}
@@ -0,0 +1,85 @@
+package importer_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/ONSdigital/dp-interactives-importer/importer"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestManifestDiff(t *testing.T) {
+
+	Convey("Given the manifest of a previous import", t, func() {
+		previous := importer.NewManifest("id", "interactives/id/abc")
+		previous.Add(&importer.File{Name: "index.html", Hash: "1"})
+		previous.Add(&importer.File{Name: "data.csv", Hash: "2"})
+		previous.Add(&importer.File{Name: "old.js", Hash: "3"})
+
+		Convey("And a re-import with one changed, one added and one removed file", func() {
+			current := importer.NewManifest("id", "interactives/id/abc")
+			current.Add(&importer.File{Name: "index.html", Hash: "1"})
+			current.Add(&importer.File{Name: "data.csv", Hash: "22"})
+			current.Add(&importer.File{Name: "new.js", Hash: "4"})
+
+			Convey("Then the diff should report each change", func() {
+				d := current.Diff(previous)
+				So(d.Added, ShouldResemble, []string{"new.js"})
+				So(d.Changed, ShouldResemble, []string{"data.csv"})
+				So(d.Unchanged, ShouldResemble, []string{"index.html"})
+				So(d.Removed, ShouldResemble, []string{"old.js"})
+				So(d.String(), ShouldEqual, "1 added, 1 changed, 1 unchanged, 1 removed")
+			})
+
+			Convey("Then only unchanged files should be carried over", func() {
+				So(previous.Unchanged("index.html", "1"), ShouldBeTrue)
+				So(previous.Unchanged("data.csv", "22"), ShouldBeFalse)
+				So(previous.Unchanged("new.js", "4"), ShouldBeFalse)
+			})
+		})
+	})
+
+	Convey("Given no previous import", t, func() {
+		var previous *importer.Manifest
+		current := importer.NewManifest("id", "interactives/id/abc")
+		current.Add(&importer.File{Name: "index.html", Hash: "1"})
+
+		Convey("Then every file should be added", func() {
+			So(previous.Unchanged("index.html", "1"), ShouldBeFalse)
+			So(current.Diff(previous).Added, ShouldResemble, []string{"index.html"})
+		})
+	})
+}
+
+func TestFileManifestStore(t *testing.T) {
+
+	Convey("Given a manifest store in an empty directory", t, func() {
+		dir, err := os.MkdirTemp("", "manifests_*")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+
+		store, err := importer.NewFileManifestStore(dir)
+		So(err, ShouldBeNil)
+
+		Convey("Then an interactive never imported should have no manifest", func() {
+			m, err := store.Get(context.TODO(), "unknown")
+			So(err, ShouldBeNil)
+			So(m, ShouldBeNil)
+		})
+
+		Convey("When a manifest is stored", func() {
+			m := importer.NewManifest("an/id", "interactives/an/id/abc")
+			m.Add(&importer.File{Name: "index.html", Hash: "1", SizeInBytes: 10, MimeType: "text/html"})
+			So(store.Put(context.TODO(), m), ShouldBeNil)
+
+			Convey("Then it should be read back", func() {
+				got, err := store.Get(context.TODO(), "an/id")
+				So(err, ShouldBeNil)
+				So(got.UploadRootPath, ShouldEqual, "interactives/an/id/abc")
+				So(got.Files, ShouldResemble, m.Files)
+			})
+		})
+	})
+}
@@ -0,0 +1,104 @@
+package importer
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const metricsNamespace = "interactives_importer"
+
+var (
+	importsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "imports_total",
+		Help:      "Finished imports by outcome, and the stage and category of a failed import",
+	}, []string{"outcome", "stage", "category"})
+
+	importsInFlight = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "imports_in_flight",
+		Help:      "Imports currently running",
+	})
+
+	stageDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "stage_duration_seconds",
+		Help:      "Time spent in each stage of an import",
+		Buckets:   []float64{0.1, 0.5, 1, 5, 10, 30, 60, 120, 300, 600, 1800},
+	}, []string{"stage"})
+
+	fileUploadRetriesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "file_upload_retries_total",
+		Help:      "File uploads retried, by the category of the failure",
+	}, []string{"category"})
+
+	importRetriesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "import_retries_total",
+		Help:      "Imports of an event tried again, by the category of the failure",
+	}, []string{"category"})
+
+	uploadsInFlight = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "uploads_in_flight",
+		Help:      "File uploads currently holding a slot of the upload limiter",
+	})
+
+	uploadConcurrencyLimit = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "upload_concurrency_limit",
+		Help:      "File uploads allowed at once across every import",
+	})
+
+	uploadsThrottledTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "uploads_throttled_total",
+		Help:      "File uploads the upload service turned away with 429 Too Many Requests",
+	})
+
+	importsResumedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "imports_resumed_total",
+		Help:      "Number of imports that carried on from the checkpoint of an earlier attempt",
+	})
+
+	circuitBreakerState = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "circuit_breaker_state",
+		Help:      "State of each circuit breaker: 0 closed, 1 half-open, 2 open",
+	}, []string{"name"})
+
+	fileUploadDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "file_upload_duration_seconds",
+		Help:      "Latency of uploading a single file to the upload backend",
+		Buckets:   prometheus.DefBuckets,
+	})
+
+	filesProcessedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "files_processed_total",
+		Help:      "Files in archives passed through a processor, by result",
+	}, []string{"result"})
+
+	archiveSize = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "archive_size_bytes",
+		Help:      "Size of imported archives",
+		Buckets:   prometheus.ExponentialBuckets(64*1024, 4, 10), // 64KiB to 16GiB
+	})
+
+	archiveFiles = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "archive_files",
+		Help:      "Number of files in imported archives",
+		Buckets:   prometheus.ExponentialBuckets(1, 4, 9), // 1 to 65536
+	})
+
+	tempDiskUsage = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "temp_disk_usage_bytes",
+		Help:      "Bytes of archives held on local disk by running imports",
+	})
+)
@@ -0,0 +1,77 @@
+package importer_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ONSdigital/dp-api-clients-go/v2/interactives"
+	"github.com/ONSdigital/dp-interactives-importer/importer"
+	mocks_importer "github.com/ONSdigital/dp-interactives-importer/importer/mocks"
+	"github.com/ONSdigital/log.go/v2/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMetrics(t *testing.T) {
+	mockInteractivesAPI := &mocks_importer.InteractivesAPIClientMock{
+		PatchInteractiveFunc: func(context.Context, string, string, string, interactives.PatchRequest) (interactives.Interactive, error) {
+			return interactives.Interactive{}, nil
+		},
+	}
+
+	Convey("Given an import that fails while uploading", t, func() {
+		labels := map[string]string{"outcome": "failure", "stage": importer.StageUpload, "category": string(importer.CategoryStorageUnavailable)}
+		before := counterValue("interactives_importer_imports_total", labels)
+
+		var err error = &importer.ImportError{Category: importer.CategoryStorageUnavailable, Err: errors.New("upload failed")}
+		job := importer.NewJob(context.TODO(), cfg, mockInteractivesAPI)
+		job.Start(&importer.InteractivesUploaded{ID: "1"})
+		So(gaugeValue("interactives_importer_imports_in_flight"), ShouldBeGreaterThanOrEqualTo, 1)
+		job.SetStage(importer.StageUpload)
+		job.Finish(&log.Data{}, &importer.InteractivesUploaded{ID: "1"}, "", nil, &err)
+
+		Convey("Then the failure should be counted against the upload stage and its category", func() {
+			after := counterValue("interactives_importer_imports_total", labels)
+			So(after-before, ShouldEqual, 1)
+		})
+	})
+}
+
+func counterValue(name string, labels map[string]string) float64 {
+	if m := findMetric(name, labels); m != nil {
+		return m.GetCounter().GetValue()
+	}
+	return 0
+}
+
+func gaugeValue(name string) float64 {
+	if m := findMetric(name, nil); m != nil {
+		return m.GetGauge().GetValue()
+	}
+	return 0
+}
+
+func findMetric(name string, labels map[string]string) *dto.Metric {
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		return nil
+	}
+	for _, f := range families {
+		if f.GetName() != name {
+			continue
+		}
+	metrics:
+		for _, m := range f.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if v, ok := labels[l.GetName()]; ok && v != l.GetValue() {
+					continue metrics
+				}
+			}
+			return m
+		}
+	}
+	return nil
+}
@@ -17,26 +17,32 @@ var _ importer.InteractivesAPIClient = &InteractivesAPIClientMock{}
 
 // InteractivesAPIClientMock is a mock implementation of importer.InteractivesAPIClient.
 //
-// 	func TestSomethingThatUsesInteractivesAPIClient(t *testing.T) {
+//	func TestSomethingThatUsesInteractivesAPIClient(t *testing.T) {
 //
-// 		// make and configure a mocked importer.InteractivesAPIClient
-// 		mockedInteractivesAPIClient := &InteractivesAPIClientMock{
-// 			CheckerFunc: func(ctx context.Context, state *health.CheckState) error {
-// 				panic("mock out the Checker method")
-// 			},
-// 			PatchInteractiveFunc: func(contextMoqParam context.Context, s1 string, s2 string, s3 string, patchRequest interactives.PatchRequest) (interactives.Interactive, error) {
-// 				panic("mock out the PatchInteractive method")
-// 			},
-// 		}
+//		// make and configure a mocked importer.InteractivesAPIClient
+//		mockedInteractivesAPIClient := &InteractivesAPIClientMock{
+//			CheckerFunc: func(ctx context.Context, state *health.CheckState) error {
+//				panic("mock out the Checker method")
+//			},
+//			GetInteractiveFunc: func(contextMoqParam context.Context, s1 string, s2 string, s3 string) (interactives.Interactive, error) {
+//				panic("mock out the GetInteractive method")
+//			},
+//			PatchInteractiveFunc: func(contextMoqParam context.Context, s1 string, s2 string, s3 string, patchRequest interactives.PatchRequest) (interactives.Interactive, error) {
+//				panic("mock out the PatchInteractive method")
+//			},
+//		}
 //
-// 		// use mockedInteractivesAPIClient in code that requires importer.InteractivesAPIClient
-// 		// and then make assertions.
+//		// use mockedInteractivesAPIClient in code that requires importer.InteractivesAPIClient
+//		// and then make assertions.
 //
-// 	}
+//	}
 type InteractivesAPIClientMock struct {
 	// CheckerFunc mocks the Checker method.
 	CheckerFunc func(ctx context.Context, state *health.CheckState) error
 
+	// GetInteractiveFunc mocks the GetInteractive method.
+	GetInteractiveFunc func(contextMoqParam context.Context, s1 string, s2 string, s3 string) (interactives.Interactive, error)
+
 	// PatchInteractiveFunc mocks the PatchInteractive method.
 	PatchInteractiveFunc func(contextMoqParam context.Context, s1 string, s2 string, s3 string, patchRequest interactives.PatchRequest) (interactives.Interactive, error)
 
@@ -49,6 +55,17 @@ type InteractivesAPIClientMock struct {
 			// State is the state argument value.
 			State *health.CheckState
 		}
+		// GetInteractive holds details about calls to the GetInteractive method.
+		GetInteractive []struct {
+			// ContextMoqParam is the contextMoqParam argument value.
+			ContextMoqParam context.Context
+			// S1 is the s1 argument value.
+			S1 string
+			// S2 is the s2 argument value.
+			S2 string
+			// S3 is the s3 argument value.
+			S3 string
+		}
 		// PatchInteractive holds details about calls to the PatchInteractive method.
 		PatchInteractive []struct {
 			// ContextMoqParam is the contextMoqParam argument value.
@@ -64,6 +81,7 @@ type InteractivesAPIClientMock struct {
 		}
 	}
 	lockChecker          sync.RWMutex
+	lockGetInteractive   sync.RWMutex
 	lockPatchInteractive sync.RWMutex
 }
 
@@ -87,7 +105,8 @@ func (mock *InteractivesAPIClientMock) Checker(ctx context.Context, state *healt
 
 // CheckerCalls gets all the calls that were made to Checker.
 // Check the length with:
-//     len(mockedInteractivesAPIClient.CheckerCalls())
+//
+//	len(mockedInteractivesAPIClient.CheckerCalls())
 func (mock *InteractivesAPIClientMock) CheckerCalls() []struct {
 	Ctx   context.Context
 	State *health.CheckState
@@ -102,6 +121,50 @@ func (mock *InteractivesAPIClientMock) CheckerCalls() []struct {
 	return calls
 }
 
+// GetInteractive calls GetInteractiveFunc.
+func (mock *InteractivesAPIClientMock) GetInteractive(contextMoqParam context.Context, s1 string, s2 string, s3 string) (interactives.Interactive, error) {
+	if mock.GetInteractiveFunc == nil {
+		panic("InteractivesAPIClientMock.GetInteractiveFunc: method is nil but InteractivesAPIClient.GetInteractive was just called")
+	}
+	callInfo := struct {
+		ContextMoqParam context.Context
+		S1              string
+		S2              string
+		S3              string
+	}{
+		ContextMoqParam: contextMoqParam,
+		S1:              s1,
+		S2:              s2,
+		S3:              s3,
+	}
+	mock.lockGetInteractive.Lock()
+	mock.calls.GetInteractive = append(mock.calls.GetInteractive, callInfo)
+	mock.lockGetInteractive.Unlock()
+	return mock.GetInteractiveFunc(contextMoqParam, s1, s2, s3)
+}
+
+// GetInteractiveCalls gets all the calls that were made to GetInteractive.
+// Check the length with:
+//
+//	len(mockedInteractivesAPIClient.GetInteractiveCalls())
+func (mock *InteractivesAPIClientMock) GetInteractiveCalls() []struct {
+	ContextMoqParam context.Context
+	S1              string
+	S2              string
+	S3              string
+} {
+	var calls []struct {
+		ContextMoqParam context.Context
+		S1              string
+		S2              string
+		S3              string
+	}
+	mock.lockGetInteractive.RLock()
+	calls = mock.calls.GetInteractive
+	mock.lockGetInteractive.RUnlock()
+	return calls
+}
+
 // PatchInteractive calls PatchInteractiveFunc.
 func (mock *InteractivesAPIClientMock) PatchInteractive(contextMoqParam context.Context, s1 string, s2 string, s3 string, patchRequest interactives.PatchRequest) (interactives.Interactive, error) {
 	if mock.PatchInteractiveFunc == nil {
@@ -128,7 +191,8 @@ func (mock *InteractivesAPIClientMock) PatchInteractive(contextMoqParam context.
 
 // PatchInteractiveCalls gets all the calls that were made to PatchInteractive.
 // Check the length with:
-//     len(mockedInteractivesAPIClient.PatchInteractiveCalls())
+//
+//	len(mockedInteractivesAPIClient.PatchInteractiveCalls())
 func (mock *InteractivesAPIClientMock) PatchInteractiveCalls() []struct {
 	ContextMoqParam context.Context
 	S1              string
@@ -0,0 +1,201 @@
+package importer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ONSdigital/dp-api-clients-go/v2/interactives"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// PendingReport is the outcome of an import that the interactives api could not be told, kept until it is sent
+type PendingReport struct {
+	InteractiveID string                    `json:"interactive_id"`
+	Request       interactives.PatchRequest `json:"request"`
+	CreatedAt     time.Time                 `json:"created_at"`
+}
+
+// PendingReportStore keeps the outcomes not yet reported across restarts, the latest one of each interactive
+type PendingReportStore interface {
+	// Get returns nil when nothing is pending for the interactive
+	Get(ctx context.Context, interactiveID string) (*PendingReport, error)
+	Put(ctx context.Context, report PendingReport) error
+	List(ctx context.Context) ([]PendingReport, error)
+	Remove(ctx context.Context, interactiveID string) error
+}
+
+// FilePendingReportStore keeps one JSON document per interactive in a local directory
+type FilePendingReportStore struct {
+	dir string
+}
+
+func NewFilePendingReportStore(dir string) (*FilePendingReportStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FilePendingReportStore{dir: dir}, nil
+}
+
+func (s *FilePendingReportStore) Get(_ context.Context, interactiveID string) (*PendingReport, error) {
+	b, err := os.ReadFile(s.path(interactiveID))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var report PendingReport
+	if err = json.Unmarshal(b, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+func (s *FilePendingReportStore) Put(_ context.Context, report PendingReport) error {
+	b, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(s.dir, "report_*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path(report.InteractiveID))
+}
+
+func (s *FilePendingReportStore) List(_ context.Context) ([]PendingReport, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var reports []PendingReport
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		b, err := os.ReadFile(filepath.Join(s.dir, e.Name()))
+		if errors.Is(err, os.ErrNotExist) {
+			continue // sent since listed
+		}
+		if err != nil {
+			return nil, err
+		}
+		var report PendingReport
+		if err = json.Unmarshal(b, &report); err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+func (s *FilePendingReportStore) Remove(_ context.Context, interactiveID string) error {
+	err := os.Remove(s.path(interactiveID))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (s *FilePendingReportStore) path(interactiveID string) string {
+	return filepath.Join(s.dir, url.PathEscape(interactiveID)+".json")
+}
+
+// ReportSender sends the pending outcomes of imports to the interactives api in the background, so an event
+// whose outcome could not be reported is committed without losing the outcome
+type ReportSender struct {
+	Reports               PendingReportStore
+	InteractivesAPIClient InteractivesAPIClient
+	ServiceAuthToken      string
+	PatchTimeout          time.Duration
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// Run sends every pending report once, keeping those the interactives api may still accept later
+func (rs *ReportSender) Run(ctx context.Context) error {
+	reports, err := rs.Reports.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range reports {
+		logData := log.Data{"id": r.InteractiveID, "pending_since": r.CreatedAt}
+		patchCtx, cancel := withTimeout(ctx, rs.PatchTimeout)
+		_, err := rs.InteractivesAPIClient.PatchInteractive(patchCtx, "", rs.ServiceAuthToken, r.InteractiveID, r.Request)
+		cancel()
+		if err != nil && patchRetryable(err) {
+			logData["error"] = err.Error()
+			log.Warn(ctx, "outcome of import still not reported", logData)
+			continue
+		}
+		if err != nil {
+			logData["error"] = err.Error()
+			log.Error(ctx, "interactives api rejected the outcome of import, dropping it", err, logData)
+		}
+		rs.forget(ctx, r)
+	}
+	return nil
+}
+
+// forget removes the report once sent, unless a later import of the interactive has replaced it since
+func (rs *ReportSender) forget(ctx context.Context, r PendingReport) {
+	current, err := rs.Reports.Get(ctx, r.InteractiveID)
+	if err == nil && current != nil && current.CreatedAt.Equal(r.CreatedAt) {
+		err = rs.Reports.Remove(ctx, r.InteractiveID)
+	}
+	if err != nil {
+		log.Warn(ctx, "failed to remove sent outcome of import from store", log.Data{"id": r.InteractiveID, "error": err.Error()})
+	}
+}
+
+// Start sends the pending reports every interval until Stop is called
+func (rs *ReportSender) Start(ctx context.Context, interval time.Duration) {
+	rs.stop = make(chan struct{})
+	rs.done = make(chan struct{})
+
+	go func() {
+		defer close(rs.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := rs.Run(ctx); err != nil {
+					log.Error(ctx, "sending pending outcomes of imports failed", err)
+				}
+			case <-rs.stop:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+func (rs *ReportSender) Stop() {
+	if rs.stop == nil {
+		return
+	}
+	close(rs.stop)
+	<-rs.done
+}
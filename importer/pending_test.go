@@ -0,0 +1,113 @@
+package importer_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-api-clients-go/v2/interactives"
+	"github.com/ONSdigital/dp-interactives-importer/importer"
+	mocks_importer "github.com/ONSdigital/dp-interactives-importer/importer/mocks"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestFilePendingReportStore(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Given a pending report store", t, func() {
+		store, err := importer.NewFilePendingReportStore(t.TempDir())
+		So(err, ShouldBeNil)
+		first := importer.PendingReport{InteractiveID: "a/1", CreatedAt: time.Now().UTC().Add(-time.Minute)}
+		So(store.Put(ctx, first), ShouldBeNil)
+
+		Convey("When a later outcome of the same interactive is kept", func() {
+			later := first
+			later.CreatedAt = time.Now().UTC()
+			later.Request.Interactive.Archive = &interactives.Archive{ImportSuccessful: true}
+			So(store.Put(ctx, later), ShouldBeNil)
+
+			Convey("Then only the later one should be pending", func() {
+				reports, err := store.List(ctx)
+				So(err, ShouldBeNil)
+				So(reports, ShouldHaveLength, 1)
+				So(reports[0].CreatedAt.Equal(later.CreatedAt), ShouldBeTrue)
+				So(reports[0].Request.Interactive.Archive.ImportSuccessful, ShouldBeTrue)
+			})
+		})
+
+		Convey("When it is removed", func() {
+			So(store.Remove(ctx, "a/1"), ShouldBeNil)
+
+			Convey("Then nothing should be pending", func() {
+				report, err := store.Get(ctx, "a/1")
+				So(err, ShouldBeNil)
+				So(report, ShouldBeNil)
+				So(store.Remove(ctx, "a/1"), ShouldBeNil)
+			})
+		})
+	})
+}
+
+func TestReportSender(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Given outcomes pending for two interactives", t, func() {
+		store, err := importer.NewFilePendingReportStore(t.TempDir())
+		So(err, ShouldBeNil)
+		for _, id := range []string{"1", "2"} {
+			So(store.Put(ctx, importer.PendingReport{InteractiveID: id, CreatedAt: time.Now().UTC()}), ShouldBeNil)
+		}
+		patchErrs := map[string]error{}
+		mockInteractivesAPI := &mocks_importer.InteractivesAPIClientMock{
+			PatchInteractiveFunc: func(_ context.Context, _, _, id string, _ interactives.PatchRequest) (interactives.Interactive, error) {
+				return interactives.Interactive{}, patchErrs[id]
+			},
+		}
+		sender := &importer.ReportSender{Reports: store, InteractivesAPIClient: mockInteractivesAPI}
+		pending := func() []string {
+			reports, err := store.List(ctx)
+			So(err, ShouldBeNil)
+			var ids []string
+			for _, r := range reports {
+				ids = append(ids, r.InteractiveID)
+			}
+			return ids
+		}
+
+		Convey("When the interactives api is still unavailable for one of them", func() {
+			patchErrs["2"] = &importer.StatusError{StatusCode: http.StatusServiceUnavailable, Err: errors.New("interactives api failed")}
+			So(sender.Run(ctx), ShouldBeNil)
+
+			Convey("Then only the sent one should no longer be pending", func() {
+				So(mockInteractivesAPI.PatchInteractiveCalls(), ShouldHaveLength, 2)
+				So(pending(), ShouldResemble, []string{"2"})
+			})
+		})
+
+		Convey("When the interactives api rejects one of them", func() {
+			patchErrs["2"] = &importer.StatusError{StatusCode: http.StatusNotFound, Err: errors.New("interactives api failed")}
+			So(sender.Run(ctx), ShouldBeNil)
+
+			Convey("Then it should be dropped, as it would never be accepted", func() {
+				So(pending(), ShouldBeEmpty)
+			})
+		})
+
+		Convey("When a later import replaces an outcome while it is sent", func() {
+			later := importer.PendingReport{InteractiveID: "1", CreatedAt: time.Now().UTC().Add(time.Minute)}
+			mockInteractivesAPI.PatchInteractiveFunc = func(_ context.Context, _, _, id string, _ interactives.PatchRequest) (interactives.Interactive, error) {
+				if id == "1" {
+					So(store.Put(ctx, later), ShouldBeNil)
+				}
+				return interactives.Interactive{}, nil
+			}
+			So(sender.Run(ctx), ShouldBeNil)
+
+			Convey("Then the later one should still be pending", func() {
+				So(pending(), ShouldResemble, []string{"1"})
+			})
+		})
+	})
+}
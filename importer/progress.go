@@ -0,0 +1,102 @@
+package importer
+
+import (
+	"context"
+	"time"
+
+	"github.com/ONSdigital/dp-interactives-importer/schema"
+	"github.com/ONSdigital/dp-kafka/v3/avro"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+//go:generate moq -out mocks/progress.go -pkg mocks_importer . ProgressPublisher
+
+// ImportProgress provides an avro structure for the progress of a running import
+type ImportProgress struct {
+	ID             string `avro:"id"`
+	JobID          string `avro:"job_id"`
+	Stage          string `avro:"stage"`
+	FilesProcessed int64  `avro:"files_processed"`
+	FilesTotal     int64  `avro:"files_total"`
+	BytesUploaded  int64  `avro:"bytes_uploaded"`
+	ArchiveBytes   int64  `avro:"archive_bytes"`
+	UpdatedAt      string `avro:"updated_at"` // RFC 3339
+}
+
+// ProgressPublisher sends the progress of running imports, so the CMS can show how far along they are
+type ProgressPublisher interface {
+	Publish(ctx context.Context, progress *ImportProgress) error
+}
+
+// KafkaProgressPublisher publishes progress to the topic of a kafka producer
+type KafkaProgressPublisher struct {
+	Producer interface {
+		Send(schema *avro.Schema, event interface{}) error
+	}
+}
+
+func (p *KafkaProgressPublisher) Publish(_ context.Context, progress *ImportProgress) error {
+	return p.Producer.Send(schema.InteractivesImportProgressEvent, progress)
+}
+
+// reportProgress publishes the progress of the job at most once an interval, and only when it has moved on,
+// until the returned func is called, which publishes where the job got to
+func (h *InteractivesUploadedHandler) reportProgress(ctx context.Context, uploadJob *Job, event *InteractivesUploaded) func() {
+	if h.Progress == nil || h.Cfg.ProgressInterval <= 0 {
+		return func() {}
+	}
+
+	var last *ImportProgress
+	publish := func() {
+		progress := newImportProgress(event, uploadJob.Status())
+		if last != nil && progress.sameAs(last) {
+			return
+		}
+		last = progress
+		if err := h.Progress.Publish(ctx, progress); err != nil {
+			log.Warn(ctx, "failed to publish import progress", log.Data{"id": event.ID, "job_id": progress.JobID, "error": err.Error()})
+		}
+	}
+
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(h.Cfg.ProgressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				publish()
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(stop)
+		<-stopped
+		publish()
+	}
+}
+
+func newImportProgress(event *InteractivesUploaded, status Status) *ImportProgress {
+	return &ImportProgress{
+		ID:             event.ID,
+		JobID:          status.ID,
+		Stage:          status.Stage,
+		FilesProcessed: int64(status.FilesProcessed),
+		FilesTotal:     int64(status.FilesTotal),
+		BytesUploaded:  status.BytesUploaded,
+		ArchiveBytes:   status.ArchiveBytes,
+		UpdatedAt:      time.Now().UTC().Format(time.RFC3339),
+	}
+}
+
+// sameAs is true if nothing but the time has changed
+func (p *ImportProgress) sameAs(other *ImportProgress) bool {
+	a, b := *p, *other
+	a.UpdatedAt, b.UpdatedAt = "", ""
+	return a == b
+}
@@ -0,0 +1,127 @@
+package importer_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-api-clients-go/v2/interactives"
+	"github.com/ONSdigital/dp-api-clients-go/v2/upload"
+	"github.com/ONSdigital/dp-interactives-importer/config"
+	"github.com/ONSdigital/dp-interactives-importer/importer"
+	mocks_importer "github.com/ONSdigital/dp-interactives-importer/importer/mocks"
+	"github.com/ONSdigital/dp-interactives-importer/internal/test"
+	"github.com/ONSdigital/dp-interactives-importer/schema"
+	"github.com/ONSdigital/log.go/v2/log"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestProgress(t *testing.T) {
+
+	Convey("Given a handler publishing progress while it imports an archive of several files", t, func() {
+		archive, err := test.CreateTestZip("a.html", "b.html", "c.html", "d.html", "e.html")
+		So(err, ShouldBeNil)
+		defer os.Remove(archive)
+
+		var mu sync.Mutex
+		var published []importer.ImportProgress
+		var publishErr error
+		publisher := &mocks_importer.ProgressPublisherMock{
+			PublishFunc: func(_ context.Context, progress *importer.ImportProgress) error {
+				mu.Lock()
+				defer mu.Unlock()
+				published = append(published, *progress)
+				return publishErr
+			},
+		}
+		backend := &mocks_importer.UploadServiceBackendMock{
+			UploadFunc: func(context.Context, io.ReadCloser, upload.Metadata) error {
+				time.Sleep(5 * time.Millisecond)
+				return nil
+			},
+		}
+		var patched []interactives.PatchRequest
+		handler := &importer.InteractivesUploadedHandler{
+			Cfg:           &config.Config{BatchSize: 1, TempDir: t.TempDir(), ProgressInterval: time.Millisecond},
+			UploadService: importer.NewUploadService(backend),
+			Progress:      publisher,
+			InteractivesAPIClient: &mocks_importer.InteractivesAPIClientMock{
+				PatchInteractiveFunc: func(_ context.Context, _, _, _ string, req interactives.PatchRequest) (interactives.Interactive, error) {
+					patched = append(patched, req)
+					return interactives.Interactive{}, nil
+				},
+			},
+		}
+		event := &importer.InteractivesUploaded{ID: "1", Path: "archive.zip"}
+		uploadJob := handler.NewJob(context.Background(), event)
+		runImport := func() error {
+			return handler.Import(context.Background(), uploadJob, event, archive, log.Data{})
+		}
+
+		Convey("When it is imported", func() {
+			So(runImport(), ShouldBeNil)
+
+			Convey("Then progress should be published as files are uploaded, ending with the import complete", func() {
+				So(len(published), ShouldBeGreaterThan, 1)
+				last := published[len(published)-1]
+				So(last.ID, ShouldEqual, "1")
+				So(last.JobID, ShouldEqual, uploadJob.ID())
+				So(last.Stage, ShouldEqual, importer.StageComplete)
+				So(last.FilesTotal, ShouldEqual, 5)
+				So(last.FilesProcessed, ShouldEqual, 5)
+				So(last.BytesUploaded, ShouldBeGreaterThan, 0)
+			})
+
+			Convey("Then progress should only be published when it has moved on", func() {
+				for i := 1; i < len(published); i++ {
+					previous, current := published[i-1], published[i]
+					previous.UpdatedAt, current.UpdatedAt = "", ""
+					So(current, ShouldNotResemble, previous)
+				}
+			})
+
+			Convey("Then each update should encode with the progress schema", func() {
+				encoded, err := schema.InteractivesImportProgressEvent.Marshal(published[0])
+				So(err, ShouldBeNil)
+				var decoded importer.ImportProgress
+				So(schema.InteractivesImportProgressEvent.Unmarshal(encoded, &decoded), ShouldBeNil)
+				So(decoded, ShouldResemble, published[0])
+			})
+		})
+
+		Convey("When the import fails", func() {
+			backend.UploadFunc = func(context.Context, io.ReadCloser, upload.Metadata) error {
+				return upload.ErrFileTooLarge
+			}
+			So(runImport(), ShouldNotBeNil)
+
+			Convey("Then the last progress published should be the failure", func() {
+				So(published, ShouldNotBeEmpty)
+				So(published[len(published)-1].Stage, ShouldEqual, importer.StageFailed)
+			})
+		})
+
+		Convey("When publishing progress fails", func() {
+			publishErr = errors.New("broker down")
+			err := runImport()
+
+			Convey("Then the import should still succeed", func() {
+				So(err, ShouldBeNil)
+				So(patched[0].Interactive.Archive.ImportSuccessful, ShouldBeTrue)
+			})
+		})
+
+		Convey("When progress is switched off", func() {
+			handler.Cfg.ProgressInterval = 0
+			So(runImport(), ShouldBeNil)
+
+			Convey("Then nothing should be published", func() {
+				So(published, ShouldBeEmpty)
+			})
+		})
+	})
+}
@@ -0,0 +1,120 @@
+package importer
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Stages an import passes through, in order
+const (
+	StageQueued   = "queued"
+	StageDownload = "download"
+	StageValidate = "validate"
+	StageUpload   = "upload"
+	StagePatch    = "patch"
+	StageComplete = "complete"
+	StageFailed   = "failed"
+)
+
+// Status is a point in time copy of the progress of an import
+type Status struct {
+	ID             string           `json:"id"`
+	InteractiveID  string           `json:"interactive_id"`
+	Path           string           `json:"path"`
+	UploadRoot     string           `json:"upload_root,omitempty"`
+	Stage          string           `json:"stage"`
+	InFlight       bool             `json:"in_flight"`
+	FilesProcessed uint64           `json:"files_processed"`
+	FilesTotal     uint64           `json:"files_total"`
+	ArchiveBytes   int64            `json:"archive_bytes"`
+	BytesUploaded  int64            `json:"bytes_uploaded"`
+	Errors         []string         `json:"errors,omitempty"`
+	StartedAt      time.Time        `json:"started_at"`
+	FinishedAt     *time.Time       `json:"finished_at,omitempty"`
+	TimingsMillis  map[string]int64 `json:"timings_ms,omitempty"`
+}
+
+// Registry holds every in-flight import and the most recent finished ones
+type Registry struct {
+	mu       sync.RWMutex
+	capacity int
+	jobs     map[string]*Job
+	order    []string // oldest first
+}
+
+// NewRegistry keeps at most capacity finished imports, in-flight imports are never evicted
+func NewRegistry(capacity int) *Registry {
+	return &Registry{
+		capacity: capacity,
+		jobs:     make(map[string]*Job),
+	}
+}
+
+func (r *Registry) Add(j *Job) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.jobs[j.ID()] = j
+	r.order = append(r.order, j.ID())
+	r.evict()
+}
+
+func (r *Registry) Get(id string) (Status, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	j, ok := r.jobs[id]
+	if !ok {
+		return Status{}, false
+	}
+	return j.Status(), true
+}
+
+// List returns every import held, newest first
+func (r *Registry) List() []Status {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	list := make([]Status, 0, len(r.order))
+	for i := len(r.order) - 1; i >= 0; i-- {
+		list = append(list, r.jobs[r.order[i]].Status())
+	}
+	return list
+}
+
+// InFlight returns the imports still running, oldest first
+func (r *Registry) InFlight() []Status {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var list []Status
+	for _, id := range r.order {
+		if s := r.jobs[id].Status(); s.InFlight {
+			list = append(list, s)
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].StartedAt.Before(list[j].StartedAt) })
+	return list
+}
+
+// evict drops the oldest finished imports over capacity, must be called with the lock held
+func (r *Registry) evict() {
+	finished := 0
+	for _, id := range r.order {
+		if !r.jobs[id].Status().InFlight {
+			finished++
+		}
+	}
+
+	kept := r.order[:0]
+	for _, id := range r.order {
+		if finished > r.capacity && !r.jobs[id].Status().InFlight {
+			delete(r.jobs, id)
+			finished--
+			continue
+		}
+		kept = append(kept, id)
+	}
+	r.order = kept
+}
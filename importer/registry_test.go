@@ -0,0 +1,63 @@
+package importer_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ONSdigital/dp-api-clients-go/v2/interactives"
+	"github.com/ONSdigital/dp-interactives-importer/importer"
+	mocks_importer "github.com/ONSdigital/dp-interactives-importer/importer/mocks"
+	"github.com/ONSdigital/log.go/v2/log"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRegistry(t *testing.T) {
+	mockInteractivesAPI := &mocks_importer.InteractivesAPIClientMock{
+		PatchInteractiveFunc: func(context.Context, string, string, string, interactives.PatchRequest) (interactives.Interactive, error) {
+			return interactives.Interactive{}, nil
+		},
+	}
+	event := &importer.InteractivesUploaded{ID: "1", Path: "abc/test.zip"}
+
+	Convey("Given a registry holding one finished import", t, func() {
+		registry := importer.NewRegistry(1)
+
+		var err error
+		finished := importer.NewJob(context.TODO(), cfg, mockInteractivesAPI)
+		finished.Start(event)
+		registry.Add(finished)
+		finished.Finish(&log.Data{}, event, "interactives/1/abc", nil, &err)
+
+		Convey("Then the import should be complete and no longer in flight", func() {
+			s, ok := registry.Get(finished.ID())
+			So(ok, ShouldBeTrue)
+			So(s.Stage, ShouldEqual, importer.StageComplete)
+			So(s.InFlight, ShouldBeFalse)
+			So(s.FinishedAt, ShouldNotBeNil)
+			So(registry.InFlight(), ShouldBeEmpty)
+		})
+
+		Convey("When two more imports start and one finishes", func() {
+			running := importer.NewJob(context.TODO(), cfg, mockInteractivesAPI)
+			registry.Add(running)
+			latest := importer.NewJob(context.TODO(), cfg, mockInteractivesAPI)
+			latest.Finish(&log.Data{}, event, "interactives/1/def", nil, &err)
+			registry.Add(latest)
+
+			Convey("Then the oldest finished import should be evicted and in-flight imports kept", func() {
+				_, ok := registry.Get(finished.ID())
+				So(ok, ShouldBeFalse)
+
+				list := registry.List()
+				So(list, ShouldHaveLength, 2)
+				So(list[0].ID, ShouldEqual, latest.ID())
+				So(list[1].ID, ShouldEqual, running.ID())
+
+				inFlight := registry.InFlight()
+				So(inFlight, ShouldHaveLength, 1)
+				So(inFlight[0].ID, ShouldEqual, running.ID())
+			})
+		})
+	})
+}
@@ -0,0 +1,204 @@
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"unicode/utf8"
+)
+
+// Codes of the entries in an import report
+const (
+	CodeInvalidFile      = "invalid_file"
+	CodeProcessingFailed = "processing_failed"
+	CodeUploadFailed     = "upload_failed"
+	CodeTimeout          = "timeout"
+	CodeInterrupted      = "interrupted"
+	CodeDiskSpace        = "insufficient_disk_space"
+	CodeImportFailed     = "import_failed"
+	CodeIgnoredFile      = "ignored_file"
+)
+
+// maxEntryMessage caps the message of a single entry, so one long error cannot crowd out the rest
+const maxEntryMessage = 512
+
+// ReportEntry is an error or warning about an import, File is empty if it is not about a single file
+type ReportEntry struct {
+	Code    string `json:"code"`
+	File    string `json:"file,omitempty"`
+	Stage   string `json:"stage"`
+	Message string `json:"message"`
+}
+
+// ReportSummary counts the files of an import and everything reported about it
+type ReportSummary struct {
+	Files    uint64 `json:"files"`
+	Uploaded uint64 `json:"uploaded"`
+	Skipped  uint64 `json:"skipped"`
+	Errors   int    `json:"errors"`
+	Warnings int    `json:"warnings"`
+}
+
+// Report is the structured outcome of an import, sent to the interactives api as the import message
+type Report struct {
+	Successful bool          `json:"successful"`
+	Category   Category      `json:"category,omitempty"` // why a failed import failed
+	Message    string        `json:"message,omitempty"`
+	Summary    ReportSummary `json:"summary"`
+	Errors     []ReportEntry `json:"errors,omitempty"`
+	Warnings   []ReportEntry `json:"warnings,omitempty"`
+	Truncated  bool          `json:"truncated,omitempty"` // some entries were left out to keep within the size limit
+
+	mu sync.Mutex
+}
+
+func (r *Report) AddError(e ReportEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.Errors = append(r.Errors, e)
+	r.Summary.Errors++
+}
+
+func (r *Report) AddWarning(e ReportEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.Warnings = append(r.Warnings, e)
+	r.Summary.Warnings++
+}
+
+// finish records the outcome of the import. A failure is told to editors by its category, with the errors
+// reported per file if it was down to files of the archive.
+func (r *Report) finish(err error, stage, message string, files uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.Summary.Files = files
+	r.Successful = err == nil
+	if err == nil {
+		r.Message = message
+		return
+	}
+
+	r.Category = CategoryOf(err)
+	r.Message = r.Category.EditorMessage()
+
+	var archiveErrs ArchiveErrors
+	if !errors.As(err, &archiveErrs) {
+		r.Errors = append(r.Errors, ReportEntry{Code: errorCode(err), Stage: stage, Message: err.Error()})
+		r.Summary.Errors++
+		return
+	}
+	for _, fileErr := range archiveErrs {
+		r.Errors = append(r.Errors, ReportEntry{Code: fileErrorCode(stage, fileErr), File: fileErr.File, Stage: stage, Message: fileErr.Err.Error()})
+	}
+	r.Summary.Errors += len(archiveErrs)
+}
+
+func (r *Report) fileProcessed(uploaded bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if uploaded {
+		r.Summary.Uploaded++
+	} else {
+		r.Summary.Skipped++
+	}
+}
+
+// Encode returns the report as JSON of at most maxBytes, or unlimited if maxBytes is zero or less. Messages are
+// cut short, then warnings and then errors are left out, sorted by file, until it fits. The summary counts everything.
+func (r *Report) Encode(maxBytes int) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out := &Report{
+		Successful: r.Successful,
+		Category:   r.Category,
+		Message:    truncate(r.Message, maxEntryMessage),
+		Summary:    r.Summary,
+		Errors:     capEntries(r.Errors),
+		Warnings:   capEntries(r.Warnings),
+	}
+	b, err := json.Marshal(out)
+	if err != nil || maxBytes <= 0 || len(b) <= maxBytes {
+		return string(b), err
+	}
+
+	// keep as many entries as fit, errors before warnings
+	errs, warnings := out.Errors, out.Warnings
+	total := len(errs) + len(warnings)
+	fits := func(n int) ([]byte, bool) {
+		out.Errors, out.Warnings = errs, nil
+		if n < len(errs) {
+			out.Errors = errs[:n]
+		} else {
+			out.Warnings = warnings[:n-len(errs)]
+		}
+		out.Truncated = n < total
+		b, _ := json.Marshal(out)
+		return b, len(b) <= maxBytes
+	}
+	n := sort.Search(total+1, func(n int) bool {
+		_, ok := fits(n)
+		return !ok
+	}) - 1
+	if n < 0 {
+		n = 0
+	}
+	b, _ = fits(n)
+	return string(b), nil
+}
+
+// capEntries returns a copy of the entries sorted by file with their messages cut short
+func capEntries(entries []ReportEntry) []ReportEntry {
+	if len(entries) == 0 {
+		return nil
+	}
+	capped := make([]ReportEntry, len(entries))
+	for i, e := range entries {
+		e.Message = truncate(e.Message, maxEntryMessage)
+		capped[i] = e
+	}
+	sort.SliceStable(capped, func(i, j int) bool { return capped[i].File < capped[j].File })
+	return capped
+}
+
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	s = s[:max]
+	for !utf8.ValidString(s) {
+		s = s[:len(s)-1]
+	}
+	return s + "…"
+}
+
+// fileErrorCode classifies the failure of a file in the given stage
+func fileErrorCode(stage string, fileErr *FileError) string {
+	var timeoutErr *TimeoutError
+	switch {
+	case errors.As(fileErr.Err, &timeoutErr):
+		return CodeTimeout
+	case fileErr.Code == CodeProcessingFailed && stage == StageUpload:
+		return CodeUploadFailed
+	}
+	return fileErr.Code
+}
+
+// errorCode classifies the failure of a whole import
+func errorCode(err error) string {
+	var timeoutErr *TimeoutError
+	switch {
+	case errors.Is(err, ErrInterrupted):
+		return CodeInterrupted
+	case errors.As(err, &timeoutErr):
+		return CodeTimeout
+	case errors.Is(err, ErrInsufficientDiskSpace):
+		return CodeDiskSpace
+	}
+	return CodeImportFailed
+}
@@ -0,0 +1,122 @@
+package importer_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/dp-api-clients-go/v2/interactives"
+	"github.com/ONSdigital/dp-interactives-importer/importer"
+	mocks_importer "github.com/ONSdigital/dp-interactives-importer/importer/mocks"
+	"github.com/ONSdigital/log.go/v2/log"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestReport(t *testing.T) {
+
+	Convey("Given a report with more entries than fit in the size limit", t, func() {
+		report := &importer.Report{}
+		for i := 0; i < 50; i++ {
+			report.AddError(importer.ReportEntry{Code: importer.CodeInvalidFile, File: fmt.Sprintf("file-%02d.html", i), Stage: importer.StageValidate, Message: "broken"})
+			report.AddWarning(importer.ReportEntry{Code: importer.CodeIgnoredFile, File: fmt.Sprintf(".hidden-%02d", i), Stage: importer.StageValidate, Message: "ignored"})
+		}
+
+		Convey("When it is encoded", func() {
+			encoded, err := report.Encode(1024)
+			So(err, ShouldBeNil)
+
+			Convey("Then it should fit, keeping errors before warnings and counting everything", func() {
+				So(len(encoded), ShouldBeLessThanOrEqualTo, 1024)
+				decoded := decodeReport(encoded)
+				So(decoded.Truncated, ShouldBeTrue)
+				So(decoded.Errors, ShouldNotBeEmpty)
+				So(decoded.Errors[0].File, ShouldEqual, "file-00.html")
+				So(decoded.Warnings, ShouldBeEmpty)
+				So(decoded.Summary.Errors, ShouldEqual, 50)
+				So(decoded.Summary.Warnings, ShouldEqual, 50)
+			})
+		})
+
+		Convey("When it is encoded without a size limit", func() {
+			encoded, err := report.Encode(0)
+			So(err, ShouldBeNil)
+
+			Convey("Then every entry should be kept", func() {
+				decoded := decodeReport(encoded)
+				So(decoded.Truncated, ShouldBeFalse)
+				So(decoded.Errors, ShouldHaveLength, 50)
+				So(decoded.Warnings, ShouldHaveLength, 50)
+			})
+		})
+	})
+
+	Convey("Given an entry with a very long message", t, func() {
+		report := &importer.Report{}
+		report.AddError(importer.ReportEntry{Code: importer.CodeProcessingFailed, Stage: importer.StageUpload, Message: strings.Repeat("é", 1000)})
+
+		Convey("Then its message should be cut short", func() {
+			encoded, err := report.Encode(0)
+			So(err, ShouldBeNil)
+			So(json.Valid([]byte(encoded)), ShouldBeTrue)
+			decoded := decodeReport(encoded)
+			So(len(decoded.Errors[0].Message), ShouldBeLessThan, 600)
+			So(decoded.Errors[0].Message, ShouldEndWith, "…")
+		})
+	})
+}
+
+func TestJobReport(t *testing.T) {
+
+	Convey("Given a job that fails on files of the archive", t, func() {
+		uploadJob, mockInteractivesAPI := newReportJob()
+		err := error(importer.ArchiveErrors{
+			{File: "a.html", Code: importer.CodeInvalidFile, Err: &importer.ImportError{Category: importer.CategoryCorruptArchive, Err: errors.New("bad header")}},
+			{File: "b.html", Code: importer.CodeProcessingFailed, Err: errors.New("upload refused")},
+		})
+		finishReportJob(uploadJob, importer.StageUpload, err)
+
+		Convey("Then each file should be reported with its error", func() {
+			report := decodeReport(mockInteractivesAPI.PatchInteractiveCalls()[0].PatchRequest.Interactive.Archive.ImportMessage)
+			So(report.Successful, ShouldBeFalse)
+			So(report.Category, ShouldEqual, importer.CategoryCorruptArchive)
+			So(report.Message, ShouldEqual, importer.CategoryCorruptArchive.EditorMessage())
+			So(report.Errors, ShouldResemble, []importer.ReportEntry{
+				{Code: importer.CodeInvalidFile, File: "a.html", Stage: importer.StageUpload, Message: "bad header"},
+				{Code: importer.CodeUploadFailed, File: "b.html", Stage: importer.StageUpload, Message: "upload refused"},
+			})
+		})
+	})
+
+	Convey("Given a job that succeeds with a warning", t, func() {
+		uploadJob, mockInteractivesAPI := newReportJob()
+		uploadJob.SetImportMessage("2 added, 0 changed, 0 unchanged, 0 removed")
+		uploadJob.Report().AddWarning(importer.ReportEntry{Code: importer.CodeIgnoredFile, File: ".DS_Store", Stage: importer.StageValidate, Message: "ignored"})
+		finishReportJob(uploadJob, importer.StageUpload, nil)
+
+		Convey("Then the report should carry the message and the warning", func() {
+			report := decodeReport(mockInteractivesAPI.PatchInteractiveCalls()[0].PatchRequest.Interactive.Archive.ImportMessage)
+			So(report.Successful, ShouldBeTrue)
+			So(report.Message, ShouldEqual, "2 added, 0 changed, 0 unchanged, 0 removed")
+			So(report.Warnings, ShouldHaveLength, 1)
+			So(report.Summary.Warnings, ShouldEqual, 1)
+		})
+	})
+}
+
+func newReportJob() (*importer.Job, *mocks_importer.InteractivesAPIClientMock) {
+	mockInteractivesAPI := &mocks_importer.InteractivesAPIClientMock{
+		PatchInteractiveFunc: func(ctx context.Context, userAuthToken string, serviceAuthToken string, interactiveID string, req interactives.PatchRequest) (interactives.Interactive, error) {
+			return interactives.Interactive{}, nil
+		},
+	}
+	return importer.NewJob(context.TODO(), cfg, mockInteractivesAPI), mockInteractivesAPI
+}
+
+func finishReportJob(uploadJob *importer.Job, stage string, err error) {
+	var zipSize int64
+	uploadJob.SetStage(stage)
+	uploadJob.Finish(&log.Data{}, &importer.InteractivesUploaded{ID: "1"}, "", &zipSize, &err)
+}
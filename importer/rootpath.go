@@ -0,0 +1,67 @@
+package importer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+
+	gonanoid "github.com/matoous/go-nanoid/v2"
+)
+
+// Strategies for naming the upload root of an import
+const (
+	// RootStrategyRandom gives every import a new root, so re-processing an event uploads a duplicate
+	RootStrategyRandom = "random"
+	// RootStrategyContent derives the root from the archive content, identical archives share a root
+	RootStrategyContent = "content"
+	// RootStrategyEvent derives the root from the event and its version, so a redelivered event maps to the same
+	// root. An event without a version falls back to the archive content, so a new archive is never skipped.
+	RootStrategyEvent = "event"
+)
+
+// UploadRootPath returns the path, under which all files of the archive are uploaded, for the given strategy
+func UploadRootPath(strategy string, event *InteractivesUploaded, archive string) (string, error) {
+	var version string
+	switch strategy {
+	case RootStrategyRandom, "":
+		version = gonanoid.Must(16)
+	case RootStrategyContent:
+		sum, err := fileHash(archive)
+		if err != nil {
+			return "", archiveError(fmt.Errorf("cannot hash archive %w", err))
+		}
+		version = sum[:32]
+	case RootStrategyEvent:
+		eventVersion := event.Version
+		if eventVersion == "" {
+			sum, err := fileHash(archive)
+			if err != nil {
+				return "", archiveError(fmt.Errorf("cannot hash archive %w", err))
+			}
+			eventVersion = "sha256:" + sum
+		}
+		sum := sha256.Sum256([]byte(event.ID + "\x00" + event.Path + "\x00" + eventVersion))
+		version = hex.EncodeToString(sum[:])[:32]
+	default:
+		return "", fmt.Errorf("unknown upload root strategy: %s", strategy)
+	}
+
+	//no leading slash: https://github.com/ONSdigital/dp-upload-service/blob/ecc6062e6fe5856385b5fafbe1105606c1a958ff/api/upload.go#L25
+	return fmt.Sprintf("%s/%s/%s", "interactives", event.ID, version), nil
+}
+
+func fileHash(name string) (string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err = io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,85 @@
+package importer_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/ONSdigital/dp-interactives-importer/importer"
+	"github.com/ONSdigital/dp-interactives-importer/internal/test"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestUploadRootPath(t *testing.T) {
+	event := &importer.InteractivesUploaded{ID: "id", Path: "abc/single-interactive.zip"}
+
+	Convey("Given an archive", t, func() {
+		archiveName, err := test.CreateTestZip("index.html")
+		defer os.Remove(archiveName)
+		So(err, ShouldBeNil)
+
+		Convey("Then the random strategy should give a new root each time", func() {
+			a, err := importer.UploadRootPath(importer.RootStrategyRandom, event, archiveName)
+			So(err, ShouldBeNil)
+			b, err := importer.UploadRootPath(importer.RootStrategyRandom, event, archiveName)
+			So(err, ShouldBeNil)
+			So(a, ShouldStartWith, "interactives/id/")
+			So(a, ShouldNotEqual, b)
+		})
+
+		Convey("Then the content strategy should give the same root for the same archive", func() {
+			a, err := importer.UploadRootPath(importer.RootStrategyContent, event, archiveName)
+			So(err, ShouldBeNil)
+			b, err := importer.UploadRootPath(importer.RootStrategyContent, &importer.InteractivesUploaded{ID: "id", Path: "other.zip"}, archiveName)
+			So(err, ShouldBeNil)
+			So(a, ShouldStartWith, "interactives/id/")
+			So(a, ShouldEqual, b)
+
+			Convey("And a different root for a different archive", func() {
+				otherName, err := test.CreateTestZip("index.html", "data.csv")
+				defer os.Remove(otherName)
+				So(err, ShouldBeNil)
+				c, err := importer.UploadRootPath(importer.RootStrategyContent, event, otherName)
+				So(err, ShouldBeNil)
+				So(c, ShouldNotEqual, a)
+			})
+		})
+
+		Convey("Then the event strategy should give the same root for a redelivered event", func() {
+			versioned := &importer.InteractivesUploaded{ID: "id", Path: "abc/single-interactive.zip", Version: "1"}
+			a, err := importer.UploadRootPath(importer.RootStrategyEvent, versioned, archiveName)
+			So(err, ShouldBeNil)
+			b, err := importer.UploadRootPath(importer.RootStrategyEvent, versioned, "")
+			So(err, ShouldBeNil)
+			So(a, ShouldEqual, b)
+			c, err := importer.UploadRootPath(importer.RootStrategyEvent, &importer.InteractivesUploaded{ID: "id", Path: "other.zip", Version: "1"}, archiveName)
+			So(err, ShouldBeNil)
+			So(c, ShouldNotEqual, a)
+
+			Convey("And a different root for a new version at the same path", func() {
+				d, err := importer.UploadRootPath(importer.RootStrategyEvent, &importer.InteractivesUploaded{ID: "id", Path: "abc/single-interactive.zip", Version: "2"}, archiveName)
+				So(err, ShouldBeNil)
+				So(d, ShouldNotEqual, a)
+			})
+		})
+
+		Convey("Then the event strategy should tell archives apart by content for an event without a version", func() {
+			a, err := importer.UploadRootPath(importer.RootStrategyEvent, event, archiveName)
+			So(err, ShouldBeNil)
+			b, err := importer.UploadRootPath(importer.RootStrategyEvent, event, archiveName)
+			So(err, ShouldBeNil)
+			So(a, ShouldEqual, b)
+			otherName, err := test.CreateTestZip("index.html", "data.csv")
+			defer os.Remove(otherName)
+			So(err, ShouldBeNil)
+			c, err := importer.UploadRootPath(importer.RootStrategyEvent, event, otherName)
+			So(err, ShouldBeNil)
+			So(c, ShouldNotEqual, a)
+		})
+
+		Convey("Then an unknown strategy should error", func() {
+			_, err := importer.UploadRootPath("unknown", event, archiveName)
+			So(err, ShouldBeError, "unknown upload root strategy: unknown")
+		})
+	})
+}
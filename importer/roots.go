@@ -0,0 +1,126 @@
+package importer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	RootStatusImporting = "importing"
+	RootStatusComplete  = "complete"
+	RootStatusFailed    = "failed"
+)
+
+// UploadRoot is a directory in storage that an import uploaded files under
+type UploadRoot struct {
+	InteractiveID string    `json:"interactive_id"`
+	Path          string    `json:"path"`
+	Status        string    `json:"status"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// UploadRootStore records every upload root created by the importer, so roots that were never cleaned up
+// can be garbage collected later
+type UploadRootStore interface {
+	// Get returns nil when the root has not been recorded
+	Get(ctx context.Context, path string) (*UploadRoot, error)
+	Put(ctx context.Context, root UploadRoot) error
+	List(ctx context.Context) ([]UploadRoot, error)
+	Remove(ctx context.Context, path string) error
+}
+
+// FileUploadRootStore keeps one JSON document per upload root in a local directory
+type FileUploadRootStore struct {
+	dir string
+}
+
+func NewFileUploadRootStore(dir string) (*FileUploadRootStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileUploadRootStore{dir: dir}, nil
+}
+
+func (s *FileUploadRootStore) Get(_ context.Context, path string) (*UploadRoot, error) {
+	b, err := os.ReadFile(s.path(path))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var root UploadRoot
+	if err = json.Unmarshal(b, &root); err != nil {
+		return nil, err
+	}
+	return &root, nil
+}
+
+func (s *FileUploadRootStore) Put(_ context.Context, root UploadRoot) error {
+	root.UpdatedAt = time.Now().UTC()
+	b, err := json.Marshal(root)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(s.dir, "root_*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path(root.Path))
+}
+
+func (s *FileUploadRootStore) List(_ context.Context) ([]UploadRoot, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var roots []UploadRoot
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		b, err := os.ReadFile(filepath.Join(s.dir, e.Name()))
+		if errors.Is(err, os.ErrNotExist) {
+			continue // removed since listed
+		}
+		if err != nil {
+			return nil, err
+		}
+		var root UploadRoot
+		if err = json.Unmarshal(b, &root); err != nil {
+			return nil, err
+		}
+		roots = append(roots, root)
+	}
+	return roots, nil
+}
+
+func (s *FileUploadRootStore) Remove(_ context.Context, path string) error {
+	err := os.Remove(s.path(path))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (s *FileUploadRootStore) path(uploadRootPath string) string {
+	return filepath.Join(s.dir, url.PathEscape(uploadRootPath)+".json")
+}
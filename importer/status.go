@@ -0,0 +1,64 @@
+package importer
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/ONSdigital/dp-api-clients-go/v2/health"
+	"github.com/ONSdigital/dp-api-clients-go/v2/interactives"
+	dphttp "github.com/ONSdigital/dp-net/v2/http"
+)
+
+type statusKey struct{}
+
+// StatusClienter records the status of each response on the context of its request, so a client that does not
+// keep the status in its errors can be wrapped to add it
+type StatusClienter struct {
+	dphttp.Clienter
+}
+
+func (c *StatusClienter) Do(ctx context.Context, req *http.Request) (*http.Response, error) {
+	resp, err := c.Clienter.Do(ctx, req)
+	if status, ok := ctx.Value(statusKey{}).(*int); ok && resp != nil {
+		*status = resp.StatusCode
+	}
+	return resp, err
+}
+
+// recordStatus returns a context on which a StatusClienter records the status of the response
+func recordStatus(ctx context.Context) (context.Context, *int) {
+	status := new(int)
+	return context.WithValue(ctx, statusKey{}, status), status
+}
+
+// withStatus adds the status of the response to the failure of a request
+func withStatus(err error, status int) error {
+	if err == nil || status == 0 {
+		return err
+	}
+	return &StatusError{StatusCode: status, Err: err}
+}
+
+// StatusInteractivesAPIClient adds the status of the response to each failed request to the interactives api
+type StatusInteractivesAPIClient struct {
+	InteractivesAPIClient
+}
+
+// NewStatusInteractivesAPIClient returns an interactives api client sending its requests through a StatusClienter
+func NewStatusInteractivesAPIClient(interactivesAPIURL string) *StatusInteractivesAPIClient {
+	clienter := &StatusClienter{Clienter: dphttp.NewClient()}
+	hcCli := health.NewClientWithClienter("interactives-api", interactivesAPIURL, clienter)
+	return &StatusInteractivesAPIClient{InteractivesAPIClient: interactives.NewWithHealthClient(hcCli, "v1")}
+}
+
+func (c *StatusInteractivesAPIClient) GetInteractive(ctx context.Context, userAuthToken, serviceAuthToken, interactiveID string) (interactives.Interactive, error) {
+	ctx, status := recordStatus(ctx)
+	interactive, err := c.InteractivesAPIClient.GetInteractive(ctx, userAuthToken, serviceAuthToken, interactiveID)
+	return interactive, withStatus(err, *status)
+}
+
+func (c *StatusInteractivesAPIClient) PatchInteractive(ctx context.Context, userAuthToken, serviceAuthToken, interactiveID string, req interactives.PatchRequest) (interactives.Interactive, error) {
+	ctx, status := recordStatus(ctx)
+	interactive, err := c.InteractivesAPIClient.PatchInteractive(ctx, userAuthToken, serviceAuthToken, interactiveID, req)
+	return interactive, withStatus(err, *status)
+}
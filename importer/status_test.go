@@ -0,0 +1,56 @@
+package importer_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ONSdigital/dp-api-clients-go/v2/interactives"
+	"github.com/ONSdigital/dp-interactives-importer/importer"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestStatusInteractivesAPIClient(t *testing.T) {
+
+	Convey("Given an interactives api responding with a status", t, func() {
+		status := http.StatusServiceUnavailable
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(status)
+			w.Write([]byte("{}"))
+		}))
+		defer server.Close()
+		client := importer.NewStatusInteractivesAPIClient(server.URL)
+
+		Convey("When an interactive is patched and the api is unavailable", func() {
+			_, err := client.PatchInteractive(context.Background(), "", "token", "1", interactives.PatchRequest{})
+
+			Convey("Then the failure should keep the status", func() {
+				var statusErr *importer.StatusError
+				So(errors.As(err, &statusErr), ShouldBeTrue)
+				So(statusErr.StatusCode, ShouldEqual, http.StatusServiceUnavailable)
+			})
+		})
+
+		Convey("When an interactive is fetched and it is not found", func() {
+			status = http.StatusNotFound
+			_, err := client.GetInteractive(context.Background(), "", "token", "1")
+
+			Convey("Then the failure should keep the status", func() {
+				var statusErr *importer.StatusError
+				So(errors.As(err, &statusErr), ShouldBeTrue)
+				So(statusErr.StatusCode, ShouldEqual, http.StatusNotFound)
+			})
+		})
+
+		Convey("When an interactive is patched and the api accepts it", func() {
+			status = http.StatusOK
+			_, err := client.PatchInteractive(context.Background(), "", "token", "1", interactives.PatchRequest{})
+
+			Convey("Then there should be no failure", func() {
+				So(err, ShouldBeNil)
+			})
+		})
+	})
+}
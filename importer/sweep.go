@@ -0,0 +1,115 @@
+package importer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// tempFilePattern matches the archives downloaded from S3 or uploaded to the api for an import
+const tempFilePattern = "s3-zip_*.zip"
+
+// TempFileSweeper removes archives left in the working directory by imports that never got to remove them,
+// such as when the process is killed. The directory is emptied at startup, when no import can be using it; after
+// that only files older than MaxAge are removed, so it must exceed the longest import.
+type TempFileSweeper struct {
+	Dir    string
+	MaxAge time.Duration
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// Sweep removes stale archives, returning the paths it removed
+func (s *TempFileSweeper) Sweep(ctx context.Context) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(s.Dir, tempFilePattern))
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	cutoff := time.Now().Add(-s.MaxAge)
+	for _, path := range matches {
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() || info.ModTime().After(cutoff) {
+			continue
+		}
+		if err = os.Remove(path); err != nil {
+			log.Warn(ctx, "failed to remove orphaned temp file", log.Data{"path": path, "error": err.Error()})
+			continue
+		}
+		removed = append(removed, path)
+		log.Info(ctx, "removed orphaned temp file", log.Data{"path": path, "size": info.Size(), "modified": info.ModTime()})
+	}
+	return removed, nil
+}
+
+// Clear removes everything in the working directory, returning the paths it removed. The directory must be
+// dedicated to the importer and not in use by a running import.
+func (s *TempFileSweeper) Clear(ctx context.Context) ([]string, error) {
+	entries, err := os.ReadDir(s.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	for _, entry := range entries {
+		path := filepath.Join(s.Dir, entry.Name())
+		if err = os.RemoveAll(path); err != nil {
+			log.Warn(ctx, "failed to remove orphaned temp file", log.Data{"path": path, "error": err.Error()})
+			continue
+		}
+		removed = append(removed, path)
+		log.Info(ctx, "removed orphaned temp file", log.Data{"path": path})
+	}
+	return removed, nil
+}
+
+// Start clears the directory straight away, then sweeps it on every interval until stopped
+func (s *TempFileSweeper) Start(ctx context.Context, interval time.Duration) {
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+
+	sweep := func() {
+		removed, err := s.Sweep(ctx)
+		if err != nil {
+			log.Error(ctx, "sweep of orphaned temp files failed", err, log.Data{"dir": s.Dir})
+			return
+		}
+		log.Info(ctx, "sweep of orphaned temp files complete", log.Data{"dir": s.Dir, "removed": len(removed)})
+	}
+
+	removed, err := s.Clear(ctx)
+	if err != nil {
+		log.Error(ctx, "clearing temp dir failed", err, log.Data{"dir": s.Dir})
+	} else {
+		log.Info(ctx, "cleared temp dir", log.Data{"dir": s.Dir, "removed": len(removed)})
+	}
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				sweep()
+			case <-s.stop:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+func (s *TempFileSweeper) Stop() {
+	if s.stop == nil {
+		return
+	}
+	close(s.stop)
+	<-s.done
+}
@@ -0,0 +1,64 @@
+package importer_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-interactives-importer/importer"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTempFileSweeper(t *testing.T) {
+
+	Convey("Given a working directory with stale and recent temp files", t, func() {
+		dir := t.TempDir()
+		old := time.Now().Add(-2 * time.Hour)
+		write := func(name string, modified time.Time) string {
+			path := filepath.Join(dir, name)
+			So(os.WriteFile(path, []byte("zip"), 0600), ShouldBeNil)
+			So(os.Chtimes(path, modified, modified), ShouldBeNil)
+			return path
+		}
+		stale := write("s3-zip_1.zip", old)
+		recent := write("s3-zip_2.zip", time.Now())
+		other := write("manifest.json", old)
+
+		sweeper := &importer.TempFileSweeper{Dir: dir, MaxAge: time.Hour}
+
+		Convey("When it is swept", func() {
+			removed, err := sweeper.Sweep(context.TODO())
+
+			Convey("Then only the stale temp file should be removed", func() {
+				So(err, ShouldBeNil)
+				So(removed, ShouldResemble, []string{stale})
+				So(stale, shouldNotExist)
+				_, err = os.Stat(recent)
+				So(err, ShouldBeNil)
+				_, err = os.Stat(other)
+				So(err, ShouldBeNil)
+			})
+		})
+
+		Convey("When the sweeper is started", func() {
+			sweeper.Start(context.TODO(), time.Hour)
+			sweeper.Stop()
+
+			Convey("Then it should clear the directory straight away, whatever the age of its files", func() {
+				So(stale, shouldNotExist)
+				So(recent, shouldNotExist)
+				So(other, shouldNotExist)
+			})
+		})
+	})
+}
+
+func shouldNotExist(actual interface{}, _ ...interface{}) string {
+	if _, err := os.Stat(actual.(string)); !os.IsNotExist(err) {
+		return actual.(string) + " should not exist"
+	}
+	return ""
+}
@@ -0,0 +1,57 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// TimeoutError is the error of an import that ran out of time, either overall or in one stage
+type TimeoutError struct {
+	Stage   string
+	File    string // set when uploading a single file timed out
+	Timeout time.Duration
+	Import  bool // the whole import timed out, rather than just the stage
+	Err     error
+}
+
+func (e *TimeoutError) Error() string {
+	if e.File != "" {
+		return fmt.Sprintf("upload of %s timed out after %s: %v", e.File, e.Timeout, e.Err)
+	}
+	if e.Import {
+		return fmt.Sprintf("import timed out after %s in %s stage: %v", e.Timeout, e.Stage, e.Err)
+	}
+	return fmt.Sprintf("%s stage timed out after %s: %v", e.Stage, e.Timeout, e.Err)
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+func (e *TimeoutError) Is(target error) bool {
+	return target == ErrTimeout
+}
+
+// withTimeout is context.WithTimeout, except that a timeout of zero or less means no timeout
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// stageTimeout wraps err in a TimeoutError if it was down to the deadline of the import or of the stage
+func (h *InteractivesUploadedHandler) stageTimeout(importCtx, stageCtx context.Context, stage string, timeout time.Duration, err error) error {
+	if err == nil {
+		return nil
+	}
+	switch {
+	case errors.Is(importCtx.Err(), context.DeadlineExceeded):
+		return &TimeoutError{Stage: stage, Timeout: h.Cfg.ImportTimeout, Import: true, Err: err}
+	case errors.Is(stageCtx.Err(), context.DeadlineExceeded):
+		return &TimeoutError{Stage: stage, Timeout: timeout, Err: err}
+	}
+	return err
+}
@@ -0,0 +1,43 @@
+package importer
+
+import (
+	kafka "github.com/ONSdigital/dp-kafka/v3"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/ONSdigital/dp-interactives-importer/importer")
+
+// messageCarrier reads trace context propagated in the headers of a kafka message
+type messageCarrier struct {
+	msg kafka.Message
+}
+
+func (c messageCarrier) Get(key string) string {
+	return c.msg.GetHeader(key)
+}
+
+// Set is a no-op, trace context is only ever extracted from a consumed message
+func (c messageCarrier) Set(string, string) {}
+
+func (c messageCarrier) Keys() []string {
+	return nil
+}
+
+// endSpan records the error, if any, on the span and ends it
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func interactiveAttributes(event *InteractivesUploaded) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("interactive.id", event.ID),
+		attribute.String("interactive.path", event.Path),
+	}
+}
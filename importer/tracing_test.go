@@ -0,0 +1,123 @@
+package importer_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ONSdigital/dp-api-clients-go/v2/interactives"
+	"github.com/ONSdigital/dp-api-clients-go/v2/upload"
+	"github.com/ONSdigital/dp-interactives-importer/config"
+	"github.com/ONSdigital/dp-interactives-importer/importer"
+	mocks_importer "github.com/ONSdigital/dp-interactives-importer/importer/mocks"
+	"github.com/ONSdigital/dp-interactives-importer/schema"
+	"github.com/ONSdigital/dp-kafka/v3/kafkatest"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const traceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+var (
+	recorder     *tracetest.SpanRecorder
+	recorderOnce sync.Once
+)
+
+// spanRecorder installs a global tracer provider that records every span, the importer's tracer
+// delegates to whichever provider is installed first so all tests share the one recorder
+func spanRecorder() *tracetest.SpanRecorder {
+	recorderOnce.Do(func() {
+		recorder = tracetest.NewSpanRecorder()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+	})
+	return recorder
+}
+
+func endedSpans(traceID string) map[string]sdktrace.ReadOnlySpan {
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, s := range recorder.Ended() {
+		if s.SpanContext().TraceID().String() == traceID {
+			spans[s.Name()] = s
+		}
+	}
+	return spans
+}
+
+func TestTracing(t *testing.T) {
+	spanRecorder()
+
+	Convey("Given a kafka message carrying trace context", t, func() {
+		data, err := schema.InteractivesUploadedEvent.Marshal(&importer.InteractivesUploaded{ID: "1", Path: "path/to/archive.zip"})
+		So(err, ShouldBeNil)
+		msg, err := kafkatest.NewMessage(data, 0, kafkatest.OptionalHeaders(kafkatest.Headers{"traceparent": traceParent}))
+		So(err, ShouldBeNil)
+
+		mockInteractivesAPI := &mocks_importer.InteractivesAPIClientMock{
+			PatchInteractiveFunc: func(context.Context, string, string, string, interactives.PatchRequest) (interactives.Interactive, error) {
+				return interactives.Interactive{}, nil
+			},
+		}
+
+		Convey("When the archive cannot be downloaded", func() {
+			handler := &importer.InteractivesUploadedHandler{
+				Cfg: &config.Config{BatchSize: 1},
+				S3: &mocks_importer.S3InterfaceMock{
+					GetFunc: func(string) (io.ReadCloser, *int64, error) {
+						return nil, nil, errors.New("s3 unavailable")
+					},
+				},
+				InteractivesAPIClient: mockInteractivesAPI,
+			}
+			So(handler.Handle(context.Background(), 1, msg), ShouldNotBeNil)
+
+			Convey("Then the import is traced under the propagated trace", func() {
+				spans := endedSpans("4bf92f3577b34da6a3ce929d0e0e4736")
+				So(spans, ShouldContainKey, "import")
+				So(spans, ShouldContainKey, "s3 get")
+				So(spans, ShouldContainKey, "finish")
+
+				importSpan := spans["import"]
+				So(importSpan.Parent().SpanID().String(), ShouldEqual, "00f067aa0ba902b7")
+				So(importSpan.Status().Code, ShouldEqual, codes.Error)
+				So(spans["s3 get"].Parent().SpanID(), ShouldEqual, importSpan.SpanContext().SpanID())
+				So(spans["s3 get"].Status().Code, ShouldEqual, codes.Error)
+				So(spans["finish"].Parent().SpanID(), ShouldEqual, importSpan.SpanContext().SpanID())
+			})
+		})
+	})
+
+	Convey("Given a traced context", t, func() {
+		ctx := otel.GetTextMapPropagator().Extract(context.Background(), propagation.MapCarrier{"traceparent": traceParent})
+		svc := importer.NewUploadService(&mocks_importer.UploadServiceBackendMock{
+			UploadFunc: func(context.Context, io.ReadCloser, upload.Metadata) error {
+				return nil
+			},
+		})
+
+		Convey("When a file is sent", func() {
+			f := &importer.File{Name: "index.html", ReadCloser: io.NopCloser(strings.NewReader("content")), SizeInBytes: 7}
+			_, err := svc.SendFile(ctx, getTestEvent("index.html"), f, rootPath)
+			So(err, ShouldBeNil)
+
+			Convey("Then the upload is traced with the file details", func() {
+				span, found := endedSpans("4bf92f3577b34da6a3ce929d0e0e4736")["upload file"]
+				So(found, ShouldBeTrue)
+				attrs := make(map[string]interface{})
+				for _, kv := range span.Attributes() {
+					attrs[string(kv.Key)] = kv.Value.AsInterface()
+				}
+				So(attrs["file.name"], ShouldEqual, "index.html")
+				So(attrs["file.size"], ShouldEqual, int64(7))
+			})
+		})
+	})
+}
@@ -2,9 +2,13 @@ package importer
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/ONSdigital/dp-api-clients-go/v2/upload"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -18,14 +22,89 @@ func NewUploadService(backend UploadServiceBackend) *UploadService {
 	}
 }
 
-//todo handling retries?
+var (
+	ErrDeleteNotSupported = errors.New("upload backend does not support deleting files")
+	ErrCopyNotSupported   = errors.New("upload backend does not support copying files")
+)
 
 type UploadService struct {
 	backend UploadServiceBackend
 }
 
 func (s *UploadService) SendFile(ctx context.Context, event *InteractivesUploaded, f *File, uploadRootPath string) (string, error) {
-	metadata := upload.Metadata{
+	metadata := newMetadata(event, f, uploadRootPath)
+
+	ctx, span := tracer.Start(ctx, "upload file", trace.WithAttributes(
+		attribute.String("file.name", f.Name),
+		attribute.Int64("file.size", f.SizeInBytes),
+		attribute.String("file.mime_type", f.MimeType),
+	))
+	start := time.Now()
+	err := s.backend.Upload(ctx, f.ReadCloser, metadata)
+	fileUploadDuration.Observe(time.Since(start).Seconds())
+	endSpan(span, err)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s/%s", metadata.Path, metadata.FileName), nil
+}
+
+// CopyFile stores the file under the upload root as a copy of the identical file already stored at sourcePath
+func (s *UploadService) CopyFile(ctx context.Context, event *InteractivesUploaded, f *File, sourcePath, uploadRootPath string) (string, error) {
+	copier, ok := s.backend.(UploadCopier)
+	if !ok {
+		return "", ErrCopyNotSupported
+	}
+	metadata := newMetadata(event, f, uploadRootPath)
+
+	ctx, span := tracer.Start(ctx, "copy file", trace.WithAttributes(
+		attribute.String("file.name", f.Name),
+		attribute.String("file.source", sourcePath),
+	))
+	err := copier.Copy(ctx, sourcePath, metadata)
+	endSpan(span, err)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s/%s", metadata.Path, metadata.FileName), nil
+}
+
+// DeleteRoot removes every file uploaded under the root path, if the backend supports it
+func (s *UploadService) DeleteRoot(ctx context.Context, uploadRootPath string) error {
+	deleter, ok := s.backend.(UploadRootDeleter)
+	if !ok {
+		return ErrDeleteNotSupported
+	}
+	return deleter.DeleteRoot(ctx, uploadRootPath)
+}
+
+// CanCopy is true if the backend, rather than only a wrapper around it, can copy stored files
+func (s *UploadService) CanCopy() bool {
+	_, ok := unwrapBackend(s.backend).(UploadCopier)
+	return ok
+}
+
+// CanDeleteRoots is true if the backend, rather than only a wrapper around it, can delete upload roots
+func (s *UploadService) CanDeleteRoots() bool {
+	_, ok := unwrapBackend(s.backend).(UploadRootDeleter)
+	return ok
+}
+
+// unwrapBackend returns the backend that wrappers such as BreakerUploadServiceBackend call through to
+func unwrapBackend(backend UploadServiceBackend) UploadServiceBackend {
+	for {
+		wrapper, ok := backend.(interface{ Unwrap() UploadServiceBackend })
+		if !ok {
+			return backend
+		}
+		backend = wrapper.Unwrap()
+	}
+}
+
+func newMetadata(event *InteractivesUploaded, f *File, uploadRootPath string) upload.Metadata {
+	return upload.Metadata{
 		CollectionID:  &event.CollectionID,
 		Path:          uploadRootPath,
 		IsPublishable: true,
@@ -36,11 +115,4 @@ func (s *UploadService) SendFile(ctx context.Context, event *InteractivesUploade
 		LicenseURL:    licenseURL,
 		FileName:      f.Name,
 	}
-
-	err := s.backend.Upload(ctx, f.ReadCloser, metadata)
-	if err != nil {
-		return "", err
-	}
-
-	return fmt.Sprintf("%s/%s", metadata.Path, metadata.FileName), nil
 }
@@ -0,0 +1,23 @@
+// Package awssession creates the AWS session shared by the service and the producer tool
+package awssession
+
+import (
+	"github.com/ONSdigital/dp-interactives-importer/config"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+)
+
+// New returns a session for the configured region, or for AWS_ENDPOINT when set
+func New(cfg *config.Config) (*session.Session, error) {
+	if cfg.AwsEndpoint != "" {
+		//for local development only - set env var to initialise
+		return session.NewSession(&aws.Config{
+			Endpoint:         aws.String(cfg.AwsEndpoint),
+			Region:           aws.String(cfg.AwsRegion),
+			S3ForcePathStyle: aws.Bool(true),
+			Credentials:      credentials.NewStaticCredentials("na", "na", ""),
+		})
+	}
+	return session.NewSession(&aws.Config{Region: aws.String(cfg.AwsRegion)})
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/ONSdigital/dp-interactives-importer/config"
 	"github.com/ONSdigital/dp-interactives-importer/service"
+	"github.com/ONSdigital/dp-interactives-importer/tracing"
 	"github.com/ONSdigital/log.go/v2/log"
 	"github.com/pkg/errors"
 )
@@ -44,6 +45,19 @@ func run(ctx context.Context) error {
 
 	log.Info(ctx, "configuration loaded", log.Data{"config": cfg})
 
+	shutdownTracing, err := tracing.Init(ctx, cfg)
+	if err != nil {
+		return errors.Wrap(err, "unable to set up tracing")
+	}
+	defer func() {
+		// the exporter may be unreachable, so flushing must not hold up the exit
+		flushCtx, cancel := context.WithTimeout(context.Background(), cfg.GracefulShutdownTimeout)
+		defer cancel()
+		if tracingErr := shutdownTracing(flushCtx); tracingErr != nil {
+			log.Error(ctx, "failed to flush traces", tracingErr)
+		}
+	}()
+
 	// Run the service, providing an error channel for fatal errors
 	svcErrors := make(chan error, 1)
 	svcList := service.NewServiceList(&service.Init{})
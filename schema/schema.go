@@ -11,7 +11,8 @@ var interactivesUploadedEvent = `{
     {"name": "id", "type": "string"},
     {"name": "path", "type": "string"},
     {"name": "title", "type": "string"},
-    {"name": "collection_id", "type": "string"}
+    {"name": "collection_id", "type": "string"},
+    {"name": "version", "type": "string", "default": ""}
   ]
 }`
 
@@ -19,3 +20,40 @@ var interactivesUploadedEvent = `{
 var InteractivesUploadedEvent = &avro.Schema{
 	Definition: interactivesUploadedEvent,
 }
+
+var legacyInteractivesUploadedEvent = `{
+  "type": "record",
+  "name": "interactives-uploaded",
+  "fields": [
+    {"name": "id", "type": "string"},
+    {"name": "path", "type": "string"},
+    {"name": "title", "type": "string"},
+    {"name": "collection_id", "type": "string"}
+  ]
+}`
+
+// LegacyInteractivesUploadedEvent is the Avro schema of interactives uploaded messages sent before the version
+// field was added, which cannot be read with InteractivesUploadedEvent.
+var LegacyInteractivesUploadedEvent = &avro.Schema{
+	Definition: legacyInteractivesUploadedEvent,
+}
+
+var interactivesImportProgressEvent = `{
+  "type": "record",
+  "name": "interactives-import-progress",
+  "fields": [
+    {"name": "id", "type": "string"},
+    {"name": "job_id", "type": "string"},
+    {"name": "stage", "type": "string"},
+    {"name": "files_processed", "type": "long"},
+    {"name": "files_total", "type": "long"},
+    {"name": "bytes_uploaded", "type": "long"},
+    {"name": "archive_bytes", "type": "long"},
+    {"name": "updated_at", "type": "string"}
+  ]
+}`
+
+// InteractivesImportProgressEvent is the Avro schema for the progress of a running import.
+var InteractivesImportProgressEvent = &avro.Schema{
+	Definition: interactivesImportProgressEvent,
+}
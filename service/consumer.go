@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ONSdigital/dp-healthcheck/healthcheck"
+	"github.com/ONSdigital/dp-interactives-importer/api"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+const drainPollInterval = 100 * time.Millisecond
+
+// PauseConsumer stops consuming new import events, imports in flight are left to finish
+func (svc *Service) PauseConsumer(ctx context.Context) error {
+	if err := svc.kafkaConsumer.Stop(); err != nil {
+		return err
+	}
+	svc.setPaused(ctx, true)
+	return nil
+}
+
+// ResumeConsumer starts consuming import events again after a pause or drain
+func (svc *Service) ResumeConsumer(ctx context.Context) error {
+	if err := svc.kafkaConsumer.Start(); err != nil {
+		return err
+	}
+	svc.setPaused(ctx, false)
+	return nil
+}
+
+// DrainConsumer pauses the consumer then blocks until no import is in flight or the context is done
+func (svc *Service) DrainConsumer(ctx context.Context) error {
+	svc.setPaused(ctx, true)
+
+	stopped := make(chan error, 1)
+	go func() {
+		// waits for the handlers of the current kafka session to return
+		stopped <- svc.kafkaConsumer.StopAndWait()
+	}()
+	select {
+	case err := <-stopped:
+		if err != nil {
+			return err
+		}
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	// imports started over http are not part of the kafka session
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	for len(svc.registry.InFlight()) > 0 {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	log.Info(ctx, "kafka consumer drained")
+	return nil
+}
+
+// ConsumerStatus returns the state of the kafka consumer and how many imports are in flight
+func (svc *Service) ConsumerStatus() api.ConsumerStatus {
+	svc.consumerMu.Lock()
+	defer svc.consumerMu.Unlock()
+
+	return api.ConsumerStatus{
+		State:    svc.kafkaConsumer.State().String(),
+		Paused:   svc.pausedAt != nil,
+		PausedAt: svc.pausedAt,
+		InFlight: len(svc.registry.InFlight()),
+	}
+}
+
+// ConsumerStateChecker warns while the consumer is paused, so a pause is visible on the health endpoint
+func (svc *Service) ConsumerStateChecker(_ context.Context, state *healthcheck.CheckState) error {
+	status := svc.ConsumerStatus()
+	if status.Paused {
+		msg := fmt.Sprintf("consumer paused since %s, state %s, %d imports in flight", status.PausedAt.Format(time.RFC3339), status.State, status.InFlight)
+		return state.Update(healthcheck.StatusWarning, msg, 0)
+	}
+	return state.Update(healthcheck.StatusOK, "consumer state "+status.State, 0)
+}
+
+func (svc *Service) setPaused(ctx context.Context, paused bool) {
+	svc.consumerMu.Lock()
+	defer svc.consumerMu.Unlock()
+
+	if paused == (svc.pausedAt != nil) {
+		return
+	}
+	if paused {
+		now := time.Now().UTC()
+		svc.pausedAt = &now
+		log.Info(ctx, "kafka consumer paused")
+	} else {
+		svc.pausedAt = nil
+		log.Info(ctx, "kafka consumer resumed")
+	}
+}
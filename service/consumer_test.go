@@ -0,0 +1,277 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-api-clients-go/v2/interactives"
+	"github.com/ONSdigital/dp-healthcheck/healthcheck"
+	"github.com/ONSdigital/dp-interactives-importer/config"
+	"github.com/ONSdigital/dp-interactives-importer/importer"
+	mocks_importer "github.com/ONSdigital/dp-interactives-importer/importer/mocks"
+	"github.com/ONSdigital/dp-interactives-importer/schema"
+	"github.com/ONSdigital/dp-interactives-importer/service"
+	mocks_service "github.com/ONSdigital/dp-interactives-importer/service/mocks"
+	kafka "github.com/ONSdigital/dp-kafka/v3"
+	"github.com/ONSdigital/dp-kafka/v3/kafkatest"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// testService runs the service against mocks, with an import that blocks downloading its archive until released
+type testService struct {
+	svc      *service.Service
+	consumer *kafkatest.IConsumerGroupMock
+	handler  kafka.Handler
+	checkers map[string]healthcheck.Checker
+	release  chan struct{}
+	started  chan struct{}
+
+	mu    sync.Mutex
+	calls []string
+	state kafka.State
+}
+
+func newTestService(t *testing.T) *testService {
+	cfg, err := config.Get()
+	So(err, ShouldBeNil)
+	cfg.TempDir = t.TempDir()
+	cfg.ImportRetries = 0
+	cfg.PatchRetries = 0
+	cfg.ShutdownDrainTimeout = time.Second
+	cfg.GracefulShutdownTimeout = time.Second
+
+	ts := &testService{
+		checkers: make(map[string]healthcheck.Checker),
+		release:  make(chan struct{}),
+		started:  make(chan struct{}, 1),
+		state:    kafka.Consuming,
+	}
+	ts.consumer = &kafkatest.IConsumerGroupMock{
+		RegisterHandlerFunc: func(_ context.Context, h kafka.Handler) error {
+			ts.handler = h
+			return nil
+		},
+		StartFunc:       func() error { return ts.record("start", kafka.Starting) },
+		StopFunc:        func() error { return ts.record("stop", kafka.Stopping) },
+		StopAndWaitFunc: func() error { return ts.record("stop and wait", kafka.Stopped) },
+		StateFunc: func() kafka.State {
+			ts.mu.Lock()
+			defer ts.mu.Unlock()
+			return ts.state
+		},
+		CloseFunc: func(context.Context, ...kafka.OptFunc) error {
+			return ts.record("close", kafka.Closing)
+		},
+		CheckerFunc: func(context.Context, *healthcheck.CheckState) error { return nil },
+	}
+	s3 := &mocks_importer.S3InterfaceMock{
+		GetFunc: func(string) (io.ReadCloser, *int64, error) {
+			ts.started <- struct{}{}
+			<-ts.release
+			return nil, nil, errors.New("archive not found")
+		},
+		CheckerFunc: func(context.Context, *healthcheck.CheckState) error { return nil },
+	}
+	initMock := &mocks_service.InitialiserMock{
+		DoGetHTTPServerFunc: func(string, http.Handler) service.HTTPServer {
+			return &mocks_service.HTTPServerMock{ListenAndServeFunc: func() error { return nil }}
+		},
+		DoGetHealthCheckFunc: func(*config.Config, string, string, string) (service.HealthChecker, error) {
+			return &mocks_service.HealthCheckerMock{
+				AddCheckFunc: func(name string, checker healthcheck.Checker) error {
+					ts.checkers[name] = checker
+					return nil
+				},
+				StartFunc: func(context.Context) {},
+				StopFunc:  func() {},
+			}, nil
+		},
+		DoGetKafkaConsumerFunc: func(context.Context, *config.Config) (kafka.IConsumerGroup, error) {
+			return ts.consumer, nil
+		},
+		DoGetKafkaLagReaderFunc: func(context.Context, *config.Config) (importer.LagReader, error) {
+			return &mocks_importer.LagReaderMock{}, nil
+		},
+		DoGetS3ClientFunc: func(context.Context, *config.Config) (importer.S3Interface, error) {
+			return s3, nil
+		},
+		DoGetUploadServiceBackendFunc: func(context.Context, *config.Config) (importer.UploadServiceBackend, error) {
+			return &mocks_importer.UploadServiceBackendMock{
+				CheckerFunc: func(context.Context, *healthcheck.CheckState) error { return nil },
+			}, nil
+		},
+		DoGetInteractivesAPIClientFunc: func(context.Context, *config.Config) (importer.InteractivesAPIClient, error) {
+			return &mocks_importer.InteractivesAPIClientMock{
+				PatchInteractiveFunc: func(context.Context, string, string, string, interactives.PatchRequest) (interactives.Interactive, error) {
+					return interactives.Interactive{}, nil
+				},
+				CheckerFunc: func(context.Context, *healthcheck.CheckState) error { return nil },
+			}, nil
+		},
+	}
+
+	ts.svc, err = service.Run(context.Background(), cfg, service.NewServiceList(initMock), "", "", "", make(chan error, 1))
+	So(err, ShouldBeNil)
+	ts.calls = nil
+	return ts
+}
+
+func (ts *testService) record(call string, state kafka.State) error {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	ts.calls = append(ts.calls, call)
+	ts.state = state
+	return nil
+}
+
+func (ts *testService) recorded() []string {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return append([]string(nil), ts.calls...)
+}
+
+// startImport consumes an event whose import blocks until released, returning once it is in flight
+func (ts *testService) startImport() chan error {
+	data, err := schema.InteractivesUploadedEvent.Marshal(&importer.InteractivesUploaded{ID: "1", Path: "a.zip"})
+	So(err, ShouldBeNil)
+	msg, err := kafkatest.NewMessage(data, 0)
+	So(err, ShouldBeNil)
+
+	handled := make(chan error, 1)
+	go func() {
+		handled <- ts.handler(context.Background(), 1, msg)
+	}()
+	<-ts.started
+	return handled
+}
+
+func (ts *testService) checkState() *healthcheck.CheckState {
+	state := healthcheck.NewCheckState("Kafka consumer state")
+	So(ts.checkers["Kafka consumer state"](context.Background(), state), ShouldBeNil)
+	return state
+}
+
+func TestConsumerPauseAndResume(t *testing.T) {
+
+	Convey("Given a running service", t, func() {
+		ts := newTestService(t)
+
+		Convey("Then the consumer state should be healthy", func() {
+			state := ts.checkState()
+			So(state.Status(), ShouldEqual, healthcheck.StatusOK)
+			So(state.StatusCode(), ShouldEqual, 0)
+			So(ts.svc.ConsumerStatus().Paused, ShouldBeFalse)
+		})
+
+		Convey("When the consumer is paused", func() {
+			So(ts.svc.PauseConsumer(context.Background()), ShouldBeNil)
+
+			Convey("Then the consumer should be stopped and the pause reported", func() {
+				So(ts.recorded(), ShouldResemble, []string{"stop"})
+				status := ts.svc.ConsumerStatus()
+				So(status.Paused, ShouldBeTrue)
+				So(status.PausedAt, ShouldNotBeNil)
+				state := ts.checkState()
+				So(state.Status(), ShouldEqual, healthcheck.StatusWarning)
+				So(state.StatusCode(), ShouldEqual, 0)
+			})
+
+			Convey("And it is resumed", func() {
+				So(ts.svc.ResumeConsumer(context.Background()), ShouldBeNil)
+
+				Convey("Then the consumer should be started again and no longer reported paused", func() {
+					So(ts.recorded(), ShouldResemble, []string{"stop", "start"})
+					So(ts.svc.ConsumerStatus().Paused, ShouldBeFalse)
+					So(ts.checkState().Status(), ShouldEqual, healthcheck.StatusOK)
+				})
+			})
+		})
+
+		Convey("When the consumer fails to stop", func() {
+			ts.consumer.StopFunc = func() error { return errors.New("stop failed") }
+
+			Convey("Then it should not be reported paused", func() {
+				So(ts.svc.PauseConsumer(context.Background()), ShouldNotBeNil)
+				So(ts.svc.ConsumerStatus().Paused, ShouldBeFalse)
+			})
+		})
+	})
+}
+
+func TestConsumerDrain(t *testing.T) {
+
+	Convey("Given a running service with an import in flight", t, func() {
+		ts := newTestService(t)
+		handled := ts.startImport()
+		So(ts.svc.ConsumerStatus().InFlight, ShouldEqual, 1)
+
+		Convey("When the consumer is drained before the import finishes", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			err := ts.svc.DrainConsumer(ctx)
+
+			Convey("Then the drain should time out with the consumer stopped and paused", func() {
+				So(errors.Is(err, context.DeadlineExceeded), ShouldBeTrue)
+				So(ts.recorded(), ShouldResemble, []string{"stop and wait"})
+				So(ts.svc.ConsumerStatus().Paused, ShouldBeTrue)
+			})
+			close(ts.release)
+			<-handled
+		})
+
+		Convey("When the consumer is drained and the import finishes", func() {
+			drained := make(chan error, 1)
+			go func() {
+				drained <- ts.svc.DrainConsumer(context.Background())
+			}()
+			close(ts.release)
+			<-handled
+
+			Convey("Then the drain should return once nothing is in flight", func() {
+				So(<-drained, ShouldBeNil)
+				So(ts.svc.ConsumerStatus().InFlight, ShouldEqual, 0)
+				So(ts.svc.ConsumerStatus().Paused, ShouldBeTrue)
+			})
+		})
+	})
+}
+
+func TestServiceCloseDrainsImports(t *testing.T) {
+
+	Convey("Given a running service with an import in flight", t, func() {
+		ts := newTestService(t)
+		handled := ts.startImport()
+
+		Convey("When the service is closed", func() {
+			closed := make(chan error, 1)
+			go func() {
+				closed <- ts.svc.Close(context.Background())
+			}()
+
+			Convey("Then the consumer should be stopped, and only closed once the import has finished", func() {
+				So(waitFor(func() bool { return len(ts.recorded()) > 0 }), ShouldBeTrue)
+				So(ts.recorded(), ShouldResemble, []string{"stop"})
+				close(ts.release)
+				So(<-handled, ShouldNotBeNil)
+				So(<-closed, ShouldBeNil)
+				So(ts.recorded(), ShouldResemble, []string{"stop", "close"})
+			})
+		})
+	})
+}
+
+// waitFor polls the condition for up to a second
+func waitFor(condition func() bool) bool {
+	for i := 0; i < 100; i++ {
+		if condition() {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
@@ -2,12 +2,10 @@ package service
 
 import (
 	"context"
-	"github.com/ONSdigital/dp-api-clients-go/v2/interactives"
+	"errors"
+	"fmt"
 	"github.com/ONSdigital/dp-api-clients-go/v2/upload"
 	mocks_importer "github.com/ONSdigital/dp-interactives-importer/importer/mocks"
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/credentials"
-	"github.com/aws/aws-sdk-go/aws/session"
 	"io"
 	"net/http"
 
@@ -15,6 +13,8 @@ import (
 	"github.com/ONSdigital/dp-healthcheck/healthcheck"
 	"github.com/ONSdigital/dp-interactives-importer/config"
 	"github.com/ONSdigital/dp-interactives-importer/importer"
+	"github.com/ONSdigital/dp-interactives-importer/internal/awssession"
+	"github.com/ONSdigital/dp-interactives-importer/storage"
 	kafka "github.com/ONSdigital/dp-kafka/v3"
 	dphttp "github.com/ONSdigital/dp-net/http"
 	dps3 "github.com/ONSdigital/dp-s3"
@@ -23,6 +23,7 @@ import (
 type ExternalServiceList struct {
 	HealthCheck          bool
 	KafkaConsumer        bool
+	KafkaProducer        bool
 	S3Client             bool
 	UploadServiceBackend bool
 	InteractivesApi      bool
@@ -33,6 +34,7 @@ func NewServiceList(initialiser Initialiser) *ExternalServiceList {
 	return &ExternalServiceList{
 		HealthCheck:          false,
 		KafkaConsumer:        false,
+		KafkaProducer:        false,
 		S3Client:             false,
 		UploadServiceBackend: false,
 		InteractivesApi:      false,
@@ -58,6 +60,21 @@ func (e *ExternalServiceList) GetKafkaConsumer(ctx context.Context, cfg *config.
 	return consumer, nil
 }
 
+// GetKafkaLagReader creates a reader of the kafka consumer group's lag
+func (e *ExternalServiceList) GetKafkaLagReader(ctx context.Context, cfg *config.Config) (importer.LagReader, error) {
+	return e.Init.DoGetKafkaLagReader(ctx, cfg)
+}
+
+// GetKafkaProducer creates a Kafka producer for import progress and sets the producer flag to true
+func (e *ExternalServiceList) GetKafkaProducer(ctx context.Context, cfg *config.Config) (kafka.IProducer, error) {
+	producer, err := e.Init.DoGetKafkaProducer(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	e.KafkaProducer = true
+	return producer, nil
+}
+
 // GetS3Client creates a S3 client and sets the S3Client flag to true
 func (e *ExternalServiceList) GetS3Client(ctx context.Context, cfg *config.Config) (importer.S3Interface, error) {
 	s3, err := e.Init.DoGetS3Client(ctx, cfg)
@@ -114,6 +131,24 @@ func (e *Init) DoGetHTTPServer(bindAddr string, router http.Handler) HTTPServer
 
 // DoGetKafkaConsumer returns a Kafka Consumer group
 func (e *Init) DoGetKafkaConsumer(ctx context.Context, cfg *config.Config) (kafka.IConsumerGroup, error) {
+	return kafka.NewConsumerGroup(ctx, consumerGroupConfig(cfg))
+}
+
+// DoGetKafkaLagReader returns a reader of the consumer group's lag, connecting the same way as the consumer
+func (e *Init) DoGetKafkaLagReader(ctx context.Context, cfg *config.Config) (importer.LagReader, error) {
+	saramaConfig, err := consumerGroupConfig(cfg).Get()
+	if err != nil {
+		return nil, err
+	}
+	return &kafkaLagReader{
+		brokers: cfg.Brokers,
+		topic:   cfg.InteractivesReadTopic,
+		group:   cfg.InteractivesGroup,
+		config:  saramaConfig,
+	}, nil
+}
+
+func consumerGroupConfig(cfg *config.Config) *kafka.ConsumerGroupConfig {
 	kafkaOffset := kafka.OffsetOldest
 
 	cgConfig := &kafka.ConsumerGroupConfig{
@@ -132,37 +167,64 @@ func (e *Init) DoGetKafkaConsumer(ctx context.Context, cfg *config.Config) (kafk
 			cfg.KafkaSecSkipVerify,
 		)
 	}
+	return cgConfig
+}
 
-	return kafka.NewConsumerGroup(ctx, cgConfig)
+// DoGetKafkaProducer returns a Kafka producer for the import progress topic
+func (e *Init) DoGetKafkaProducer(ctx context.Context, cfg *config.Config) (kafka.IProducer, error) {
+	pConfig := &kafka.ProducerConfig{
+		BrokerAddrs:     cfg.Brokers,       // compulsory
+		Topic:           cfg.ProgressTopic, // compulsory
+		KafkaVersion:    &cfg.KafkaVersion,
+		MaxMessageBytes: &cfg.KafkaMaxBytes,
+	}
+	if cfg.KafkaSecProtocol == "TLS" {
+		pConfig.SecurityConfig = kafka.GetSecurityConfig(
+			cfg.KafkaSecCACerts,
+			cfg.KafkaSecClientCert,
+			cfg.KafkaSecClientKey,
+			cfg.KafkaSecSkipVerify,
+		)
+	}
+	return kafka.NewProducer(ctx, pConfig)
 }
 
 // DoGetS3Uploaded returns a S3Client
 func (e *Init) DoGetS3Client(ctx context.Context, cfg *config.Config) (importer.S3Interface, error) {
-	if cfg.AwsEndpoint != "" {
-		//for local development only - set env var to initialise
-		s, err := session.NewSession(&aws.Config{
-			Endpoint:         aws.String(cfg.AwsEndpoint),
-			Region:           aws.String(cfg.AwsRegion),
-			S3ForcePathStyle: aws.Bool(true),
-			Credentials:      credentials.NewStaticCredentials("na", "na", ""),
-		})
-
-		if err != nil {
-			return nil, err
-		}
-
-		return dps3.NewClientWithSession(cfg.DownloadBucketName, s), nil
+	switch cfg.DownloadBackend {
+	case storage.BackendFilesystem:
+		return storage.NewFileBucket(cfg.DownloadDir)
+	case storage.BackendS3, "":
+	default:
+		return nil, fmt.Errorf("unknown download backend: %s", cfg.DownloadBackend)
 	}
 
-	s3Client, err := dps3.NewClient(cfg.AwsRegion, cfg.DownloadBucketName)
+	s, err := awssession.New(cfg)
 	if err != nil {
 		return nil, err
 	}
-	return s3Client, nil
+	return dps3.NewClientWithSession(cfg.DownloadBucketName, s), nil
 }
 
 // DoGetUploadServiceBackend returns an upload service backend
 func (e *Init) DoGetUploadServiceBackend(ctx context.Context, cfg *config.Config) (importer.UploadServiceBackend, error) {
+	switch cfg.UploadBackend {
+	case storage.BackendFilesystem:
+		return storage.NewFileUploadBackend(cfg.UploadDir)
+	case storage.BackendS3:
+		if cfg.UploadBucketName == "" {
+			return nil, errors.New("UPLOAD_BUCKET_NAME must be set for the s3 upload backend")
+		}
+		s, err := awssession.New(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return storage.NewS3UploadBackend(dps3.NewUploaderWithSession(cfg.UploadBucketName, s), cfg.UploadPartSize), nil
+	case storage.BackendUploadService, "":
+	default:
+		return nil, fmt.Errorf("unknown upload backend: %s", cfg.UploadBackend)
+	}
+
 	var apiClient importer.UploadServiceBackend
 	if cfg.UploadAPIURL == "mock" {
 		apiClient = &mocks_importer.UploadServiceBackendMock{
@@ -174,15 +236,14 @@ func (e *Init) DoGetUploadServiceBackend(ctx context.Context, cfg *config.Config
 			},
 		}
 	} else {
-		apiClient = upload.NewAPIClient(cfg.UploadAPIURL, cfg.ServiceAuthToken)
+		apiClient = storage.NewUploadAPIBackend(cfg.UploadAPIURL, cfg.ServiceAuthToken)
 	}
 	return apiClient, nil
 }
 
 // DoGetInteractivesApiClient returns an interactives api client
 func (e *Init) DoGetInteractivesAPIClient(ctx context.Context, cfg *config.Config) (importer.InteractivesAPIClient, error) {
-	apiClient := interactives.NewAPIClient(cfg.InteractivesAPIURL, "v1")
-	return apiClient, nil
+	return importer.NewStatusInteractivesAPIClient(cfg.InteractivesAPIURL), nil
 }
 
 // DoGetHealthClient creates a new Health Client for the provided name and url
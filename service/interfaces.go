@@ -19,6 +19,8 @@ import (
 type Initialiser interface {
 	DoGetHTTPServer(bindAddr string, router http.Handler) HTTPServer
 	DoGetKafkaConsumer(ctx context.Context, cfg *config.Config) (kafka.IConsumerGroup, error)
+	DoGetKafkaLagReader(ctx context.Context, cfg *config.Config) (importer.LagReader, error)
+	DoGetKafkaProducer(ctx context.Context, cfg *config.Config) (kafka.IProducer, error)
 	DoGetHealthClient(name, url string) *health.Client
 	DoGetHealthCheck(cfg *config.Config, buildTime, gitCommit, version string) (HealthChecker, error)
 	DoGetS3Client(ctx context.Context, cfg *config.Config) (importer.S3Interface, error)
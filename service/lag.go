@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ONSdigital/dp-interactives-importer/importer"
+	"github.com/Shopify/sarama"
+)
+
+// kafkaLagReader compares the committed offsets of the consumer group with the newest offset of each partition.
+// It connects on first use, so a broker that is down at startup is left to the consumer's own health check, and
+// keeps the connection for every later check.
+type kafkaLagReader struct {
+	brokers []string
+	topic   string
+	group   string
+	config  *sarama.Config
+
+	mu       sync.Mutex // held for a whole read, a partition can only be consumed once at a time
+	client   sarama.Client
+	admin    sarama.ClusterAdmin // owns client, closing it closes both
+	consumer sarama.Consumer     // reads the timestamp of the oldest unprocessed message
+}
+
+func (r *kafkaLagReader) connect() error {
+	if r.admin != nil {
+		return nil
+	}
+	client, err := sarama.NewClient(r.brokers, r.config)
+	if err != nil {
+		return err
+	}
+	admin, err := sarama.NewClusterAdminFromClient(client)
+	if err != nil {
+		client.Close()
+		return err
+	}
+	consumer, err := sarama.NewConsumerFromClient(client)
+	if err != nil {
+		admin.Close()
+		return err
+	}
+	r.client, r.admin, r.consumer = client, admin, consumer
+	return nil
+}
+
+func (r *kafkaLagReader) Lag(ctx context.Context) ([]importer.PartitionLag, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.connect(); err != nil {
+		return nil, err
+	}
+	client, admin := r.client, r.admin
+
+	partitions, err := client.Partitions(r.topic)
+	if err != nil {
+		return nil, err
+	}
+	committed, err := admin.ListConsumerGroupOffsets(r.group, map[string][]int32{r.topic: partitions})
+	if err != nil {
+		return nil, err
+	}
+
+	lags := make([]importer.PartitionLag, 0, len(partitions))
+	for _, partition := range partitions {
+		newest, err := client.GetOffset(r.topic, partition, sarama.OffsetNewest)
+		if err != nil {
+			return nil, err
+		}
+
+		next := int64(-1)
+		if block := committed.GetBlock(r.topic, partition); block != nil {
+			next = block.Offset
+		}
+		if next < 0 {
+			// nothing committed yet, the group starts from the oldest message
+			if next, err = client.GetOffset(r.topic, partition, sarama.OffsetOldest); err != nil {
+				return nil, err
+			}
+		}
+
+		lag := importer.PartitionLag{Partition: partition, Lag: newest - next}
+		if lag.Lag > 0 {
+			if lag.OldestUnprocessed, err = r.timestamp(ctx, partition, next); err != nil {
+				return nil, err
+			}
+		}
+		lags = append(lags, lag)
+	}
+	return lags, nil
+}
+
+// timestamp reads the message at offset to find when it was produced
+func (r *kafkaLagReader) timestamp(ctx context.Context, partition int32, offset int64) (t time.Time, err error) {
+	pc, err := r.consumer.ConsumePartition(r.topic, partition, offset)
+	if err != nil {
+		return t, err
+	}
+	defer pc.Close()
+
+	select {
+	case msg := <-pc.Messages():
+		return msg.Timestamp, nil
+	case err := <-pc.Errors():
+		return t, err
+	case <-ctx.Done():
+		return t, ctx.Err()
+	}
+}
+
+func (r *kafkaLagReader) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.admin == nil {
+		return nil
+	}
+	if err := r.consumer.Close(); err != nil {
+		r.admin.Close()
+		return err
+	}
+	return r.admin.Close()
+}
@@ -0,0 +1,84 @@
+package service_test
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-interactives-importer/config"
+	"github.com/ONSdigital/dp-interactives-importer/importer"
+	"github.com/ONSdigital/dp-interactives-importer/service"
+	"github.com/Shopify/sarama"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestKafkaLagReader(t *testing.T) {
+
+	Convey("Given a broker holding a topic of two partitions", t, func() {
+		cfg, err := config.Get()
+		So(err, ShouldBeNil)
+		broker := sarama.NewMockBroker(t, 1)
+		defer broker.Close()
+		cfg.Brokers = []string{broker.Addr()}
+		topic, group := cfg.InteractivesReadTopic, cfg.InteractivesGroup
+		produced := time.Now().Add(-time.Hour).Truncate(time.Millisecond)
+
+		fetch := &sarama.FetchResponse{Version: 4}
+		fetch.AddRecordWithTimestamp(topic, 0, nil, sarama.StringEncoder("event"), 7, produced)
+		fetch.SetLastOffsetDelta(topic, 0, 7)
+		broker.SetHandlerByMap(map[string]sarama.MockResponse{
+			"MetadataRequest": sarama.NewMockMetadataResponse(t).
+				SetController(broker.BrokerID()).
+				SetBroker(broker.Addr(), broker.BrokerID()).
+				SetLeader(topic, 0, broker.BrokerID()).
+				SetLeader(topic, 1, broker.BrokerID()),
+			"FindCoordinatorRequest": sarama.NewMockFindCoordinatorResponse(t).
+				SetCoordinator(sarama.CoordinatorGroup, group, broker),
+			"OffsetFetchRequest": sarama.NewMockOffsetFetchResponse(t).
+				SetOffset(group, topic, 0, 7, "", sarama.ErrNoError),
+			"OffsetRequest": sarama.NewMockOffsetResponse(t).
+				SetOffset(topic, 0, sarama.OffsetNewest, 10).
+				SetOffset(topic, 0, sarama.OffsetOldest, 0).
+				SetOffset(topic, 1, sarama.OffsetNewest, 5).
+				SetOffset(topic, 1, sarama.OffsetOldest, 5),
+			"FetchRequest": sarama.NewMockWrapper(fetch),
+		})
+
+		reader, err := (&service.Init{}).DoGetKafkaLagReader(context.Background(), cfg)
+		So(err, ShouldBeNil)
+		defer reader.(io.Closer).Close()
+
+		Convey("When the lag is read", func() {
+			lags, err := reader.Lag(context.Background())
+
+			Convey("Then each partition should be compared with the committed offset of the group", func() {
+				So(err, ShouldBeNil)
+				So(lags, ShouldHaveLength, 2)
+				So(lags[0].Partition, ShouldEqual, 0)
+				So(lags[0].Lag, ShouldEqual, 3)
+				So(lags[0].OldestUnprocessed.Equal(produced), ShouldBeTrue)
+			})
+
+			Convey("Then a partition with nothing committed should be compared with its oldest offset", func() {
+				So(lags[1], ShouldResemble, importer.PartitionLag{Partition: 1})
+			})
+		})
+
+		Convey("When the lag is read again", func() {
+			_, err := reader.Lag(context.Background())
+			So(err, ShouldBeNil)
+			requests := len(broker.History())
+			lags, err := reader.Lag(context.Background())
+
+			Convey("Then it should not connect again", func() {
+				So(err, ShouldBeNil)
+				So(lags[0].Lag, ShouldEqual, 3)
+				for _, r := range broker.History()[requests:] {
+					_, isMetadata := r.Request.(*sarama.MetadataRequest)
+					So(isMetadata, ShouldBeFalse)
+				}
+			})
+		})
+	})
+}
@@ -20,37 +20,43 @@ var _ service.Initialiser = &InitialiserMock{}
 
 // InitialiserMock is a mock implementation of service.Initialiser.
 //
-// 	func TestSomethingThatUsesInitialiser(t *testing.T) {
+//	func TestSomethingThatUsesInitialiser(t *testing.T) {
 //
-// 		// make and configure a mocked service.Initialiser
-// 		mockedInitialiser := &InitialiserMock{
-// 			DoGetHTTPServerFunc: func(bindAddr string, router http.Handler) service.HTTPServer {
-// 				panic("mock out the DoGetHTTPServer method")
-// 			},
-// 			DoGetHealthCheckFunc: func(cfg *config.Config, buildTime string, gitCommit string, version string) (service.HealthChecker, error) {
-// 				panic("mock out the DoGetHealthCheck method")
-// 			},
-// 			DoGetHealthClientFunc: func(name string, url string) *health.Client {
-// 				panic("mock out the DoGetHealthClient method")
-// 			},
-// 			DoGetInteractivesAPIClientFunc: func(ctx context.Context, cfg *config.Config) (importer.InteractivesAPIClient, error) {
-// 				panic("mock out the DoGetInteractivesAPIClient method")
-// 			},
-// 			DoGetKafkaConsumerFunc: func(ctx context.Context, cfg *config.Config) (kafka.IConsumerGroup, error) {
-// 				panic("mock out the DoGetKafkaConsumer method")
-// 			},
-// 			DoGetS3ClientFunc: func(ctx context.Context, cfg *config.Config) (importer.S3Interface, error) {
-// 				panic("mock out the DoGetS3Client method")
-// 			},
-// 			DoGetUploadServiceBackendFunc: func(ctx context.Context, cfg *config.Config) (importer.UploadServiceBackend, error) {
-// 				panic("mock out the DoGetUploadServiceBackend method")
-// 			},
-// 		}
+//		// make and configure a mocked service.Initialiser
+//		mockedInitialiser := &InitialiserMock{
+//			DoGetHTTPServerFunc: func(bindAddr string, router http.Handler) service.HTTPServer {
+//				panic("mock out the DoGetHTTPServer method")
+//			},
+//			DoGetHealthCheckFunc: func(cfg *config.Config, buildTime string, gitCommit string, version string) (service.HealthChecker, error) {
+//				panic("mock out the DoGetHealthCheck method")
+//			},
+//			DoGetHealthClientFunc: func(name string, url string) *health.Client {
+//				panic("mock out the DoGetHealthClient method")
+//			},
+//			DoGetInteractivesAPIClientFunc: func(ctx context.Context, cfg *config.Config) (importer.InteractivesAPIClient, error) {
+//				panic("mock out the DoGetInteractivesAPIClient method")
+//			},
+//			DoGetKafkaConsumerFunc: func(ctx context.Context, cfg *config.Config) (kafka.IConsumerGroup, error) {
+//				panic("mock out the DoGetKafkaConsumer method")
+//			},
+//			DoGetKafkaLagReaderFunc: func(ctx context.Context, cfg *config.Config) (importer.LagReader, error) {
+//				panic("mock out the DoGetKafkaLagReader method")
+//			},
+//			DoGetKafkaProducerFunc: func(ctx context.Context, cfg *config.Config) (kafka.IProducer, error) {
+//				panic("mock out the DoGetKafkaProducer method")
+//			},
+//			DoGetS3ClientFunc: func(ctx context.Context, cfg *config.Config) (importer.S3Interface, error) {
+//				panic("mock out the DoGetS3Client method")
+//			},
+//			DoGetUploadServiceBackendFunc: func(ctx context.Context, cfg *config.Config) (importer.UploadServiceBackend, error) {
+//				panic("mock out the DoGetUploadServiceBackend method")
+//			},
+//		}
 //
-// 		// use mockedInitialiser in code that requires service.Initialiser
-// 		// and then make assertions.
+//		// use mockedInitialiser in code that requires service.Initialiser
+//		// and then make assertions.
 //
-// 	}
+//	}
 type InitialiserMock struct {
 	// DoGetHTTPServerFunc mocks the DoGetHTTPServer method.
 	DoGetHTTPServerFunc func(bindAddr string, router http.Handler) service.HTTPServer
@@ -67,6 +73,12 @@ type InitialiserMock struct {
 	// DoGetKafkaConsumerFunc mocks the DoGetKafkaConsumer method.
 	DoGetKafkaConsumerFunc func(ctx context.Context, cfg *config.Config) (kafka.IConsumerGroup, error)
 
+	// DoGetKafkaLagReaderFunc mocks the DoGetKafkaLagReader method.
+	DoGetKafkaLagReaderFunc func(ctx context.Context, cfg *config.Config) (importer.LagReader, error)
+
+	// DoGetKafkaProducerFunc mocks the DoGetKafkaProducer method.
+	DoGetKafkaProducerFunc func(ctx context.Context, cfg *config.Config) (kafka.IProducer, error)
+
 	// DoGetS3ClientFunc mocks the DoGetS3Client method.
 	DoGetS3ClientFunc func(ctx context.Context, cfg *config.Config) (importer.S3Interface, error)
 
@@ -114,6 +126,20 @@ type InitialiserMock struct {
 			// Cfg is the cfg argument value.
 			Cfg *config.Config
 		}
+		// DoGetKafkaLagReader holds details about calls to the DoGetKafkaLagReader method.
+		DoGetKafkaLagReader []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Cfg is the cfg argument value.
+			Cfg *config.Config
+		}
+		// DoGetKafkaProducer holds details about calls to the DoGetKafkaProducer method.
+		DoGetKafkaProducer []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Cfg is the cfg argument value.
+			Cfg *config.Config
+		}
 		// DoGetS3Client holds details about calls to the DoGetS3Client method.
 		DoGetS3Client []struct {
 			// Ctx is the ctx argument value.
@@ -134,6 +160,8 @@ type InitialiserMock struct {
 	lockDoGetHealthClient          sync.RWMutex
 	lockDoGetInteractivesAPIClient sync.RWMutex
 	lockDoGetKafkaConsumer         sync.RWMutex
+	lockDoGetKafkaLagReader        sync.RWMutex
+	lockDoGetKafkaProducer         sync.RWMutex
 	lockDoGetS3Client              sync.RWMutex
 	lockDoGetUploadServiceBackend  sync.RWMutex
 }
@@ -158,7 +186,8 @@ func (mock *InitialiserMock) DoGetHTTPServer(bindAddr string, router http.Handle
 
 // DoGetHTTPServerCalls gets all the calls that were made to DoGetHTTPServer.
 // Check the length with:
-//     len(mockedInitialiser.DoGetHTTPServerCalls())
+//
+//	len(mockedInitialiser.DoGetHTTPServerCalls())
 func (mock *InitialiserMock) DoGetHTTPServerCalls() []struct {
 	BindAddr string
 	Router   http.Handler
@@ -197,7 +226,8 @@ func (mock *InitialiserMock) DoGetHealthCheck(cfg *config.Config, buildTime stri
 
 // DoGetHealthCheckCalls gets all the calls that were made to DoGetHealthCheck.
 // Check the length with:
-//     len(mockedInitialiser.DoGetHealthCheckCalls())
+//
+//	len(mockedInitialiser.DoGetHealthCheckCalls())
 func (mock *InitialiserMock) DoGetHealthCheckCalls() []struct {
 	Cfg       *config.Config
 	BuildTime string
@@ -236,7 +266,8 @@ func (mock *InitialiserMock) DoGetHealthClient(name string, url string) *health.
 
 // DoGetHealthClientCalls gets all the calls that were made to DoGetHealthClient.
 // Check the length with:
-//     len(mockedInitialiser.DoGetHealthClientCalls())
+//
+//	len(mockedInitialiser.DoGetHealthClientCalls())
 func (mock *InitialiserMock) DoGetHealthClientCalls() []struct {
 	Name string
 	URL  string
@@ -271,7 +302,8 @@ func (mock *InitialiserMock) DoGetInteractivesAPIClient(ctx context.Context, cfg
 
 // DoGetInteractivesAPIClientCalls gets all the calls that were made to DoGetInteractivesAPIClient.
 // Check the length with:
-//     len(mockedInitialiser.DoGetInteractivesAPIClientCalls())
+//
+//	len(mockedInitialiser.DoGetInteractivesAPIClientCalls())
 func (mock *InitialiserMock) DoGetInteractivesAPIClientCalls() []struct {
 	Ctx context.Context
 	Cfg *config.Config
@@ -306,7 +338,8 @@ func (mock *InitialiserMock) DoGetKafkaConsumer(ctx context.Context, cfg *config
 
 // DoGetKafkaConsumerCalls gets all the calls that were made to DoGetKafkaConsumer.
 // Check the length with:
-//     len(mockedInitialiser.DoGetKafkaConsumerCalls())
+//
+//	len(mockedInitialiser.DoGetKafkaConsumerCalls())
 func (mock *InitialiserMock) DoGetKafkaConsumerCalls() []struct {
 	Ctx context.Context
 	Cfg *config.Config
@@ -321,6 +354,78 @@ func (mock *InitialiserMock) DoGetKafkaConsumerCalls() []struct {
 	return calls
 }
 
+// DoGetKafkaLagReader calls DoGetKafkaLagReaderFunc.
+func (mock *InitialiserMock) DoGetKafkaLagReader(ctx context.Context, cfg *config.Config) (importer.LagReader, error) {
+	if mock.DoGetKafkaLagReaderFunc == nil {
+		panic("InitialiserMock.DoGetKafkaLagReaderFunc: method is nil but Initialiser.DoGetKafkaLagReader was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		Cfg *config.Config
+	}{
+		Ctx: ctx,
+		Cfg: cfg,
+	}
+	mock.lockDoGetKafkaLagReader.Lock()
+	mock.calls.DoGetKafkaLagReader = append(mock.calls.DoGetKafkaLagReader, callInfo)
+	mock.lockDoGetKafkaLagReader.Unlock()
+	return mock.DoGetKafkaLagReaderFunc(ctx, cfg)
+}
+
+// DoGetKafkaLagReaderCalls gets all the calls that were made to DoGetKafkaLagReader.
+// Check the length with:
+//
+//	len(mockedInitialiser.DoGetKafkaLagReaderCalls())
+func (mock *InitialiserMock) DoGetKafkaLagReaderCalls() []struct {
+	Ctx context.Context
+	Cfg *config.Config
+} {
+	var calls []struct {
+		Ctx context.Context
+		Cfg *config.Config
+	}
+	mock.lockDoGetKafkaLagReader.RLock()
+	calls = mock.calls.DoGetKafkaLagReader
+	mock.lockDoGetKafkaLagReader.RUnlock()
+	return calls
+}
+
+// DoGetKafkaProducer calls DoGetKafkaProducerFunc.
+func (mock *InitialiserMock) DoGetKafkaProducer(ctx context.Context, cfg *config.Config) (kafka.IProducer, error) {
+	if mock.DoGetKafkaProducerFunc == nil {
+		panic("InitialiserMock.DoGetKafkaProducerFunc: method is nil but Initialiser.DoGetKafkaProducer was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		Cfg *config.Config
+	}{
+		Ctx: ctx,
+		Cfg: cfg,
+	}
+	mock.lockDoGetKafkaProducer.Lock()
+	mock.calls.DoGetKafkaProducer = append(mock.calls.DoGetKafkaProducer, callInfo)
+	mock.lockDoGetKafkaProducer.Unlock()
+	return mock.DoGetKafkaProducerFunc(ctx, cfg)
+}
+
+// DoGetKafkaProducerCalls gets all the calls that were made to DoGetKafkaProducer.
+// Check the length with:
+//
+//	len(mockedInitialiser.DoGetKafkaProducerCalls())
+func (mock *InitialiserMock) DoGetKafkaProducerCalls() []struct {
+	Ctx context.Context
+	Cfg *config.Config
+} {
+	var calls []struct {
+		Ctx context.Context
+		Cfg *config.Config
+	}
+	mock.lockDoGetKafkaProducer.RLock()
+	calls = mock.calls.DoGetKafkaProducer
+	mock.lockDoGetKafkaProducer.RUnlock()
+	return calls
+}
+
 // DoGetS3Client calls DoGetS3ClientFunc.
 func (mock *InitialiserMock) DoGetS3Client(ctx context.Context, cfg *config.Config) (importer.S3Interface, error) {
 	if mock.DoGetS3ClientFunc == nil {
@@ -341,7 +446,8 @@ func (mock *InitialiserMock) DoGetS3Client(ctx context.Context, cfg *config.Conf
 
 // DoGetS3ClientCalls gets all the calls that were made to DoGetS3Client.
 // Check the length with:
-//     len(mockedInitialiser.DoGetS3ClientCalls())
+//
+//	len(mockedInitialiser.DoGetS3ClientCalls())
 func (mock *InitialiserMock) DoGetS3ClientCalls() []struct {
 	Ctx context.Context
 	Cfg *config.Config
@@ -376,7 +482,8 @@ func (mock *InitialiserMock) DoGetUploadServiceBackend(ctx context.Context, cfg
 
 // DoGetUploadServiceBackendCalls gets all the calls that were made to DoGetUploadServiceBackend.
 // Check the length with:
-//     len(mockedInitialiser.DoGetUploadServiceBackendCalls())
+//
+//	len(mockedInitialiser.DoGetUploadServiceBackendCalls())
 func (mock *InitialiserMock) DoGetUploadServiceBackendCalls() []struct {
 	Ctx context.Context
 	Cfg *config.Config
@@ -2,14 +2,21 @@ package service
 
 import (
 	"context"
+	"io"
 	"net/http"
+	"os"
+	"sync"
+	"time"
 
+	"github.com/ONSdigital/dp-healthcheck/healthcheck"
+	"github.com/ONSdigital/dp-interactives-importer/api"
 	"github.com/ONSdigital/dp-interactives-importer/config"
 	"github.com/ONSdigital/dp-interactives-importer/importer"
 	kafka "github.com/ONSdigital/dp-kafka/v3"
 	"github.com/ONSdigital/log.go/v2/log"
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 // Service contains all the configs, server and clients to run the dp-upload-service API
@@ -18,6 +25,16 @@ type Service struct {
 	serviceList   *ExternalServiceList
 	healthCheck   HealthChecker
 	kafkaConsumer kafka.IConsumerGroup
+	kafkaProducer kafka.IProducer
+	gc            *importer.GarbageCollector
+	reportSender  *importer.ReportSender
+	sweeper       *importer.TempFileSweeper
+	registry      *importer.Registry
+	handler       *importer.InteractivesUploadedHandler
+	lagReader     importer.LagReader
+
+	consumerMu sync.Mutex
+	pausedAt   *time.Time // set while the consumer is paused through the admin endpoints
 }
 
 func Run(ctx context.Context, cfg *config.Config, serviceList *ExternalServiceList, buildTime, gitCommit, version string, svcErrors chan error) (*Service, error) {
@@ -32,6 +49,18 @@ func Run(ctx context.Context, cfg *config.Config, serviceList *ExternalServiceLi
 		log.Fatal(ctx, "failed to initialise kafka consumer", err)
 		return nil, err
 	}
+	svc := &Service{
+		config:        cfg,
+		serviceList:   serviceList,
+		kafkaConsumer: consumer,
+	}
+
+	lagReader, err := serviceList.GetKafkaLagReader(ctx, cfg)
+	if err != nil {
+		log.Fatal(ctx, "failed to initialise kafka lag reader", err)
+		return nil, err
+	}
+	svc.lagReader = lagReader
 
 	s3Client, err := serviceList.GetS3Client(ctx, cfg)
 	if err != nil {
@@ -44,7 +73,6 @@ func Run(ctx context.Context, cfg *config.Config, serviceList *ExternalServiceLi
 		log.Fatal(ctx, "failed to initialise upload service", err)
 		return nil, err
 	}
-	uploadService := importer.NewUploadService(uploadServiceBackend)
 
 	interactivesAPIClient, err := serviceList.GetInteractivesAPIClient(ctx, cfg)
 	if err != nil {
@@ -52,12 +80,119 @@ func Run(ctx context.Context, cfg *config.Config, serviceList *ExternalServiceLi
 		return nil, err
 	}
 
+	// the wrapped clients also back the health checks, which warn while a breaker is open
+	var breakers []*importer.CircuitBreaker
+	if cfg.CircuitBreakerFailures > 0 {
+		uploadBreaker := importer.NewCircuitBreaker("upload", cfg.CircuitBreakerFailures, cfg.CircuitBreakerOpenTimeout)
+		uploadServiceBackend = &importer.BreakerUploadServiceBackend{UploadServiceBackend: uploadServiceBackend, Breaker: uploadBreaker}
+		interactivesBreaker := importer.NewCircuitBreaker("interactives", cfg.CircuitBreakerFailures, cfg.CircuitBreakerOpenTimeout)
+		interactivesAPIClient = &importer.BreakerInteractivesAPIClient{InteractivesAPIClient: interactivesAPIClient, Breaker: interactivesBreaker}
+		breakers = []*importer.CircuitBreaker{uploadBreaker, interactivesBreaker}
+	}
+	uploadService := importer.NewUploadService(uploadServiceBackend)
+	if err = checkCleanup(cfg, uploadService); err != nil {
+		log.Fatal(ctx, "failed to initialise cleanup of failed imports", err)
+		return nil, err
+	}
+
+	if err = os.MkdirAll(cfg.TempDir, 0700); err != nil {
+		log.Fatal(ctx, "failed to create temp dir", err, log.Data{"dir": cfg.TempDir})
+		return nil, err
+	}
+	diskSpace := &importer.DiskSpaceChecker{Dir: cfg.TempDir, MinFreeBytes: cfg.TempDirMinFreeBytes}
+	svc.sweeper = &importer.TempFileSweeper{Dir: cfg.TempDir, MaxAge: cfg.TempFileMaxAge}
+	svc.sweeper.Start(ctx, cfg.TempFileSweepInterval)
+
 	// Event Handler for Kafka Consumer
 	handler := &importer.InteractivesUploadedHandler{
 		Cfg:                   cfg,
 		S3:                    s3Client,
 		UploadService:         uploadService,
 		InteractivesAPIClient: interactivesAPIClient,
+		Registry:              importer.NewRegistry(cfg.ImportHistorySize),
+		Breakers:              breakers,
+	}
+	switch {
+	case cfg.UploadConcurrency > 0 && cfg.UploadConcurrencyAdaptive:
+		handler.Uploads = importer.NewAdaptiveUploadLimiter(cfg.UploadConcurrency, cfg.UploadConcurrencyMin, cfg.UploadLatencyTarget)
+	case cfg.UploadConcurrency > 0:
+		handler.Uploads = importer.NewUploadLimiter(cfg.UploadConcurrency)
+	}
+	if cfg.ProgressEnabled {
+		producer, err := serviceList.GetKafkaProducer(ctx, cfg)
+		if err != nil {
+			log.Fatal(ctx, "failed to initialise kafka progress producer", err)
+			return nil, err
+		}
+		producer.LogErrors(ctx)
+		svc.kafkaProducer = producer
+		handler.Progress = &importer.KafkaProgressPublisher{Producer: producer}
+	}
+	if cfg.DeduplicationEnabled {
+		contentIndex, err := newContentIndex(cfg, uploadService)
+		if err != nil {
+			log.Fatal(ctx, "failed to initialise content index", err, log.Data{"dir": cfg.ContentIndexDir})
+			return nil, err
+		}
+		handler.ContentIndex = contentIndex
+	}
+	if cfg.IncrementalImportEnabled {
+		manifests, err := newManifestStore(cfg, uploadService)
+		if err != nil {
+			log.Fatal(ctx, "failed to initialise manifest store", err, log.Data{"dir": cfg.ManifestDir})
+			return nil, err
+		}
+		handler.Manifests = manifests
+	}
+	if cfg.CheckpointsEnabled {
+		checkpoints, err := newCheckpointStore(cfg)
+		if err != nil {
+			log.Fatal(ctx, "failed to initialise checkpoint store", err, log.Data{"dir": cfg.CheckpointDir})
+			return nil, err
+		}
+		handler.Checkpoints = checkpoints
+	}
+	svc.handler = handler
+	svc.registry = handler.Registry
+	if cfg.UploadRootsDir != "" {
+		uploadRoots, err := importer.NewFileUploadRootStore(cfg.UploadRootsDir)
+		if err != nil {
+			log.Fatal(ctx, "failed to initialise upload root store", err, log.Data{"dir": cfg.UploadRootsDir})
+			return nil, err
+		}
+		handler.UploadRoots = uploadRoots
+	}
+	if cfg.PendingReportsDir != "" {
+		pendingReports, err := importer.NewFilePendingReportStore(cfg.PendingReportsDir)
+		if err != nil {
+			log.Fatal(ctx, "failed to initialise pending report store", err, log.Data{"dir": cfg.PendingReportsDir})
+			return nil, err
+		}
+		handler.PendingReports = pendingReports
+		svc.reportSender = &importer.ReportSender{
+			Reports:               pendingReports,
+			InteractivesAPIClient: interactivesAPIClient,
+			ServiceAuthToken:      cfg.ServiceAuthToken,
+			PatchTimeout:          cfg.PatchTimeout,
+		}
+		svc.reportSender.Start(ctx, cfg.PendingReportsInterval)
+	}
+
+	if cfg.GCEnabled {
+		if err = checkGC(cfg, uploadService); err != nil {
+			log.Fatal(ctx, "failed to initialise garbage collection", err)
+			return nil, err
+		}
+		svc.gc = &importer.GarbageCollector{
+			Roots:                 handler.UploadRoots,
+			UploadService:         uploadService,
+			InteractivesAPIClient: interactivesAPIClient,
+			ContentIndex:          handler.ContentIndex,
+			ServiceAuthToken:      cfg.ServiceAuthToken,
+			Retention:             cfg.GCRetention,
+			DryRun:                cfg.GCDryRun,
+		}
+		svc.gc.Start(ctx, cfg.GCInterval)
 	}
 	err = consumer.RegisterHandler(ctx, handler.Handle)
 	if err != nil {
@@ -76,12 +211,23 @@ func Run(ctx context.Context, cfg *config.Config, serviceList *ExternalServiceLi
 		log.Fatal(ctx, "could not instantiate healthcheck", err)
 		return nil, err
 	}
-	err = registerCheckers(ctx, cfg, hc, consumer, s3Client, uploadServiceBackend, interactivesAPIClient)
+	svc.healthCheck = hc
+	processing := &importer.ProcessingChecker{
+		Registry:          handler.Registry,
+		Lag:               lagReader,
+		MaxLag:            cfg.ConsumerMaxLag,
+		MaxMessageAge:     cfg.ConsumerMaxMessageAge,
+		MaxImportDuration: cfg.MaxImportDuration,
+		ImportTimeout:     cfg.ImportTimeout,
+	}
+	err = registerCheckers(ctx, cfg, hc, consumer, svc.kafkaProducer, svc.ConsumerStateChecker, processing.Checker, diskSpace.Checker, s3Client, uploadServiceBackend, interactivesAPIClient)
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to register checkers")
 	}
 
 	r.StrictSlash(true).Path("/health").Methods(http.MethodGet).HandlerFunc(hc.Handler)
+	r.Path("/metrics").Methods(http.MethodGet).Handler(promhttp.Handler())
+	api.Setup(ctx, cfg, r, handler.Registry, handler, svc)
 	hc.Start(ctx)
 	//healthcheck - end
 
@@ -92,16 +238,73 @@ func Run(ctx context.Context, cfg *config.Config, serviceList *ExternalServiceLi
 		}
 	}()
 
-	return &Service{
-		config:        cfg,
-		serviceList:   serviceList,
-		healthCheck:   hc,
-		kafkaConsumer: consumer,
-	}, nil
+	return svc, nil
+}
+
+// newContentIndex returns the index deduplication copies stored files from. It must outlive the instance,
+// and the backend must be able to copy, as every import serves its files from its own upload root.
+func newContentIndex(cfg *config.Config, uploadService *importer.UploadService) (importer.ContentIndex, error) {
+	if !uploadService.CanCopy() {
+		return nil, errors.Errorf("deduplication needs an upload backend that can copy files, %s cannot", cfg.UploadBackend)
+	}
+	if cfg.ContentIndexDir == "" {
+		return nil, errors.New("deduplication needs CONTENT_INDEX_DIR, a directory kept across deployments")
+	}
+	return importer.NewFileContentIndex(cfg.ContentIndexDir)
+}
+
+// newManifestStore returns the store of the manifest of each import, which incremental imports compare against.
+// It must outlive the instance, and the backend must be able to copy unchanged files into the new upload root.
+func newManifestStore(cfg *config.Config, uploadService *importer.UploadService) (importer.ManifestStore, error) {
+	if !uploadService.CanCopy() {
+		return nil, errors.Errorf("incremental imports need an upload backend that can copy files, %s cannot", cfg.UploadBackend)
+	}
+	if cfg.ManifestDir == "" {
+		return nil, errors.New("incremental imports need MANIFEST_DIR, a directory kept across deployments")
+	}
+	return importer.NewFileManifestStore(cfg.ManifestDir)
 }
 
-// Close gracefully shuts the service down in the required order, with timeout
+func newCheckpointStore(cfg *config.Config) (importer.CheckpointStore, error) {
+	if cfg.CheckpointDir == "" {
+		return nil, errors.New("checkpoints need CHECKPOINT_DIR, a directory kept across deployments")
+	}
+	return importer.NewFileCheckpointStore(cfg.CheckpointDir)
+}
+
+// checkCleanup refuses cleanup of failed imports that cannot delete, or that could delete a live root: a root
+// derived from the event or archive may already be published, which only the recorded upload roots tell
+func checkCleanup(cfg *config.Config, uploadService *importer.UploadService) error {
+	if !cfg.CleanupFailedImports {
+		return nil
+	}
+	if !uploadService.CanDeleteRoots() {
+		return errors.Errorf("cleanup of failed imports needs an upload backend that can delete files, %s cannot", cfg.UploadBackend)
+	}
+	fixedRoot := cfg.UploadRootStrategy != importer.RootStrategyRandom && cfg.UploadRootStrategy != ""
+	if fixedRoot && cfg.UploadRootsDir == "" {
+		return errors.Errorf("cleanup of failed imports with the %s upload root strategy needs UPLOAD_ROOTS_DIR", cfg.UploadRootStrategy)
+	}
+	return nil
+}
+
+// checkGC refuses garbage collection that could never remove anything: it needs the upload roots recorded across
+// deployments and, unless it is a dry run, a backend that can delete them
+func checkGC(cfg *config.Config, uploadService *importer.UploadService) error {
+	if cfg.UploadRootsDir == "" {
+		return errors.New("garbage collection needs UPLOAD_ROOTS_DIR, a directory kept across deployments")
+	}
+	if !cfg.GCDryRun && !uploadService.CanDeleteRoots() {
+		return errors.Errorf("garbage collection needs an upload backend that can delete files, %s cannot", cfg.UploadBackend)
+	}
+	return nil
+}
+
+// Close gracefully shuts the service down in the required order, with timeout. Running imports are drained
+// first, with their own timeout, so the shutdown timeout only covers closing everything else.
 func (svc *Service) Close(ctx context.Context) error {
+	svc.drain(ctx)
+
 	timeout := svc.config.GracefulShutdownTimeout
 	log.Info(ctx, "commencing graceful shutdown", log.Data{"graceful_shutdown_timeout": timeout})
 	ctx, cancel := context.WithTimeout(ctx, timeout)
@@ -118,6 +321,18 @@ func (svc *Service) Close(ctx context.Context) error {
 			svc.healthCheck.Stop()
 		}
 
+		if svc.gc != nil {
+			svc.gc.Stop()
+		}
+
+		if svc.reportSender != nil {
+			svc.reportSender.Stop()
+		}
+
+		if svc.sweeper != nil {
+			svc.sweeper.Stop()
+		}
+
 		if svc.serviceList.KafkaConsumer {
 			if err := svc.kafkaConsumer.Close(ctx); err != nil {
 				log.Error(ctx, "error closing Kafka consumer", err)
@@ -125,6 +340,20 @@ func (svc *Service) Close(ctx context.Context) error {
 			}
 		}
 
+		if svc.serviceList.KafkaProducer {
+			if err := svc.kafkaProducer.Close(ctx); err != nil {
+				log.Error(ctx, "error closing Kafka producer", err)
+				hasShutdownError = true
+			}
+		}
+
+		if closer, ok := svc.lagReader.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				log.Error(ctx, "error closing kafka lag reader", err)
+				hasShutdownError = true
+			}
+		}
+
 		if !hasShutdownError {
 			gracefulShutdown = true
 		}
@@ -143,10 +372,38 @@ func (svc *Service) Close(ctx context.Context) error {
 	return nil
 }
 
+// drain stops intake of new events then waits for running imports, interrupting any still running after the
+// drain timeout so their events are redelivered
+func (svc *Service) drain(ctx context.Context) {
+	if svc.serviceList.KafkaConsumer {
+		if err := svc.kafkaConsumer.Stop(); err != nil {
+			log.Warn(ctx, "failed to stop kafka consumer before draining imports", log.Data{"error": err.Error()})
+		}
+	}
+	if svc.handler == nil {
+		return
+	}
+
+	timeout := svc.config.ShutdownDrainTimeout
+	logData := log.Data{"shutdown_drain_timeout": timeout, "in_flight": len(svc.registry.InFlight())}
+	log.Info(ctx, "waiting for in-flight imports to finish", logData)
+	drainCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	if err := svc.handler.Shutdown(drainCtx); err != nil {
+		log.Warn(ctx, "in-flight imports interrupted, their events will be redelivered", logData)
+		return
+	}
+	log.Info(ctx, "in-flight imports finished")
+}
+
 func registerCheckers(ctx context.Context,
 	cfg *config.Config,
 	hc HealthChecker,
 	consumer kafka.IConsumerGroup,
+	producer kafka.IProducer, // nil unless progress is enabled
+	consumerState healthcheck.Checker,
+	processing healthcheck.Checker,
+	diskSpace healthcheck.Checker,
 	s3 importer.S3Interface,
 	uploadServiceBackend importer.UploadServiceBackend,
 	interactivesAPIClient importer.InteractivesAPIClient) (err error) {
@@ -158,6 +415,28 @@ func registerCheckers(ctx context.Context,
 		log.Error(ctx, "error adding check for kafka consumer", err, log.Data{"group": cfg.InteractivesGroup, "topic": cfg.InteractivesReadTopic})
 	}
 
+	if producer != nil {
+		if err = hc.AddCheck("Kafka producer", producer.Checker); err != nil {
+			hasErrors = true
+			log.Error(ctx, "error adding check for kafka producer", err, log.Data{"topic": cfg.ProgressTopic})
+		}
+	}
+
+	if err = hc.AddCheck("Kafka consumer state", consumerState); err != nil {
+		hasErrors = true
+		log.Error(ctx, "error adding check for kafka consumer state", err)
+	}
+
+	if err = hc.AddCheck("Import processing", processing); err != nil {
+		hasErrors = true
+		log.Error(ctx, "error adding check for import processing", err)
+	}
+
+	if err = hc.AddCheck("Temp disk space", diskSpace); err != nil {
+		hasErrors = true
+		log.Error(ctx, "error adding check for temp disk space", err, log.Data{"dir": cfg.TempDir})
+	}
+
 	if err = hc.AddCheck("S3 bucket", s3.Checker); err != nil {
 		hasErrors = true
 		log.Error(ctx, "error adding check for s3", err)
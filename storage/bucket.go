@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ONSdigital/dp-healthcheck/healthcheck"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// FileBucket stands in for the S3 bucket that archives are downloaded from, mapping each key to a file under a local
+// directory, so a full import can run without S3 or localstack
+type FileBucket struct {
+	dir string
+}
+
+func NewFileBucket(dir string) (*FileBucket, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileBucket{dir: dir}, nil
+}
+
+// Get opens the file of the key, failing as S3 would with a 404 if there is none
+func (b *FileBucket) Get(key string) (io.ReadCloser, *int64, error) {
+	name, ok := b.path(key)
+	if !ok {
+		return nil, nil, noSuchKey(key, nil)
+	}
+	f, err := os.Open(name)
+	if os.IsNotExist(err) {
+		return nil, nil, noSuchKey(key, err)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	if info.IsDir() {
+		f.Close()
+		return nil, nil, noSuchKey(key, nil)
+	}
+	size := info.Size()
+	return f, &size, nil
+}
+
+// Put copies content to the file of the key, for tools that stage archives before sending an event
+func (b *FileBucket) Put(key string, content io.Reader) error {
+	name, ok := b.path(key)
+	if !ok {
+		return fmt.Errorf("key %s is outside the bucket directory", key)
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+	return writeFile(name, func(w io.Writer) error {
+		_, err := io.Copy(w, content)
+		return err
+	})
+}
+
+// Checker reports whether the bucket directory can be read
+func (b *FileBucket) Checker(_ context.Context, state *healthcheck.CheckState) error {
+	f, err := os.Open(b.dir)
+	if err == nil {
+		// also fails if it is not a directory
+		_, err = f.Readdirnames(1)
+		f.Close()
+		if err == io.EOF {
+			err = nil
+		}
+	}
+	if err != nil {
+		return state.Update(healthcheck.StatusCritical, fmt.Sprintf("cannot read %s: %s", b.dir, err), 0)
+	}
+	return state.Update(healthcheck.StatusOK, fmt.Sprintf("reading archives from %s", b.dir), 0)
+}
+
+// path maps a key to a file under the directory, refusing any that would escape it
+func (b *FileBucket) path(key string) (string, bool) {
+	name := filepath.Join(b.dir, filepath.FromSlash(key))
+	rel, err := filepath.Rel(b.dir, name)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return name, true
+}
+
+func noSuchKey(key string, err error) error {
+	return awserr.NewRequestFailure(awserr.New(s3.ErrCodeNoSuchKey, "no file for key "+key, err), http.StatusNotFound, "")
+}
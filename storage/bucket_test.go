@@ -0,0 +1,92 @@
+package storage_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/dp-healthcheck/healthcheck"
+	"github.com/ONSdigital/dp-interactives-importer/storage"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestFileBucket(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Given a filesystem bucket holding an archive", t, func() {
+		dir := t.TempDir()
+		bucket, err := storage.NewFileBucket(dir)
+		So(err, ShouldBeNil)
+		So(os.MkdirAll(filepath.Join(dir, "uploads"), 0o755), ShouldBeNil)
+		So(os.WriteFile(filepath.Join(dir, "uploads", "single.zip"), []byte("archive"), 0o644), ShouldBeNil)
+		shouldBeNoSuchKey := func(actual interface{}, _ ...interface{}) string {
+			reqErr, ok := actual.(awserr.RequestFailure)
+			if !ok {
+				return "expected an aws request failure"
+			}
+			if msg := ShouldEqual(reqErr.StatusCode(), http.StatusNotFound); msg != "" {
+				return msg
+			}
+			return ShouldEqual(reqErr.Code(), s3.ErrCodeNoSuchKey)
+		}
+
+		Convey("When its key is read", func() {
+			r, size, err := bucket.Get("uploads/single.zip")
+			So(err, ShouldBeNil)
+			defer r.Close()
+
+			Convey("Then its content and size should be returned", func() {
+				b, err := io.ReadAll(r)
+				So(err, ShouldBeNil)
+				So(string(b), ShouldEqual, "archive")
+				So(*size, ShouldEqual, 7)
+			})
+		})
+
+		Convey("Then a missing key should fail as S3 would", func() {
+			_, _, err := bucket.Get("uploads/missing.zip")
+			So(err, shouldBeNoSuchKey)
+		})
+
+		Convey("Then a directory or a key escaping the directory should not be found", func() {
+			_, _, err := bucket.Get("uploads")
+			So(err, shouldBeNoSuchKey)
+			So(os.WriteFile(filepath.Join(filepath.Dir(dir), "outside.zip"), []byte("x"), 0o644), ShouldBeNil)
+			_, _, err = bucket.Get("../outside.zip")
+			So(err, shouldBeNoSuchKey)
+		})
+
+		Convey("When an archive is put", func() {
+			So(bucket.Put("staged/other.zip", strings.NewReader("other")), ShouldBeNil)
+
+			Convey("Then it should be read back from its key", func() {
+				r, _, err := bucket.Get("staged/other.zip")
+				So(err, ShouldBeNil)
+				defer r.Close()
+				b, err := io.ReadAll(r)
+				So(err, ShouldBeNil)
+				So(string(b), ShouldEqual, "other")
+			})
+		})
+
+		Convey("Then putting outside the directory should be refused", func() {
+			So(bucket.Put("../escaped.zip", strings.NewReader("x")), ShouldNotBeNil)
+		})
+
+		Convey("Then the health check should pass while the directory is readable", func() {
+			state := healthcheck.NewCheckState("S3")
+			So(bucket.Checker(ctx, state), ShouldBeNil)
+			So(state.Status(), ShouldEqual, healthcheck.StatusOK)
+
+			So(os.RemoveAll(dir), ShouldBeNil)
+			So(bucket.Checker(ctx, state), ShouldBeNil)
+			So(state.Status(), ShouldEqual, healthcheck.StatusCritical)
+		})
+	})
+}
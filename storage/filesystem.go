@@ -0,0 +1,177 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ONSdigital/dp-api-clients-go/v2/upload"
+	"github.com/ONSdigital/dp-healthcheck/healthcheck"
+	"github.com/ONSdigital/dp-interactives-importer/importer"
+)
+
+// MetadataSuffix ends the name of the sidecar file holding the metadata of an uploaded file
+const MetadataSuffix = ".metadata.json"
+
+// Metadata is what the upload service is told about a file, as stored alongside it
+type Metadata struct {
+	CollectionID  string    `json:"collection_id,omitempty"`
+	Path          string    `json:"path"`
+	FileName      string    `json:"file_name"`
+	Title         string    `json:"title"`
+	IsPublishable bool      `json:"is_publishable"`
+	FileSizeBytes int64     `json:"file_size_bytes"`
+	FileType      string    `json:"file_type"`
+	License       string    `json:"license"`
+	LicenseURL    string    `json:"license_url"`
+	UploadedAt    time.Time `json:"uploaded_at"`
+}
+
+func newMetadata(m upload.Metadata) Metadata {
+	md := Metadata{
+		Path:          m.Path,
+		FileName:      m.FileName,
+		Title:         m.Title,
+		IsPublishable: m.IsPublishable,
+		FileSizeBytes: m.FileSizeBytes,
+		FileType:      m.FileType,
+		License:       m.License,
+		LicenseURL:    m.LicenseURL,
+		UploadedAt:    time.Now().UTC(),
+	}
+	if m.CollectionID != nil {
+		md.CollectionID = *m.CollectionID
+	}
+	return md
+}
+
+// FileUploadBackend stores uploaded files under a local directory instead of sending them to the upload service,
+// each next to a hidden sidecar of its metadata: js/app.js is described by js/.app.js.metadata.json. Hidden files
+// are never imported, so a sidecar cannot clash with a file of an archive.
+type FileUploadBackend struct {
+	dir string
+}
+
+func NewFileUploadBackend(dir string) (*FileUploadBackend, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileUploadBackend{dir: dir}, nil
+}
+
+func (b *FileUploadBackend) Upload(ctx context.Context, fileContent io.ReadCloser, metadata upload.Metadata) error {
+	return b.store(ctx, fileContent, metadata)
+}
+
+// Copy stores a copy of the file at sourcePath, a path returned by an earlier upload, as described by metadata
+func (b *FileUploadBackend) Copy(ctx context.Context, sourcePath string, metadata upload.Metadata) error {
+	source, err := b.path(sourcePath, "")
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return b.store(ctx, f, metadata)
+}
+
+func (b *FileUploadBackend) store(ctx context.Context, content io.Reader, metadata upload.Metadata) error {
+	name, err := b.path(metadata.Path, metadata.FileName)
+	if err != nil {
+		return err
+	}
+	sidecar := MetadataPath(name)
+	if err = os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+
+	// write then rename so a failed upload never leaves a partial file behind
+	if err = writeFile(name, func(w io.Writer) error {
+		_, err := io.Copy(w, &contextReader{ctx: ctx, r: content})
+		return err
+	}); err != nil {
+		return err
+	}
+	return writeFile(sidecar, func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(newMetadata(metadata))
+	})
+}
+
+// DeleteRoot removes every file stored under the upload root
+func (b *FileUploadBackend) DeleteRoot(_ context.Context, uploadRootPath string) error {
+	root, err := b.path(uploadRootPath, "")
+	if err != nil {
+		return err
+	}
+	if root == b.dir {
+		return fmt.Errorf("refusing to delete the whole upload directory %s", b.dir)
+	}
+	return os.RemoveAll(root)
+}
+
+// Checker reports whether files can be written to the upload directory
+func (b *FileUploadBackend) Checker(_ context.Context, state *healthcheck.CheckState) error {
+	f, err := os.CreateTemp(b.dir, ".check_*")
+	if err != nil {
+		return state.Update(healthcheck.StatusCritical, fmt.Sprintf("cannot write to %s: %s", b.dir, err), 0)
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return state.Update(healthcheck.StatusOK, fmt.Sprintf("writing uploads to %s", b.dir), 0)
+}
+
+// MetadataPath returns the sidecar path of a stored file
+func MetadataPath(name string) string {
+	return filepath.Join(filepath.Dir(name), "."+filepath.Base(name)+MetadataSuffix)
+}
+
+// path maps an upload path and file name into the upload directory, refusing any that would escape it
+func (b *FileUploadBackend) path(uploadPath, fileName string) (string, error) {
+	name := filepath.Join(b.dir, filepath.FromSlash(uploadPath), filepath.FromSlash(fileName))
+	rel, err := filepath.Rel(b.dir, name)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", &importer.ImportError{
+			Category: importer.CategoryPolicyViolation,
+			Err:      fmt.Errorf("%s is outside the upload directory", filepath.Join(uploadPath, fileName)),
+		}
+	}
+	return name, nil
+}
+
+func writeFile(name string, write func(io.Writer) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".upload_*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err = write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+// contextReader stops a copy once its context is done
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
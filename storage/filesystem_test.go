@@ -0,0 +1,132 @@
+package storage_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/dp-api-clients-go/v2/upload"
+	"github.com/ONSdigital/dp-healthcheck/healthcheck"
+	"github.com/ONSdigital/dp-interactives-importer/importer"
+	"github.com/ONSdigital/dp-interactives-importer/storage"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestFileUploadBackend(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Given a filesystem upload backend", t, func() {
+		dir := t.TempDir()
+		backend, err := storage.NewFileUploadBackend(dir)
+		So(err, ShouldBeNil)
+		collection := "collection-1"
+		metadata := upload.Metadata{
+			CollectionID:  &collection,
+			Path:          "interactives/1/root",
+			FileName:      "js/app.js",
+			Title:         "An interactive",
+			IsPublishable: true,
+			FileSizeBytes: 5,
+			FileType:      "text/javascript",
+		}
+		send := func(content string) error {
+			return backend.Upload(ctx, io.NopCloser(strings.NewReader(content)), metadata)
+		}
+
+		Convey("When a file is uploaded", func() {
+			So(send("hello"), ShouldBeNil)
+			name := filepath.Join(dir, "interactives", "1", "root", "js", "app.js")
+
+			Convey("Then it should be written under its upload path", func() {
+				b, err := os.ReadFile(name)
+				So(err, ShouldBeNil)
+				So(string(b), ShouldEqual, "hello")
+			})
+
+			Convey("Then its metadata should be written next to it", func() {
+				b, err := os.ReadFile(filepath.Join(dir, "interactives", "1", "root", "js", ".app.js"+storage.MetadataSuffix))
+				So(err, ShouldBeNil)
+				var md storage.Metadata
+				So(json.Unmarshal(b, &md), ShouldBeNil)
+				So(md.CollectionID, ShouldEqual, "collection-1")
+				So(md.FileName, ShouldEqual, "js/app.js")
+				So(md.FileType, ShouldEqual, "text/javascript")
+				So(md.IsPublishable, ShouldBeTrue)
+				So(md.UploadedAt.IsZero(), ShouldBeFalse)
+			})
+
+			Convey("Then uploading it again should replace it", func() {
+				So(send("bye"), ShouldBeNil)
+				b, err := os.ReadFile(name)
+				So(err, ShouldBeNil)
+				So(string(b), ShouldEqual, "bye")
+			})
+
+			Convey("Then copying it should store the same content under another root with its own metadata", func() {
+				copied := metadata
+				copied.Path = "interactives/2/root"
+				copied.Title = "Another interactive"
+				So(backend.Copy(ctx, "interactives/1/root/js/app.js", copied), ShouldBeNil)
+				b, err := os.ReadFile(filepath.Join(dir, "interactives", "2", "root", "js", "app.js"))
+				So(err, ShouldBeNil)
+				So(string(b), ShouldEqual, "hello")
+				b, err = os.ReadFile(filepath.Join(dir, "interactives", "2", "root", "js", ".app.js"+storage.MetadataSuffix))
+				So(err, ShouldBeNil)
+				var md storage.Metadata
+				So(json.Unmarshal(b, &md), ShouldBeNil)
+				So(md.Title, ShouldEqual, "Another interactive")
+			})
+
+			Convey("Then copying a missing file should fail", func() {
+				So(backend.Copy(ctx, "interactives/1/root/js/missing.js", metadata), ShouldNotBeNil)
+			})
+
+			Convey("Then deleting its upload root should remove it", func() {
+				So(backend.DeleteRoot(ctx, "interactives/1/root"), ShouldBeNil)
+				_, err := os.Stat(name)
+				So(errors.Is(err, os.ErrNotExist), ShouldBeTrue)
+				_, err = os.Stat(filepath.Join(dir, "interactives", "1"))
+				So(err, ShouldBeNil)
+			})
+		})
+
+		Convey("Then a file name escaping the upload directory should be refused as a policy violation", func() {
+			metadata.FileName = "../../../../escaped.js"
+			err := send("hello")
+			So(importer.CategoryOf(err), ShouldEqual, importer.CategoryPolicyViolation)
+			_, statErr := os.Stat(filepath.Join(filepath.Dir(dir), "escaped.js"))
+			So(errors.Is(statErr, os.ErrNotExist), ShouldBeTrue)
+		})
+
+		Convey("Then deleting the whole upload directory should be refused", func() {
+			So(backend.DeleteRoot(ctx, ""), ShouldNotBeNil)
+			So(backend.DeleteRoot(ctx, "../"+filepath.Base(dir)), ShouldNotBeNil)
+			_, err := os.Stat(dir)
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Then an upload cancelled part way should leave no file behind", func() {
+			cancelled, cancel := context.WithCancel(ctx)
+			cancel()
+			err := backend.Upload(cancelled, io.NopCloser(strings.NewReader("hello")), metadata)
+			So(errors.Is(err, context.Canceled), ShouldBeTrue)
+			_, err = os.Stat(filepath.Join(dir, "interactives", "1", "root", "js", "app.js"))
+			So(errors.Is(err, os.ErrNotExist), ShouldBeTrue)
+		})
+
+		Convey("Then the health check should pass while the directory is writable", func() {
+			state := healthcheck.NewCheckState("Upload API")
+			So(backend.Checker(ctx, state), ShouldBeNil)
+			So(state.Status(), ShouldEqual, healthcheck.StatusOK)
+
+			So(os.RemoveAll(dir), ShouldBeNil)
+			So(backend.Checker(ctx, state), ShouldBeNil)
+			So(state.Status(), ShouldEqual, healthcheck.StatusCritical)
+		})
+	})
+}
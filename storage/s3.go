@@ -0,0 +1,160 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ONSdigital/dp-api-clients-go/v2/upload"
+	"github.com/ONSdigital/dp-healthcheck/healthcheck"
+	dps3 "github.com/ONSdigital/dp-s3"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+)
+
+// S3 limits on the length of a tag value and on the objects removed by a single delete
+const (
+	maxTagValueLength = 256
+	deleteBatchSize   = 1000
+)
+
+// tagValueDisallowed matches the characters S3 does not accept in a tag value
+var tagValueDisallowed = regexp.MustCompile(`[^\p{L}\p{N}\s+\-=._:/@]`)
+
+// S3UploadBackend writes each file to an S3 bucket, at its upload path, rather than sending it through the upload
+// service. Large files go up as multipart uploads of partSize, and the metadata is kept as object tags.
+type S3UploadBackend struct {
+	uploader *dps3.Uploader
+	client   s3iface.S3API // copies, lists and deletes the objects of an upload root
+	partSize int64
+}
+
+// NewS3UploadBackend returns a backend writing to the bucket of the uploader, a partSize below the S3 minimum of
+// 5MiB uses the minimum
+func NewS3UploadBackend(uploader *dps3.Uploader, partSize int64) *S3UploadBackend {
+	if partSize < s3manager.MinUploadPartSize {
+		partSize = s3manager.MinUploadPartSize
+	}
+	return &S3UploadBackend{
+		uploader: uploader,
+		client:   s3.New(uploader.Session()),
+		partSize: partSize,
+	}
+}
+
+func (b *S3UploadBackend) Upload(ctx context.Context, fileContent io.ReadCloser, metadata upload.Metadata) error {
+	input := &s3manager.UploadInput{
+		Bucket:  aws.String(b.uploader.BucketName()),
+		Key:     aws.String(path.Join(metadata.Path, metadata.FileName)),
+		Body:    fileContent,
+		Tagging: aws.String(Tags(metadata)),
+	}
+	if metadata.FileType != "" {
+		input.ContentType = aws.String(metadata.FileType)
+	}
+	_, err := b.uploader.UploadWithContext(ctx, input, func(u *s3manager.Uploader) {
+		u.PartSize = b.partSize
+	})
+	return err
+}
+
+// Copy stores a copy of the object at sourcePath, a path returned by an earlier upload, replacing its content type
+// and tags with those of metadata. S3 copies objects of up to 5GB this way.
+func (b *S3UploadBackend) Copy(ctx context.Context, sourcePath string, metadata upload.Metadata) error {
+	source := &url.URL{Path: path.Join(b.uploader.BucketName(), strings.TrimPrefix(sourcePath, "/"))}
+	input := &s3.CopyObjectInput{
+		Bucket:            aws.String(b.uploader.BucketName()),
+		Key:               aws.String(path.Join(metadata.Path, metadata.FileName)),
+		CopySource:        aws.String(source.EscapedPath()),
+		MetadataDirective: aws.String(s3.MetadataDirectiveReplace),
+		Tagging:           aws.String(Tags(metadata)),
+		TaggingDirective:  aws.String(s3.TaggingDirectiveReplace),
+	}
+	if metadata.FileType != "" {
+		input.ContentType = aws.String(metadata.FileType)
+	}
+	_, err := b.client.CopyObjectWithContext(ctx, input)
+	return err
+}
+
+// DeleteRoot removes every object stored under the upload root
+func (b *S3UploadBackend) DeleteRoot(ctx context.Context, uploadRootPath string) error {
+	prefix := strings.Trim(uploadRootPath, "/")
+	if prefix == "" {
+		return fmt.Errorf("refusing to delete the whole bucket %s", b.uploader.BucketName())
+	}
+
+	var keys []*s3.ObjectIdentifier
+	err := b.client.ListObjectsV2PagesWithContext(ctx, &s3.ListObjectsV2Input{
+		Bucket: aws.String(b.uploader.BucketName()),
+		Prefix: aws.String(prefix + "/"),
+	}, func(page *s3.ListObjectsV2Output, _ bool) bool {
+		for _, o := range page.Contents {
+			keys = append(keys, &s3.ObjectIdentifier{Key: o.Key})
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	for len(keys) > 0 {
+		batch := keys
+		if len(batch) > deleteBatchSize {
+			batch = keys[:deleteBatchSize]
+		}
+		keys = keys[len(batch):]
+
+		out, err := b.client.DeleteObjectsWithContext(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String(b.uploader.BucketName()),
+			Delete: &s3.Delete{Objects: batch, Quiet: aws.Bool(true)},
+		})
+		if err != nil {
+			return err
+		}
+		if len(out.Errors) > 0 {
+			return fmt.Errorf("failed to delete %d objects under %s, first %s: %s",
+				len(out.Errors), prefix, aws.StringValue(out.Errors[0].Key), aws.StringValue(out.Errors[0].Message))
+		}
+	}
+	return nil
+}
+
+// Checker reports whether the bucket can be reached
+func (b *S3UploadBackend) Checker(ctx context.Context, state *healthcheck.CheckState) error {
+	return b.uploader.Checker(ctx, state)
+}
+
+// Tags encodes the metadata of a file as S3 object tags, replacing the characters S3 does not allow in a value
+func Tags(metadata upload.Metadata) string {
+	tags := url.Values{}
+	set := func(key, value string) {
+		if value == "" {
+			return
+		}
+		value = tagValueDisallowed.ReplaceAllString(value, "_")
+		if r := []rune(value); len(r) > maxTagValueLength {
+			value = string(r[:maxTagValueLength])
+		}
+		tags.Set(key, value)
+	}
+	if metadata.CollectionID != nil {
+		set("collection_id", *metadata.CollectionID)
+	}
+	set("path", metadata.Path)
+	set("file_name", metadata.FileName)
+	set("title", metadata.Title)
+	set("is_publishable", strconv.FormatBool(metadata.IsPublishable))
+	set("file_size_bytes", strconv.FormatInt(metadata.FileSizeBytes, 10))
+	set("file_type", metadata.FileType)
+	set("license", metadata.License)
+	set("license_url", metadata.LicenseURL)
+	return tags.Encode()
+}
@@ -0,0 +1,196 @@
+package storage_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ONSdigital/dp-api-clients-go/v2/upload"
+	"github.com/ONSdigital/dp-healthcheck/healthcheck"
+	"github.com/ONSdigital/dp-interactives-importer/storage"
+	dps3 "github.com/ONSdigital/dp-s3"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/johannesboyne/gofakes3"
+	"github.com/johannesboyne/gofakes3/backend/s3mem"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const testBucket = "interactives"
+
+// fakeS3 runs an in-memory S3 and records the tags sent with each upload request, which it does not keep itself
+type fakeS3 struct {
+	*httptest.Server
+	mu   sync.Mutex
+	tags []string
+}
+
+func newFakeS3(t *testing.T) *fakeS3 {
+	backend := s3mem.New()
+	if err := backend.CreateBucket(testBucket); err != nil {
+		t.Fatal(err)
+	}
+	faker := gofakes3.New(backend)
+	f := &fakeS3{}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if tagging := r.Header.Get("X-Amz-Tagging"); tagging != "" {
+			f.mu.Lock()
+			f.tags = append(f.tags, r.Method+" "+tagging)
+			f.mu.Unlock()
+		}
+		faker.Server().ServeHTTP(w, r)
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeS3) session() *session.Session {
+	return session.Must(session.NewSession(&aws.Config{
+		Endpoint:         aws.String(f.URL),
+		Region:           aws.String("eu-west-2"),
+		S3ForcePathStyle: aws.Bool(true),
+		DisableSSL:       aws.Bool(true),
+		Credentials:      credentials.NewStaticCredentials("id", "secret", ""),
+	}))
+}
+
+func TestS3UploadBackend(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Given an S3 upload backend", t, func() {
+		fake := newFakeS3(t)
+		backend := storage.NewS3UploadBackend(dps3.NewUploaderWithSession(testBucket, fake.session()), 0)
+		client := s3.New(fake.session())
+		collection := "collection-1"
+		metadata := upload.Metadata{
+			CollectionID:  &collection,
+			Path:          "interactives/1/root",
+			FileName:      "index.html",
+			Title:         "Population, 2021 (England & Wales)",
+			IsPublishable: true,
+			FileSizeBytes: 5,
+			FileType:      "text/html",
+			License:       "Open Government Licence v3.0",
+			LicenseURL:    "https://www.nationalarchives.gov.uk/doc/open-government-licence/version/3/",
+		}
+		get := func(key string) (*s3.GetObjectOutput, []byte) {
+			out, err := client.GetObject(&s3.GetObjectInput{Bucket: aws.String(testBucket), Key: aws.String(key)})
+			So(err, ShouldBeNil)
+			defer out.Body.Close()
+			b, err := io.ReadAll(out.Body)
+			So(err, ShouldBeNil)
+			return out, b
+		}
+
+		Convey("When a small file is uploaded", func() {
+			So(backend.Upload(ctx, io.NopCloser(strings.NewReader("hello")), metadata), ShouldBeNil)
+
+			Convey("Then it should be stored at its upload path with its content type", func() {
+				out, b := get("interactives/1/root/index.html")
+				So(string(b), ShouldEqual, "hello")
+				So(aws.StringValue(out.ContentType), ShouldEqual, "text/html")
+			})
+
+			Convey("Then its metadata should be sent as tags", func() {
+				So(fake.tags, ShouldHaveLength, 1)
+				So(fake.tags[0], ShouldStartWith, http.MethodPut+" ")
+				tags, err := url.ParseQuery(strings.TrimPrefix(fake.tags[0], http.MethodPut+" "))
+				So(err, ShouldBeNil)
+				So(tags.Get("collection_id"), ShouldEqual, "collection-1")
+				So(tags.Get("file_name"), ShouldEqual, "index.html")
+				So(tags.Get("title"), ShouldEqual, "Population_ 2021 _England _ Wales_")
+				So(tags.Get("is_publishable"), ShouldEqual, "true")
+				So(tags.Get("license_url"), ShouldEqual, metadata.LicenseURL)
+			})
+		})
+
+		Convey("When an uploaded file is copied to another root", func() {
+			So(backend.Upload(ctx, io.NopCloser(strings.NewReader("hello")), metadata), ShouldBeNil)
+			copied := metadata
+			copied.Path = "interactives/2/root"
+			copied.FileType = "text/plain"
+			So(backend.Copy(ctx, "interactives/1/root/index.html", copied), ShouldBeNil)
+
+			Convey("Then the copy should be stored at its own upload path with its own content type", func() {
+				out, b := get("interactives/2/root/index.html")
+				So(string(b), ShouldEqual, "hello")
+				So(aws.StringValue(out.ContentType), ShouldEqual, "text/plain")
+				_, b = get("interactives/1/root/index.html")
+				So(string(b), ShouldEqual, "hello")
+			})
+
+			Convey("Then its metadata should be sent as tags", func() {
+				So(fake.tags, ShouldHaveLength, 2)
+				tags, err := url.ParseQuery(strings.TrimPrefix(fake.tags[1], http.MethodPut+" "))
+				So(err, ShouldBeNil)
+				So(tags.Get("path"), ShouldEqual, "interactives/2/root")
+			})
+		})
+
+		Convey("When a file larger than a part is uploaded", func() {
+			content := bytes.Repeat([]byte("0123456789"), 600*1024)
+			metadata.FileName = "tiles/big.bin"
+			So(backend.Upload(ctx, io.NopCloser(bytes.NewReader(content)), metadata), ShouldBeNil)
+
+			Convey("Then it should go up as a multipart upload started with its tags", func() {
+				So(fake.tags, ShouldHaveLength, 1)
+				So(fake.tags[0], ShouldStartWith, http.MethodPost+" ")
+				_, b := get("interactives/1/root/tiles/big.bin")
+				So(b, ShouldResemble, content)
+			})
+		})
+
+		Convey("When an upload root is deleted", func() {
+			for _, name := range []string{"index.html", "js/app.js"} {
+				metadata.FileName = name
+				So(backend.Upload(ctx, io.NopCloser(strings.NewReader("x")), metadata), ShouldBeNil)
+			}
+			metadata.Path = "interactives/1/rootless"
+			So(backend.Upload(ctx, io.NopCloser(strings.NewReader("x")), metadata), ShouldBeNil)
+			So(backend.DeleteRoot(ctx, "interactives/1/root"), ShouldBeNil)
+
+			Convey("Then only the objects under that root should be removed", func() {
+				out, err := client.ListObjectsV2(&s3.ListObjectsV2Input{Bucket: aws.String(testBucket)})
+				So(err, ShouldBeNil)
+				So(out.Contents, ShouldHaveLength, 1)
+				So(aws.StringValue(out.Contents[0].Key), ShouldEqual, "interactives/1/rootless/js/app.js")
+			})
+		})
+
+		Convey("Then deleting the whole bucket should be refused", func() {
+			So(backend.DeleteRoot(ctx, "/"), ShouldNotBeNil)
+		})
+
+		Convey("Then the health check should pass while the bucket exists", func() {
+			state := healthcheck.NewCheckState("Upload API")
+			So(backend.Checker(ctx, state), ShouldBeNil)
+			So(state.Status(), ShouldEqual, healthcheck.StatusOK)
+
+			missing := storage.NewS3UploadBackend(dps3.NewUploaderWithSession("missing", fake.session()), 0)
+			So(missing.Checker(ctx, state), ShouldBeNil)
+			So(state.Status(), ShouldEqual, healthcheck.StatusCritical)
+		})
+	})
+}
+
+func TestTags(t *testing.T) {
+
+	Convey("Given file metadata with a very long title", t, func() {
+		metadata := upload.Metadata{Title: strings.Repeat("é", 300)}
+
+		Convey("Then its tag should be cut to the longest value S3 accepts", func() {
+			tags, err := url.ParseQuery(storage.Tags(metadata))
+			So(err, ShouldBeNil)
+			So([]rune(tags.Get("title")), ShouldHaveLength, 256)
+			So(tags.Get("collection_id"), ShouldBeEmpty)
+		})
+	})
+}
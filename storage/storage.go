@@ -0,0 +1,10 @@
+// Package storage holds the alternatives to the upload service for storing imported files, and to S3 for reading
+// archives
+package storage
+
+// Backends, chosen by UPLOAD_BACKEND for storing files and DOWNLOAD_BACKEND for reading archives
+const (
+	BackendUploadService = "upload-service"
+	BackendFilesystem    = "filesystem"
+	BackendS3            = "s3"
+)
@@ -0,0 +1,132 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+
+	dperrors "github.com/ONSdigital/dp-api-clients-go/v2/errors"
+	"github.com/ONSdigital/dp-api-clients-go/v2/health"
+	"github.com/ONSdigital/dp-api-clients-go/v2/upload"
+	"github.com/ONSdigital/dp-healthcheck/healthcheck"
+	"github.com/ONSdigital/dp-interactives-importer/importer"
+	dphttp "github.com/ONSdigital/dp-net/v2/http"
+	dprequest "github.com/ONSdigital/dp-net/v2/request"
+)
+
+const (
+	uploadAPIService = "upload-api"
+	uploadChunkSize  = 5 * 1024 * 1024 // the chunk size of the upload client, which the upload service expects
+)
+
+// UploadAPIBackend sends each file to the upload service in chunks, the same requests as the upload client makes,
+// but keeps the status of a failed request as an importer.StatusError so a busy service can be told from a bad file
+type UploadAPIBackend struct {
+	url       string
+	authToken string
+	client    dphttp.Clienter
+	hcCli     *health.Client
+}
+
+// NewUploadAPIBackend returns a backend sending files to the upload service at uploadAPIURL
+func NewUploadAPIBackend(uploadAPIURL, authToken string) *UploadAPIBackend {
+	return &UploadAPIBackend{
+		url:       uploadAPIURL,
+		authToken: authToken,
+		client:    dphttp.NewClient(),
+		hcCli:     health.NewClient(uploadAPIService, uploadAPIURL),
+	}
+}
+
+func (b *UploadAPIBackend) Upload(ctx context.Context, fileContent io.ReadCloser, metadata upload.Metadata) error {
+	if metadata.FileSizeBytes > upload.MaxFileSize {
+		return upload.ErrFileTooLarge
+	}
+
+	totalChunks := int((metadata.FileSizeBytes + uploadChunkSize - 1) / uploadChunkSize)
+	chunk := make([]byte, uploadChunkSize)
+	for i := 1; i <= totalChunks; i++ {
+		n, err := io.ReadFull(fileContent, chunk)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return err
+		}
+		if err = b.sendChunk(ctx, metadata, i, totalChunks, chunk[:n]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *UploadAPIBackend) sendChunk(ctx context.Context, metadata upload.Metadata, current, total int, content []byte) error {
+	body := &bytes.Buffer{}
+	form := multipart.NewWriter(body)
+	fields := [][2]string{
+		{"resumableFilename", metadata.FileName},
+		{"path", metadata.Path},
+		{"isPublishable", strconv.FormatBool(metadata.IsPublishable)},
+		{"title", metadata.Title},
+		{"resumableTotalSize", strconv.FormatInt(metadata.FileSizeBytes, 10)},
+		{"resumableType", metadata.FileType},
+		{"licence", metadata.License},
+		{"licenceURL", metadata.LicenseURL},
+		{"resumableChunkNumber", strconv.Itoa(current)},
+		{"resumableTotalChunks", strconv.Itoa(total)},
+	}
+	if metadata.CollectionID != nil {
+		fields = append([][2]string{{"collectionId", *metadata.CollectionID}}, fields...)
+	}
+	for _, field := range fields {
+		if err := form.WriteField(field[0], field[1]); err != nil {
+			return err
+		}
+	}
+	part, err := form.CreateFormFile("file", metadata.FileName)
+	if err != nil {
+		return err
+	}
+	if _, err = part.Write(content); err != nil {
+		return err
+	}
+	if err = form.Close(); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.url+"/upload-new", body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	dprequest.AddServiceTokenHeader(req, b.authToken)
+
+	resp, err := b.client.Do(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return responseError(resp)
+}
+
+// responseError is the failure of a chunk the upload service did not accept, with the error the upload client
+// would return for it
+func responseError(resp *http.Response) error {
+	var err error
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+		return nil
+	case http.StatusForbidden:
+		err = upload.ErrNotAuthorized
+	case http.StatusInternalServerError, http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound:
+		err = dperrors.FromBody(resp.Body)
+	default:
+		err = dperrors.NewErrorFromUnhandledStatusCode(uploadAPIService, resp.StatusCode)
+	}
+	return &importer.StatusError{StatusCode: resp.StatusCode, Err: fmt.Errorf("upload rejected: %w", err)}
+}
+
+func (b *UploadAPIBackend) Checker(ctx context.Context, state *healthcheck.CheckState) error {
+	return b.hcCli.Checker(ctx, state)
+}
@@ -0,0 +1,107 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ONSdigital/dp-api-clients-go/v2/upload"
+	"github.com/ONSdigital/dp-interactives-importer/importer"
+	"github.com/ONSdigital/dp-interactives-importer/storage"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestUploadAPIBackend(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Given an upload service", t, func() {
+		var (
+			mu       sync.Mutex
+			requests []*http.Request
+			content  []string
+		)
+		status := http.StatusOK
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			file, _, err := r.FormFile("file")
+			if err == nil {
+				b, _ := io.ReadAll(file)
+				mu.Lock()
+				content = append(content, string(b))
+				mu.Unlock()
+			}
+			mu.Lock()
+			requests = append(requests, r)
+			mu.Unlock()
+			w.WriteHeader(status)
+		}))
+		defer server.Close()
+		backend := storage.NewUploadAPIBackend(server.URL, "token")
+		collection := "collection-1"
+		metadata := upload.Metadata{
+			CollectionID:  &collection,
+			Path:          "interactives/1/root",
+			FileName:      "index.html",
+			Title:         "An interactive",
+			IsPublishable: true,
+			FileSizeBytes: 5,
+			FileType:      "text/html",
+		}
+		send := func() error {
+			return backend.Upload(ctx, io.NopCloser(strings.NewReader("hello")), metadata)
+		}
+
+		Convey("When a file is uploaded", func() {
+			So(send(), ShouldBeNil)
+
+			Convey("Then it should be sent in one chunk with its metadata", func() {
+				So(requests, ShouldHaveLength, 1)
+				r := requests[0]
+				So(r.Method, ShouldEqual, http.MethodPost)
+				So(r.URL.Path, ShouldEqual, "/upload-new")
+				So(r.Header.Get("Authorization"), ShouldEqual, "Bearer token")
+				So(r.FormValue("collectionId"), ShouldEqual, "collection-1")
+				So(r.FormValue("path"), ShouldEqual, "interactives/1/root")
+				So(r.FormValue("resumableFilename"), ShouldEqual, "index.html")
+				So(r.FormValue("resumableChunkNumber"), ShouldEqual, "1")
+				So(r.FormValue("resumableTotalChunks"), ShouldEqual, "1")
+				So(r.FormValue("isPublishable"), ShouldEqual, "true")
+				So(content, ShouldResemble, []string{"hello"})
+			})
+		})
+
+		Convey("When the upload service is unavailable", func() {
+			status = http.StatusServiceUnavailable
+			err := send()
+
+			Convey("Then the failure should keep the status", func() {
+				var statusErr *importer.StatusError
+				So(errors.As(err, &statusErr), ShouldBeTrue)
+				So(statusErr.StatusCode, ShouldEqual, http.StatusServiceUnavailable)
+			})
+		})
+
+		Convey("When the upload is forbidden", func() {
+			status = http.StatusForbidden
+			err := send()
+
+			Convey("Then it should fail as not authorised", func() {
+				So(errors.Is(err, upload.ErrNotAuthorized), ShouldBeTrue)
+			})
+		})
+
+		Convey("When the file is too large", func() {
+			metadata.FileSizeBytes = upload.MaxFileSize + 1
+			err := send()
+
+			Convey("Then it should not be sent", func() {
+				So(errors.Is(err, upload.ErrFileTooLarge), ShouldBeTrue)
+				So(requests, ShouldBeEmpty)
+			})
+		})
+	})
+}